@@ -7,6 +7,7 @@ toolchain go1.24.0
 require (
 	github.com/IBM/sarama v1.42.1
 	github.com/gin-gonic/gin v1.9.1
+	github.com/google/uuid v1.6.0
 	go.mongodb.org/mongo-driver v1.13.1
 )
 
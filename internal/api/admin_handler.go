@@ -0,0 +1,76 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"reminder-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	deadLetters *service.DeadLetterService
+	search      *service.SearchService
+}
+
+func NewAdminHandler(deadLetters *service.DeadLetterService, search *service.SearchService) *AdminHandler {
+	return &AdminHandler{deadLetters: deadLetters, search: search}
+}
+
+// ── Dead Letters ──
+
+func (h *AdminHandler) ListDeadLetters(c *gin.Context) {
+	limit := int64(50)
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 64); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	includeReplayed := c.Query("include_replayed") == "true"
+
+	entries, err := h.deadLetters.List(c.Request.Context(), includeReplayed, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": entries})
+}
+
+func (h *AdminHandler) GetDeadLetter(c *gin.Context) {
+	dl, err := h.deadLetters.Get(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": dl})
+}
+
+func (h *AdminHandler) ReplayDeadLetter(c *gin.Context) {
+	dl, err := h.deadLetters.Replay(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead letter not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": dl})
+}
+
+// ── Search ──
+
+func (h *AdminHandler) ReindexSearch(c *gin.Context) {
+	refreshed, err := h.search.Reindex(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"refreshed": refreshed}})
+}
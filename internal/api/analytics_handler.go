@@ -1,11 +1,19 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"reminder-service/internal/ical"
 	"reminder-service/internal/models"
+	"reminder-service/internal/query"
+	"reminder-service/internal/recurrence"
 	"reminder-service/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +25,7 @@ type AnalyticsHandler struct {
 	activitySvc  *service.ActivityService
 	exportSvc    *service.ExportService
 	reminderSvc  *service.ReminderService
+	authz        *Authorizer
 }
 
 func NewAnalyticsHandler(
@@ -25,6 +34,7 @@ func NewAnalyticsHandler(
 	activitySvc *service.ActivityService,
 	exportSvc *service.ExportService,
 	reminderSvc *service.ReminderService,
+	authz *Authorizer,
 ) *AnalyticsHandler {
 	return &AnalyticsHandler{
 		analyticsSvc: analyticsSvc,
@@ -32,6 +42,7 @@ func NewAnalyticsHandler(
 		activitySvc:  activitySvc,
 		exportSvc:    exportSvc,
 		reminderSvc:  reminderSvc,
+		authz:        authz,
 	}
 }
 
@@ -45,6 +56,33 @@ func (h *AnalyticsHandler) GetWorkspaceAnalytics(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": analytics})
 }
 
+// GetAcknowledgementAnalytics reports acknowledgements over the last
+// ?days= days (30 by default), counting reminders left unacknowledged for
+// more than ?after_mins= minutes (30 by default).
+func (h *AnalyticsHandler) GetAcknowledgementAnalytics(c *gin.Context) {
+	workspaceID := c.Param("workspace_id")
+	days := 30
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 {
+			days = parsed
+		}
+	}
+	afterMins := 30
+	if a := c.Query("after_mins"); a != "" {
+		if parsed, err := strconv.Atoi(a); err == nil && parsed >= 0 {
+			afterMins = parsed
+		}
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	analytics, err := h.analyticsSvc.GetAcknowledgementAnalytics(c.Request.Context(), workspaceID, since, afterMins)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": analytics})
+}
+
 func (h *AnalyticsHandler) GetUpcoming(c *gin.Context) {
 	userID := c.Param("user_id")
 	days := 7
@@ -104,13 +142,34 @@ func (h *AnalyticsHandler) SearchReminders(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	switch {
+	case params.UserID == "" && params.WorkspaceID != "":
+		// No user filter: a search across the whole workspace.
+		if !h.authz.allowWorkspace(c, params.WorkspaceID, service.ActionSearchWorkspace) {
+			return
+		}
+	case params.UserID == "" || params.UserID == callerID(c):
+		params.UserID = callerID(c)
+	default:
+		forbidden(c)
+		return
+	}
+
+	params.Viewer = callerID(c)
 
 	result, err := h.searchSvc.Search(c.Request.Context(), &params)
+	if invalidFilter(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": result.Data, "total": result.Total, "page": result.Page, "per_page": result.PerPage, "total_pages": result.TotalPages})
+	respondPage(c, result)
 }
 
 // ── Activity ──
@@ -149,23 +208,111 @@ func (h *AnalyticsHandler) GetUserActivity(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": activities})
 }
 
+// ExportReminderActivity streams the activity log of one reminder.
+func (h *AnalyticsHandler) ExportReminderActivity(c *gin.Context) {
+	var req models.ActivityExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ReminderID = c.Param("id")
+	h.streamActivity(c, &req)
+}
+
+// ExportUserActivity streams the changes the caller made.
+func (h *AnalyticsHandler) ExportUserActivity(c *gin.Context) {
+	var req models.ActivityExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = callerID(c)
+	h.streamActivity(c, &req)
+}
+
+// ExportActivity streams the whole activity log, filtered by the query, for
+// admins.
+func (h *AnalyticsHandler) ExportActivity(c *gin.Context) {
+	var req models.ActivityExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.streamActivity(c, &req)
+}
+
+// streamActivity writes an activity export as NDJSON (the default) or CSV,
+// reporting in the export trailer whether it finished.
+func (h *AnalyticsHandler) streamActivity(c *gin.Context, req *models.ActivityExportRequest) {
+	if req.Format == "" {
+		req.Format = models.ExportNDJSON
+	}
+	header := c.Writer.Header()
+	header.Set("Content-Type", service.ExportContentType(req.Format))
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="activity-%s.%s"`, time.Now().UTC().Format("20060102"), req.Format))
+	header.Set("Trailer", exportTrailer)
+
+	err := h.activitySvc.Export(c.Request.Context(), req, c.Writer)
+	if err == nil {
+		header.Set(exportTrailer, "complete")
+		return
+	}
+	if c.Writer.Written() {
+		log.Printf("Activity export for user %s failed after streaming began: %v", callerID(c), err)
+		header.Set(exportTrailer, "failed")
+		return
+	}
+
+	header.Del("Content-Type")
+	header.Del("Content-Disposition")
+	header.Del("Trailer")
+	if errors.Is(err, service.ErrInvalidExport) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 // ── Export/Import ──
 
 func (h *AnalyticsHandler) ExportReminders(c *gin.Context) {
-	userID := c.Query("user_id")
-	format := c.Query("format")
-	if format == "" {
-		format = "json"
+	var req models.ExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Format == "" {
+		req.Format = models.ExportJSON
 	}
-	status := c.Query("status")
+	req.Columns = splitList(req.Columns)
+	req.Include = splitList(req.Include)
 
-	req := &models.ExportRequest{
-		UserID: userID,
-		Format: format,
-		Status: status,
+	switch {
+	case req.UserID == "" && req.WorkspaceID != "":
+		if !h.authz.allowWorkspace(c, req.WorkspaceID, service.ActionExportWorkspace) {
+			return
+		}
+	case req.UserID == "" || req.UserID == callerID(c):
+		req.UserID = callerID(c)
+	default:
+		forbidden(c)
+		return
 	}
+	req.Viewer = callerID(c)
 
-	result, err := h.exportSvc.Export(c.Request.Context(), req)
+	if req.Format != models.ExportJSON {
+		h.streamExport(c, &req)
+		return
+	}
+
+	result, err := h.exportSvc.Export(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrInvalidExport) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if invalidFilter(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -173,18 +320,172 @@ func (h *AnalyticsHandler) ExportReminders(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
 }
 
+// exportTrailer reports whether a streamed export finished. A failure after
+// the first row cannot change the status code, so clients must check it.
+const exportTrailer = "X-Export-Status"
+
+// streamExport writes a CSV or NDJSON export with chunked encoding.
+func (h *AnalyticsHandler) streamExport(c *gin.Context, req *models.ExportRequest) {
+	header := c.Writer.Header()
+	header.Set("Content-Type", service.ExportContentType(req.Format))
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="reminders-%s.%s"`, time.Now().UTC().Format("20060102"), req.Format))
+	header.Set("Trailer", exportTrailer)
+
+	err := h.exportSvc.Stream(c.Request.Context(), req, c.Writer, nil)
+	if err == nil {
+		header.Set(exportTrailer, "complete")
+		return
+	}
+	if c.Writer.Written() {
+		log.Printf("Export for user %s failed after streaming began: %v", callerID(c), err)
+		header.Set(exportTrailer, "failed")
+		return
+	}
+
+	header.Del("Content-Type")
+	header.Del("Content-Disposition")
+	header.Del("Trailer")
+	if errors.Is(err, service.ErrInvalidExport) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if invalidFilter(c, err) {
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// invalidFilter answers 400 for an error in a filter expression, with the
+// position of the mistake when there is one. It reports whether err was
+// such an error.
+func invalidFilter(c *gin.Context, err error) bool {
+	if !errors.Is(err, service.ErrInvalidFilter) {
+		return false
+	}
+	body := gin.H{"error": err.Error()}
+	var qerr *query.Error
+	if errors.As(err, &qerr) {
+		body["position"] = qerr.Pos
+	}
+	c.JSON(http.StatusBadRequest, body)
+	return true
+}
+
+// splitList accepts both repeated query parameters and comma-separated
+// values.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+// ImportReminders creates the reminders in the request. The caller must be a
+// member of every workspace they are imported into.
 func (h *AnalyticsHandler) ImportReminders(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	var req models.ImportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	checked := make(map[string]bool)
+	for i := range req.Reminders {
+		req.Reminders[i].UserID = userID
+		workspaceID := req.Reminders[i].WorkspaceID
+		if workspaceID == "" || checked[workspaceID] {
+			continue
+		}
+		if !h.authz.allowWorkspace(c, workspaceID, service.ActionImportReminders) {
+			return
+		}
+		checked[workspaceID] = true
+	}
 
 	result := h.exportSvc.Import(c.Request.Context(), userID, &req)
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
 }
 
+// maxICSUpload bounds the size of an uploaded calendar file.
+const maxICSUpload = 10 << 20
+
+// ImportICS accepts an .ics file, either as the "file" field of a multipart
+// form or as the raw request body. The caller must be a member of the
+// workspace the reminders are imported into.
+func (h *AnalyticsHandler) ImportICS(c *gin.Context) {
+	workspaceID := c.Query("workspace_id")
+	if workspaceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "workspace_id is required"})
+		return
+	}
+	if !h.authz.allowWorkspace(c, workspaceID, service.ActionImportReminders) {
+		return
+	}
+	floating, err := recurrence.LoadLocation(c.Query("timezone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, closeBody, err := uploadedFile(c, maxICSUpload)
+	if err != nil {
+		uploadFailed(c, err)
+		return
+	}
+	defer closeBody()
+
+	result, err := h.exportSvc.ImportICS(c.Request.Context(), callerID(c), workspaceID, floating, body, nil)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		uploadFailed(c, err)
+		return
+	}
+	if errors.Is(err, ical.ErrMalformed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+}
+
+// uploadedFile returns the "file" field of a multipart form, or else the raw
+// request body, reading at most limit bytes.
+func uploadedFile(c *gin.Context, limit int64) (io.Reader, func(), error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	if c.ContentType() != "multipart/form-data" {
+		return c.Request.Body, func() {}, nil
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, func() { file.Close() }, nil
+}
+
+// uploadFailed answers a failed upload with 413 when it was too large and
+// 400 otherwise.
+func uploadFailed(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
 // ── Bulk Extended ──
 
 func (h *AnalyticsHandler) BulkSnooze(c *gin.Context) {
@@ -200,9 +501,15 @@ func (h *AnalyticsHandler) BulkSnooze(c *gin.Context) {
 		return
 	}
 
-	resp := &models.BulkActionResponse{}
-	for _, id := range req.IDs {
-		_, err := h.reminderSvc.Snooze(c.Request.Context(), id, duration)
+	ids, denied, err := h.authz.allowedIDs(c, req.IDs, service.AccessEdit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := &models.BulkActionResponse{Failed: len(denied), Errors: denied}
+	for _, id := range ids {
+		_, err := h.reminderSvc.Snooze(c.Request.Context(), id, duration, callerID(c), models.SnoozeSourceBulk)
 		if err != nil {
 			resp.Failed++
 			resp.Errors = append(resp.Errors, err.Error())
@@ -220,8 +527,14 @@ func (h *AnalyticsHandler) BulkComplete(c *gin.Context) {
 		return
 	}
 
-	resp := &models.BulkActionResponse{}
-	for _, id := range req.IDs {
+	ids, denied, err := h.authz.allowedIDs(c, req.IDs, service.AccessEdit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := &models.BulkActionResponse{Failed: len(denied), Errors: denied}
+	for _, id := range ids {
 		err := h.reminderSvc.Complete(c.Request.Context(), id)
 		if err != nil {
 			resp.Failed++
@@ -1,7 +1,11 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"reminder-service/internal/models"
@@ -19,19 +23,18 @@ func NewCalendarHandler(svc *service.CalendarService) *CalendarHandler {
 }
 
 func (h *CalendarHandler) ExportICal(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 
 	ical, err := h.service.ExportICal(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.Header("Content-Type", "text/calendar")
-	c.String(http.StatusOK, ical)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(ical))
 }
 
 func (h *CalendarHandler) GetFeedURL(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	workspaceID := c.Query("workspace_id")
 
 	var req models.CalendarFeedRequest
@@ -41,6 +44,9 @@ func (h *CalendarHandler) GetFeedURL(c *gin.Context) {
 	}
 
 	sync, err := h.service.GetFeedURL(c.Request.Context(), userID, workspaceID, &req)
+	if viewFailed(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -48,8 +54,84 @@ func (h *CalendarHandler) GetFeedURL(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": sync})
 }
 
+func (h *CalendarHandler) RotateFeed(c *gin.Context) {
+	var req models.CalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sync, err := h.service.RotateFeedToken(c.Request.Context(), callerID(c), req.Provider, req.ViewID)
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": sync})
+}
+
+func (h *CalendarHandler) RevokeFeed(c *gin.Context) {
+	provider := c.Query("provider")
+	if provider == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provider is required"})
+		return
+	}
+
+	err := h.service.RevokeFeed(c.Request.Context(), callerID(c), provider, c.Query("view_id"))
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// Feed serves a calendar subscription. It is not behind Authenticate: the
+// unguessable token in the URL is the credential, since calendar clients
+// cannot send bearer tokens.
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	body, err := h.service.Feed(c.Request.Context(), token)
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=300")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body)
+}
+
+// etagMatches applies the weak comparison of If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *CalendarHandler) SyncCalendar(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 
 	if err := h.service.SyncCalendar(c.Request.Context(), userID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -59,7 +141,7 @@ func (h *CalendarHandler) SyncCalendar(c *gin.Context) {
 }
 
 func (h *CalendarHandler) GetCalendarView(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	startStr := c.Query("start")
 	endStr := c.Query("end")
 
@@ -74,10 +156,27 @@ func (h *CalendarHandler) GetCalendarView(c *gin.Context) {
 		return
 	}
 
-	events, err := h.service.GetCalendarView(c.Request.Context(), userID, start, end)
+	events, err := h.service.GetCalendarView(c.Request.Context(), userID, c.Query("view_id"), start, end)
+	if viewFailed(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": events})
 }
+
+// viewFailed answers errors from opening a saved view as a calendar source.
+// It reports whether err was one of them.
+func viewFailed(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrResourceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+	case errors.Is(err, service.ErrViewForbidden):
+		forbidden(c)
+	default:
+		return invalidFilter(c, err)
+	}
+	return true
+}
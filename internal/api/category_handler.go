@@ -18,7 +18,7 @@ func NewCategoryHandler(svc *service.CategoryService) *CategoryHandler {
 }
 
 func (h *CategoryHandler) CreateCategory(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	workspaceID := c.Query("workspace_id")
 
 	var req models.CreateCategoryRequest
@@ -36,7 +36,7 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 }
 
 func (h *CategoryHandler) ListCategories(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	workspaceID := c.Query("workspace_id")
 
 	categories, err := h.service.List(c.Request.Context(), userID, workspaceID)
@@ -19,7 +19,7 @@ func NewDelegationHandler(svc *service.DelegationService) *DelegationHandler {
 
 func (h *DelegationHandler) Delegate(c *gin.Context) {
 	reminderID := c.Param("id")
-	delegatedBy := c.Query("user_id")
+	delegatedBy := callerID(c)
 
 	var req models.DelegateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"reminder-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeliveryHandler struct {
+	service *service.DeliveryService
+}
+
+func NewDeliveryHandler(svc *service.DeliveryService) *DeliveryHandler {
+	return &DeliveryHandler{service: svc}
+}
+
+// ListDeliveries returns the notifications sent for a reminder, newest
+// first, with what the receipts say about each.
+func (h *DeliveryHandler) ListDeliveries(c *gin.Context) {
+	results, err := h.service.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": results})
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"reminder-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DependencyHandler struct {
+	service *service.DependencyService
+	authz   *Authorizer
+}
+
+func NewDependencyHandler(svc *service.DependencyService, authz *Authorizer) *DependencyHandler {
+	return &DependencyHandler{service: svc, authz: authz}
+}
+
+func (h *DependencyHandler) AddDependency(c *gin.Context) {
+	var req struct {
+		DependsOnID string `json:"depends_on_id" binding:"required"`
+		Type        string `json:"type"`
+		Lag         string `json:"lag"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Depending on a reminder reveals when it is completed, so the caller
+	// must be able to see it.
+	access, err := h.authz.access.ReminderAccess(c.Request.Context(), req.DependsOnID, callerID(c))
+	if errors.Is(err, service.ErrResourceNotFound) || (err == nil && access < service.AccessView) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prerequisite reminder not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	d := &service.ReminderDependency{
+		ReminderID:  c.Param("id"),
+		DependsOnID: req.DependsOnID,
+		Type:        req.Type,
+		Lag:         req.Lag,
+	}
+	err = h.service.Add(c.Request.Context(), d)
+	switch {
+	case errors.Is(err, service.ErrResourceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prerequisite reminder not found"})
+	case errors.Is(err, service.ErrDependencyCycle):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidDependency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusCreated, gin.H{"success": true, "data": d})
+	}
+}
+
+func (h *DependencyHandler) RemoveDependency(c *gin.Context) {
+	err := h.service.Remove(c.Request.Context(), c.Param("id"), c.Param("depId"))
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dependency not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func (h *DependencyHandler) ListDependencies(c *gin.Context) {
+	results, err := h.service.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": results})
+}
+
+// GetGraph returns the dependency DAG around a reminder. Reminders the
+// caller cannot see are listed without their titles.
+func (h *DependencyHandler) GetGraph(c *gin.Context) {
+	graph, err := h.service.Graph(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := make([]string, len(graph.Nodes))
+	for i, n := range graph.Nodes {
+		ids[i] = n.ReminderID
+	}
+	visible, err := h.authz.visible(c, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range graph.Nodes {
+		if !visible[graph.Nodes[i].ReminderID] {
+			graph.Nodes[i].Title = ""
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": graph})
+}
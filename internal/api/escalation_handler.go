@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"reminder-service/internal/models"
@@ -11,14 +12,15 @@ import (
 
 type EscalationHandler struct {
 	service *service.EscalationService
+	authz   *Authorizer
 }
 
-func NewEscalationHandler(svc *service.EscalationService) *EscalationHandler {
-	return &EscalationHandler{service: svc}
+func NewEscalationHandler(svc *service.EscalationService, authz *Authorizer) *EscalationHandler {
+	return &EscalationHandler{service: svc, authz: authz}
 }
 
 func (h *EscalationHandler) CreateRule(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	workspaceID := c.Query("workspace_id")
 
 	var req models.CreateEscalationRequest
@@ -26,8 +28,21 @@ func (h *EscalationHandler) CreateRule(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.WorkspaceWide {
+		if workspaceID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "workspace_id is required"})
+			return
+		}
+		if !h.authz.allowWorkspace(c, workspaceID, service.ActionManageEscalation) {
+			return
+		}
+	}
 
 	rule, err := h.service.CreateRule(c.Request.Context(), userID, workspaceID, &req)
+	if errors.Is(err, service.ErrInvalidEscalationAction) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -36,7 +51,7 @@ func (h *EscalationHandler) CreateRule(c *gin.Context) {
 }
 
 func (h *EscalationHandler) ListRules(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	workspaceID := c.Query("workspace_id")
 
 	rules, err := h.service.ListRules(c.Request.Context(), userID, workspaceID)
@@ -57,6 +72,10 @@ func (h *EscalationHandler) UpdateRule(c *gin.Context) {
 	}
 
 	rule, err := h.service.UpdateRule(c.Request.Context(), ruleID, &req)
+	if errors.Is(err, service.ErrInvalidEscalationAction) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -10,11 +10,12 @@ import (
 )
 
 type Extended2Handler struct {
-	svc *service.Extended2Service
+	svc   *service.Extended2Service
+	authz *Authorizer
 }
 
-func NewExtended2Handler(svc *service.Extended2Service) *Extended2Handler {
-	return &Extended2Handler{svc: svc}
+func NewExtended2Handler(svc *service.Extended2Service, authz *Authorizer) *Extended2Handler {
+	return &Extended2Handler{svc: svc, authz: authz}
 }
 
 func ext2Limit(c *gin.Context) int {
@@ -29,12 +30,6 @@ func ext2Offset(c *gin.Context) int {
 	return o
 }
 
-func ext2UserID(c *gin.Context) string {
-	uid := c.GetHeader("X-User-ID")
-	if uid == "" { uid = c.Query("user_id") }
-	return uid
-}
-
 // ── Attachments ──
 
 func (h *Extended2Handler) AddAttachment(c *gin.Context) {
@@ -54,7 +49,7 @@ func (h *Extended2Handler) AddAttachment(c *gin.Context) {
 		FileURL:    req.FileURL,
 		MimeType:   req.MimeType,
 		Size:       req.Size,
-		UploadedBy: ext2UserID(c),
+		UploadedBy: callerID(c),
 	}
 	if err := h.svc.AddAttachment(c.Request.Context(), att); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -92,7 +87,7 @@ func (h *Extended2Handler) AddComment(c *gin.Context) {
 	}
 	comment := &service.ReminderComment{
 		ReminderID: c.Param("id"),
-		UserID:     ext2UserID(c),
+		UserID:     callerID(c),
 		Content:    req.Content,
 	}
 	if err := h.svc.AddComment(c.Request.Context(), comment); err != nil {
@@ -146,7 +141,7 @@ func (h *Extended2Handler) AddReaction(c *gin.Context) {
 	}
 	r := &service.ReminderReaction{
 		ReminderID: c.Param("id"),
-		UserID:     ext2UserID(c),
+		UserID:     callerID(c),
 		Emoji:      req.Emoji,
 	}
 	if err := h.svc.AddReaction(c.Request.Context(), r); err != nil {
@@ -157,7 +152,7 @@ func (h *Extended2Handler) AddReaction(c *gin.Context) {
 }
 
 func (h *Extended2Handler) RemoveReaction(c *gin.Context) {
-	if err := h.svc.RemoveReaction(c.Request.Context(), c.Param("id"), ext2UserID(c), c.Query("emoji")); err != nil {
+	if err := h.svc.RemoveReaction(c.Request.Context(), c.Param("id"), callerID(c), c.Query("emoji")); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -178,7 +173,7 @@ func (h *Extended2Handler) ListReactions(c *gin.Context) {
 func (h *Extended2Handler) AddWatcher(c *gin.Context) {
 	w := &service.ReminderWatcher{
 		ReminderID: c.Param("id"),
-		UserID:     ext2UserID(c),
+		UserID:     callerID(c),
 	}
 	if err := h.svc.AddWatcher(c.Request.Context(), w); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -188,7 +183,7 @@ func (h *Extended2Handler) AddWatcher(c *gin.Context) {
 }
 
 func (h *Extended2Handler) RemoveWatcher(c *gin.Context) {
-	if err := h.svc.RemoveWatcher(c.Request.Context(), c.Param("id"), ext2UserID(c)); err != nil {
+	if err := h.svc.RemoveWatcher(c.Request.Context(), c.Param("id"), callerID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -250,6 +245,22 @@ func (h *Extended2Handler) SearchByLabel(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.ReminderID
+	}
+	visible, err := h.authz.visible(c, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filtered := []service.ReminderLabel{}
+	for _, r := range results {
+		if visible[r.ReminderID] {
+			filtered = append(filtered, r)
+		}
+	}
+	results = filtered
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": results})
 }
 
@@ -258,7 +269,7 @@ func (h *Extended2Handler) SearchByLabel(c *gin.Context) {
 func (h *Extended2Handler) AddFavorite(c *gin.Context) {
 	f := &service.ReminderFavorite{
 		ReminderID: c.Param("id"),
-		UserID:     ext2UserID(c),
+		UserID:     callerID(c),
 	}
 	if err := h.svc.AddFavorite(c.Request.Context(), f); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -268,7 +279,7 @@ func (h *Extended2Handler) AddFavorite(c *gin.Context) {
 }
 
 func (h *Extended2Handler) RemoveFavorite(c *gin.Context) {
-	if err := h.svc.RemoveFavorite(c.Request.Context(), c.Param("id"), ext2UserID(c)); err != nil {
+	if err := h.svc.RemoveFavorite(c.Request.Context(), c.Param("id"), callerID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -285,7 +296,7 @@ func (h *Extended2Handler) ListFavorites(c *gin.Context) {
 }
 
 func (h *Extended2Handler) IsFavorited(c *gin.Context) {
-	fav, err := h.svc.IsFavorited(c.Request.Context(), c.Param("id"), ext2UserID(c))
+	fav, err := h.svc.IsFavorited(c.Request.Context(), c.Param("id"), callerID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -293,115 +304,6 @@ func (h *Extended2Handler) IsFavorited(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "favorited": fav})
 }
 
-// ── Dependencies ──
-
-func (h *Extended2Handler) AddDependency(c *gin.Context) {
-	var req struct {
-		DependsOnID string `json:"depends_on_id" binding:"required"`
-		Type        string `json:"type"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	d := &service.ReminderDependency{
-		ReminderID:  c.Param("id"),
-		DependsOnID: req.DependsOnID,
-		Type:        req.Type,
-	}
-	if err := h.svc.AddDependency(c.Request.Context(), d); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusCreated, gin.H{"success": true, "data": d})
-}
-
-func (h *Extended2Handler) RemoveDependency(c *gin.Context) {
-	if err := h.svc.RemoveDependency(c.Request.Context(), c.Param("depId")); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"success": true})
-}
-
-func (h *Extended2Handler) ListDependencies(c *gin.Context) {
-	results, err := h.svc.ListDependencies(c.Request.Context(), c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": results})
-}
-
-// ── Locations ──
-
-func (h *Extended2Handler) SetLocation(c *gin.Context) {
-	var req struct {
-		Name      string  `json:"name"`
-		Latitude  float64 `json:"latitude" binding:"required"`
-		Longitude float64 `json:"longitude" binding:"required"`
-		Radius    float64 `json:"radius"`
-		TriggerOn string  `json:"trigger_on"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	loc := &service.ReminderLocation{
-		ReminderID: c.Param("id"),
-		Name:       req.Name,
-		Latitude:   req.Latitude,
-		Longitude:  req.Longitude,
-		Radius:     req.Radius,
-		TriggerOn:  req.TriggerOn,
-	}
-	if err := h.svc.SetLocation(c.Request.Context(), loc); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": loc})
-}
-
-func (h *Extended2Handler) GetLocation(c *gin.Context) {
-	loc, err := h.svc.GetLocation(c.Request.Context(), c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": loc})
-}
-
-func (h *Extended2Handler) RemoveLocation(c *gin.Context) {
-	if err := h.svc.RemoveLocation(c.Request.Context(), c.Param("id")); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"success": true})
-}
-
-func (h *Extended2Handler) ListNearby(c *gin.Context) {
-	lat, _ := strconv.ParseFloat(c.Query("lat"), 64)
-	lon, _ := strconv.ParseFloat(c.Query("lon"), 64)
-	radius, _ := strconv.ParseFloat(c.DefaultQuery("radius", "1000"), 64)
-	results, err := h.svc.ListNearby(c.Request.Context(), lat, lon, radius)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": results})
-}
-
-// ── Snooze History ──
-
-func (h *Extended2Handler) ListSnoozeHistory(c *gin.Context) {
-	results, err := h.svc.ListSnoozeHistory(c.Request.Context(), c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": results})
-}
-
 // ── Quick Actions ──
 
 func (h *Extended2Handler) CreateQuickAction(c *gin.Context) {
@@ -415,7 +317,7 @@ func (h *Extended2Handler) CreateQuickAction(c *gin.Context) {
 		return
 	}
 	qa := &service.ReminderQuickAction{
-		UserID:      ext2UserID(c),
+		UserID:      callerID(c),
 		WorkspaceID: c.Query("workspace_id"),
 		Name:        req.Name,
 		Action:      req.Action,
@@ -429,7 +331,7 @@ func (h *Extended2Handler) CreateQuickAction(c *gin.Context) {
 }
 
 func (h *Extended2Handler) ListQuickActions(c *gin.Context) {
-	results, err := h.svc.ListQuickActions(c.Request.Context(), ext2UserID(c))
+	results, err := h.svc.ListQuickActions(c.Request.Context(), callerID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
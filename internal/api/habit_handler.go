@@ -19,7 +19,7 @@ func NewHabitHandler(svc *service.HabitService) *HabitHandler {
 }
 
 func (h *HabitHandler) CreateHabit(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	workspaceID := c.Query("workspace_id")
 
 	var req models.CreateHabitRequest
@@ -93,7 +93,7 @@ func (h *HabitHandler) DeleteHabit(c *gin.Context) {
 
 func (h *HabitHandler) CompleteHabit(c *gin.Context) {
 	habitID := c.Param("id")
-	userID := c.Query("user_id")
+	userID := callerID(c)
 
 	var req models.HabitCompletionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
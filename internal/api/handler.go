@@ -1,10 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
+	"reminder-service/internal/auth"
 	"reminder-service/internal/models"
+	"reminder-service/internal/recurrence"
 	"reminder-service/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +15,7 @@ import (
 
 type Handler struct {
 	service *service.ReminderService
+	authz   *Authorizer
 }
 
 func RegisterRoutes(
@@ -33,24 +37,45 @@ func RegisterRoutes(
 	timezoneHandler *TimezoneHandler,
 	habitHandler *HabitHandler,
 	ext2Handler *Extended2Handler,
+	adminHandler *AdminHandler,
+	workspaceHandler *WorkspaceHandler,
+	jobHandler *JobHandler,
+	viewHandler *ViewHandler,
+	dependencyHandler *DependencyHandler,
+	locationHandler *LocationHandler,
+	snoozeHandler *SnoozeHandler,
+	parseHandler *ParseHandler,
+	deliveryHandler *DeliveryHandler,
+	verifier *auth.Verifier,
+	authz *Authorizer,
 ) {
-	h := &Handler{service: svc}
+	h := &Handler{service: svc, authz: authz}
+
+	view := authz.Reminder(service.AccessView)
+	edit := authz.Reminder(service.AccessEdit)
+	own := authz.Reminder(service.AccessOwner)
 
 	// Health endpoints
 	router.GET("/health", h.Health)
 	router.GET("/health/ready", h.HealthReady)
 	router.GET("/health/live", h.HealthLive)
 
+	// Calendar subscriptions authenticate with the feed token instead
+	router.GET("/api/v1/calendar/feed/:token", calendarHandler.Feed)
+
 	// API routes
-	api := router.Group("/api/v1")
+	api := router.Group("/api/v1", Authenticate(verifier), RequireSelf())
 	{
 		api.POST("/reminders", h.CreateReminder)
-		api.GET("/reminders/:id", h.GetReminder)
-		api.PUT("/reminders/:id", h.UpdateReminder)
-		api.DELETE("/reminders/:id", h.DeleteReminder)
-		api.POST("/reminders/:id/snooze", h.SnoozeReminder)
-		api.POST("/reminders/:id/cancel", h.CancelReminder)
-		api.POST("/reminders/:id/complete", h.CompleteReminder)
+		api.POST("/reminders/parse", parseHandler.ParseReminder)
+		api.GET("/reminders/:id", view, h.GetReminder)
+		api.PUT("/reminders/:id", edit, h.UpdateReminder)
+		api.DELETE("/reminders/:id", own, h.DeleteReminder)
+		api.POST("/reminders/:id/snooze", edit, h.SnoozeReminder)
+		api.POST("/reminders/:id/cancel", edit, h.CancelReminder)
+		api.POST("/reminders/:id/complete", edit, h.CompleteReminder)
+		api.POST("/reminders/:id/acknowledge", edit, h.AcknowledgeReminder)
+		api.POST("/reminders/:id/archive", edit, h.ArchiveReminder)
 
 		api.POST("/reminders/bulk", h.BulkCreateReminders)
 		api.POST("/reminders/bulk-cancel", h.BulkCancelReminders)
@@ -58,31 +83,32 @@ func RegisterRoutes(
 		api.POST("/reminders/bulk-snooze", analyticsHandler.BulkSnooze)
 		api.POST("/reminders/bulk-complete", analyticsHandler.BulkComplete)
 
-		api.POST("/reminders/:id/tags", tagHandler.TagReminder)
-		api.GET("/reminders/:id/tags", tagHandler.GetReminderTags)
-		api.DELETE("/reminders/:id/tags/:tag_id", tagHandler.UntagReminder)
+		api.POST("/reminders/:id/tags", edit, tagHandler.TagReminder)
+		api.GET("/reminders/:id/tags", view, tagHandler.GetReminderTags)
+		api.DELETE("/reminders/:id/tags/:tag_id", edit, tagHandler.UntagReminder)
 		api.POST("/tags", tagHandler.CreateTag)
-		api.GET("/tags/:id", tagHandler.GetTag)
-		api.PUT("/tags/:id", tagHandler.UpdateTag)
-		api.DELETE("/tags/:id", tagHandler.DeleteTag)
-		api.GET("/tags/:id/reminders", tagHandler.GetRemindersByTag)
+		api.GET("/tags/:id", authz.Owner(service.ResourceTag, "id"), tagHandler.GetTag)
+		api.PUT("/tags/:id", authz.Owner(service.ResourceTag, "id"), tagHandler.UpdateTag)
+		api.DELETE("/tags/:id", authz.Owner(service.ResourceTag, "id"), tagHandler.DeleteTag)
+		api.GET("/tags/:id/reminders", authz.Owner(service.ResourceTag, "id"), tagHandler.GetRemindersByTag)
 		api.POST("/tags/bulk", tagHandler.BulkTag)
 
-		api.POST("/reminders/:id/notes", noteHandler.CreateNote)
-		api.GET("/reminders/:id/notes", noteHandler.GetNotes)
-		api.PUT("/reminders/notes/:note_id", noteHandler.UpdateNote)
-		api.DELETE("/reminders/notes/:note_id", noteHandler.DeleteNote)
+		api.POST("/reminders/:id/notes", edit, noteHandler.CreateNote)
+		api.GET("/reminders/:id/notes", view, noteHandler.GetNotes)
+		api.PUT("/reminders/notes/:note_id", authz.Owner(service.ResourceNote, "note_id"), noteHandler.UpdateNote)
+		api.DELETE("/reminders/notes/:note_id", authz.Owner(service.ResourceNote, "note_id"), noteHandler.DeleteNote)
 
-		api.POST("/reminders/:id/share", sharingHandler.ShareReminder)
-		api.GET("/reminders/:id/shares", sharingHandler.GetSharesByReminder)
-		api.DELETE("/reminders/:id/share", sharingHandler.UnshareReminder)
+		api.POST("/reminders/:id/share", own, sharingHandler.ShareReminder)
+		api.GET("/reminders/:id/shares", own, sharingHandler.GetSharesByReminder)
+		api.DELETE("/reminders/:id/share", own, sharingHandler.UnshareReminder)
 
-		api.GET("/reminders/:id/activity", analyticsHandler.GetReminderActivity)
+		api.GET("/reminders/:id/activity", view, analyticsHandler.GetReminderActivity)
+		api.GET("/reminders/:id/activity/export", view, analyticsHandler.ExportReminderActivity)
 
 		api.POST("/templates", templateHandler.CreateTemplate)
-		api.GET("/templates/:id", templateHandler.GetTemplate)
-		api.PUT("/templates/:id", templateHandler.UpdateTemplate)
-		api.DELETE("/templates/:id", templateHandler.DeleteTemplate)
+		api.GET("/templates/:id", authz.Reader(service.ResourceTemplate, "id"), templateHandler.GetTemplate)
+		api.PUT("/templates/:id", authz.Owner(service.ResourceTemplate, "id"), templateHandler.UpdateTemplate)
+		api.DELETE("/templates/:id", authz.Owner(service.ResourceTemplate, "id"), templateHandler.DeleteTemplate)
 		api.POST("/templates/create-reminder", templateHandler.CreateFromTemplate)
 		api.GET("/templates/popular", templateHandler.GetPopularTemplates)
 
@@ -99,60 +125,85 @@ func RegisterRoutes(
 		api.GET("/users/:user_id/tags", tagHandler.GetUserTags)
 		api.GET("/users/:user_id/shared", sharingHandler.GetSharedWithUser)
 		api.GET("/users/:user_id/activity", analyticsHandler.GetUserActivity)
+		api.GET("/users/:user_id/activity/export", analyticsHandler.ExportUserActivity)
 		api.GET("/channels/:channel_id/reminders", h.GetChannelReminders)
-		api.GET("/workspaces/:workspace_id/reminders", h.GetWorkspaceReminders)
-		api.GET("/workspaces/:workspace_id/analytics", analyticsHandler.GetWorkspaceAnalytics)
+		api.GET("/workspaces/:workspace_id/reminders", authz.Workspace(service.ActionListReminders), h.GetWorkspaceReminders)
+		api.GET("/workspaces/:workspace_id/analytics", authz.Workspace(service.ActionViewAnalytics), analyticsHandler.GetWorkspaceAnalytics)
+		api.GET("/workspaces/:workspace_id/analytics/acknowledgements", authz.Workspace(service.ActionViewAnalytics), analyticsHandler.GetAcknowledgementAnalytics)
+		api.GET("/workspaces/:workspace_id/access-denials", authz.Workspace(service.ActionViewAccessDenial), workspaceHandler.ListAccessDenials)
 
 		api.GET("/search", analyticsHandler.SearchReminders)
 		api.GET("/export", analyticsHandler.ExportReminders)
 		api.POST("/import", analyticsHandler.ImportReminders)
+		api.POST("/import/ics", analyticsHandler.ImportICS)
+
+		// -- Jobs --
+		api.POST("/jobs/export", jobHandler.SubmitExport)
+		api.POST("/jobs/import", jobHandler.SubmitImport)
+		api.GET("/jobs", jobHandler.ListJobs)
+		api.GET("/jobs/:job_id", authz.Owner(service.ResourceJob, "job_id"), jobHandler.GetJob)
+		api.POST("/jobs/:job_id/cancel", authz.Owner(service.ResourceJob, "job_id"), jobHandler.CancelJob)
+		api.GET("/jobs/:job_id/result", authz.Owner(service.ResourceJob, "job_id"), jobHandler.DownloadResult)
+
+		// -- Saved Views --
+		api.POST("/views", viewHandler.CreateView)
+		api.GET("/views", viewHandler.ListViews)
+		api.GET("/views/:view_id", authz.SavedView(service.AccessView), viewHandler.GetView)
+		api.PUT("/views/:view_id", authz.SavedView(service.AccessEdit), viewHandler.UpdateView)
+		api.DELETE("/views/:view_id", authz.Owner(service.ResourceView, "view_id"), viewHandler.DeleteView)
+		api.GET("/views/:view_id/reminders", authz.SavedView(service.AccessView), viewHandler.RunView)
+		api.POST("/views/:view_id/shares", authz.Owner(service.ResourceView, "view_id"), viewHandler.ShareView)
+		api.GET("/views/:view_id/shares", authz.Owner(service.ResourceView, "view_id"), viewHandler.ListViewShares)
+		api.DELETE("/views/:view_id/shares/:shared_with", authz.Owner(service.ResourceView, "view_id"), viewHandler.UnshareView)
 
 		// -- Priority --
-		api.PUT("/reminders/:id/priority", priorityHandler.SetPriority)
+		api.PUT("/reminders/:id/priority", edit, priorityHandler.SetPriority)
 		api.GET("/users/:user_id/reminders/by-priority", priorityHandler.ListByPriority)
 		api.GET("/users/:user_id/reminders/priority-distribution", priorityHandler.GetDistribution)
 
 		// -- Subtasks --
-		api.POST("/reminders/:id/subtasks", subtaskHandler.AddSubtask)
-		api.GET("/reminders/:id/subtasks", subtaskHandler.ListSubtasks)
-		api.PUT("/subtasks/:subtask_id", subtaskHandler.UpdateSubtask)
-		api.DELETE("/subtasks/:subtask_id", subtaskHandler.DeleteSubtask)
-		api.POST("/subtasks/:subtask_id/toggle", subtaskHandler.ToggleSubtask)
+		api.POST("/reminders/:id/subtasks", edit, subtaskHandler.AddSubtask)
+		api.GET("/reminders/:id/subtasks", view, subtaskHandler.ListSubtasks)
+		api.PUT("/subtasks/:subtask_id", authz.Parent(service.ResourceSubtask, "subtask_id", service.AccessEdit), subtaskHandler.UpdateSubtask)
+		api.DELETE("/subtasks/:subtask_id", authz.Parent(service.ResourceSubtask, "subtask_id", service.AccessEdit), subtaskHandler.DeleteSubtask)
+		api.POST("/subtasks/:subtask_id/toggle", authz.Parent(service.ResourceSubtask, "subtask_id", service.AccessEdit), subtaskHandler.ToggleSubtask)
 
 		// -- Calendar Integration --
 		api.GET("/calendar/export", calendarHandler.ExportICal)
 		api.POST("/calendar/feed", calendarHandler.GetFeedURL)
+		api.POST("/calendar/feed/rotate", calendarHandler.RotateFeed)
+		api.DELETE("/calendar/feed", calendarHandler.RevokeFeed)
 		api.POST("/calendar/sync", calendarHandler.SyncCalendar)
 		api.GET("/calendar/view", calendarHandler.GetCalendarView)
 
 		// -- Escalation Rules --
 		api.POST("/escalation-rules", escalationHandler.CreateRule)
 		api.GET("/escalation-rules", escalationHandler.ListRules)
-		api.PUT("/escalation-rules/:id", escalationHandler.UpdateRule)
-		api.DELETE("/escalation-rules/:id", escalationHandler.DeleteRule)
-		api.GET("/reminders/:id/escalation-history", escalationHandler.GetHistory)
+		api.PUT("/escalation-rules/:id", authz.Owner(service.ResourceEscalationRule, "id"), escalationHandler.UpdateRule)
+		api.DELETE("/escalation-rules/:id", authz.Owner(service.ResourceEscalationRule, "id"), escalationHandler.DeleteRule)
+		api.GET("/reminders/:id/escalation-history", view, escalationHandler.GetHistory)
 
 		// -- Categories --
 		api.POST("/categories", categoryHandler.CreateCategory)
 		api.GET("/categories", categoryHandler.ListCategories)
-		api.PUT("/categories/:id", categoryHandler.UpdateCategory)
-		api.DELETE("/categories/:id", categoryHandler.DeleteCategory)
+		api.PUT("/categories/:id", authz.Owner(service.ResourceCategory, "id"), categoryHandler.UpdateCategory)
+		api.DELETE("/categories/:id", authz.Owner(service.ResourceCategory, "id"), categoryHandler.DeleteCategory)
 
 		// -- Delegation --
-		api.POST("/reminders/:id/delegate", delegationHandler.Delegate)
-		api.POST("/delegations/:id/accept", delegationHandler.Accept)
-		api.POST("/delegations/:id/reject", delegationHandler.Reject)
+		api.POST("/reminders/:id/delegate", own, delegationHandler.Delegate)
+		api.POST("/delegations/:id/accept", authz.Owner(service.ResourceDelegation, "id"), delegationHandler.Accept)
+		api.POST("/delegations/:id/reject", authz.Owner(service.ResourceDelegation, "id"), delegationHandler.Reject)
 		api.GET("/users/:user_id/delegated", delegationHandler.GetDelegatedReminders)
 
 		// -- Recurring Patterns --
 		api.POST("/recurring-patterns", recurringHandler.CreatePattern)
 		api.GET("/recurring-patterns", recurringHandler.ListPatterns)
 		api.GET("/recurring-patterns/active", recurringHandler.GetActivePatterns)
-		api.GET("/recurring-patterns/:id", recurringHandler.GetPattern)
-		api.PUT("/recurring-patterns/:id", recurringHandler.UpdatePattern)
-		api.DELETE("/recurring-patterns/:id", recurringHandler.DeletePattern)
-		api.POST("/recurring-patterns/:id/toggle", recurringHandler.ToggleActive)
-		api.GET("/recurring-patterns/:id/occurrences", recurringHandler.ListOccurrences)
+		api.GET("/recurring-patterns/:id", authz.Owner(service.ResourcePattern, "id"), recurringHandler.GetPattern)
+		api.PUT("/recurring-patterns/:id", authz.Owner(service.ResourcePattern, "id"), recurringHandler.UpdatePattern)
+		api.DELETE("/recurring-patterns/:id", authz.Owner(service.ResourcePattern, "id"), recurringHandler.DeletePattern)
+		api.POST("/recurring-patterns/:id/toggle", authz.Owner(service.ResourcePattern, "id"), recurringHandler.ToggleActive)
+		api.GET("/recurring-patterns/:id/occurrences", authz.Owner(service.ResourcePattern, "id"), recurringHandler.ListOccurrences)
 
 		// -- Timezone --
 		api.GET("/users/:user_id/timezone", timezoneHandler.GetUserTimezone)
@@ -163,72 +214,88 @@ func RegisterRoutes(
 
 		// -- Habits --
 		api.POST("/habits", habitHandler.CreateHabit)
-		api.GET("/habits/:id", habitHandler.GetHabit)
-		api.PUT("/habits/:id", habitHandler.UpdateHabit)
-		api.DELETE("/habits/:id", habitHandler.DeleteHabit)
-		api.POST("/habits/:id/complete", habitHandler.CompleteHabit)
-		api.GET("/habits/:id/completions", habitHandler.GetCompletions)
-		api.GET("/habits/:id/stats", habitHandler.GetStats)
-		api.POST("/habits/:id/reset-streak", habitHandler.ResetStreak)
+		api.GET("/habits/:id", authz.Owner(service.ResourceHabit, "id"), habitHandler.GetHabit)
+		api.PUT("/habits/:id", authz.Owner(service.ResourceHabit, "id"), habitHandler.UpdateHabit)
+		api.DELETE("/habits/:id", authz.Owner(service.ResourceHabit, "id"), habitHandler.DeleteHabit)
+		api.POST("/habits/:id/complete", authz.Owner(service.ResourceHabit, "id"), habitHandler.CompleteHabit)
+		api.GET("/habits/:id/completions", authz.Owner(service.ResourceHabit, "id"), habitHandler.GetCompletions)
+		api.GET("/habits/:id/stats", authz.Owner(service.ResourceHabit, "id"), habitHandler.GetStats)
+		api.POST("/habits/:id/reset-streak", authz.Owner(service.ResourceHabit, "id"), habitHandler.ResetStreak)
 		api.GET("/users/:user_id/habits", habitHandler.ListHabits)
 		api.GET("/users/:user_id/habits/summary", habitHandler.GetSummary)
 
 		// -- Reminder Attachments --
-		api.POST("/reminders/:id/attachments", ext2Handler.AddAttachment)
-		api.GET("/reminders/:id/attachments", ext2Handler.ListAttachments)
-		api.DELETE("/reminders/:id/attachments/:attachmentId", ext2Handler.DeleteAttachment)
+		api.POST("/reminders/:id/attachments", edit, ext2Handler.AddAttachment)
+		api.GET("/reminders/:id/attachments", view, ext2Handler.ListAttachments)
+		api.DELETE("/reminders/:id/attachments/:attachmentId", authz.Parent(service.ResourceAttachment, "attachmentId", service.AccessEdit), ext2Handler.DeleteAttachment)
 
 		// -- Reminder Comments --
-		api.POST("/reminders/:id/comments", ext2Handler.AddComment)
-		api.GET("/reminders/:id/comments", ext2Handler.ListComments)
-		api.PUT("/reminders/comments/:commentId", ext2Handler.UpdateComment)
-		api.DELETE("/reminders/comments/:commentId", ext2Handler.DeleteComment)
+		api.POST("/reminders/:id/comments", view, ext2Handler.AddComment)
+		api.GET("/reminders/:id/comments", view, ext2Handler.ListComments)
+		api.PUT("/reminders/comments/:commentId", authz.Owner(service.ResourceComment, "commentId"), ext2Handler.UpdateComment)
+		api.DELETE("/reminders/comments/:commentId", authz.Owner(service.ResourceComment, "commentId"), ext2Handler.DeleteComment)
 
 		// -- Reminder Reactions --
-		api.POST("/reminders/:id/reactions", ext2Handler.AddReaction)
-		api.DELETE("/reminders/:id/reactions", ext2Handler.RemoveReaction)
-		api.GET("/reminders/:id/reactions", ext2Handler.ListReactions)
+		api.POST("/reminders/:id/reactions", view, ext2Handler.AddReaction)
+		api.DELETE("/reminders/:id/reactions", view, ext2Handler.RemoveReaction)
+		api.GET("/reminders/:id/reactions", view, ext2Handler.ListReactions)
 
 		// -- Reminder Watchers --
-		api.POST("/reminders/:id/watchers", ext2Handler.AddWatcher)
-		api.DELETE("/reminders/:id/watchers", ext2Handler.RemoveWatcher)
-		api.GET("/reminders/:id/watchers", ext2Handler.ListWatchers)
+		api.POST("/reminders/:id/watchers", view, ext2Handler.AddWatcher)
+		api.DELETE("/reminders/:id/watchers", view, ext2Handler.RemoveWatcher)
+		api.GET("/reminders/:id/watchers", view, ext2Handler.ListWatchers)
 
 		// -- Reminder Labels --
-		api.POST("/reminders/:id/labels", ext2Handler.AddLabel)
-		api.DELETE("/reminders/:id/labels/:label", ext2Handler.RemoveLabel)
-		api.GET("/reminders/:id/labels", ext2Handler.ListLabels)
+		api.POST("/reminders/:id/labels", edit, ext2Handler.AddLabel)
+		api.DELETE("/reminders/:id/labels/:label", edit, ext2Handler.RemoveLabel)
+		api.GET("/reminders/:id/labels", view, ext2Handler.ListLabels)
 		api.GET("/labels/:label/reminders", ext2Handler.SearchByLabel)
 
 		// -- Reminder Favorites --
-		api.POST("/reminders/:id/favorite", ext2Handler.AddFavorite)
-		api.DELETE("/reminders/:id/favorite", ext2Handler.RemoveFavorite)
+		api.POST("/reminders/:id/favorite", view, ext2Handler.AddFavorite)
+		api.DELETE("/reminders/:id/favorite", view, ext2Handler.RemoveFavorite)
 		api.GET("/users/:user_id/favorite-reminders", ext2Handler.ListFavorites)
-		api.GET("/reminders/:id/favorited", ext2Handler.IsFavorited)
+		api.GET("/reminders/:id/favorited", view, ext2Handler.IsFavorited)
 
 		// -- Reminder Dependencies --
-		api.POST("/reminders/:id/dependencies", ext2Handler.AddDependency)
-		api.DELETE("/reminders/:id/dependencies/:depId", ext2Handler.RemoveDependency)
-		api.GET("/reminders/:id/dependencies", ext2Handler.ListDependencies)
+		api.POST("/reminders/:id/dependencies", edit, dependencyHandler.AddDependency)
+		api.DELETE("/reminders/:id/dependencies/:depId", authz.Parent(service.ResourceDependency, "depId", service.AccessEdit), dependencyHandler.RemoveDependency)
+		api.GET("/reminders/:id/dependencies", view, dependencyHandler.ListDependencies)
+		api.GET("/reminders/:id/dependencies/graph", view, dependencyHandler.GetGraph)
 
 		// -- Reminder Locations --
-		api.PUT("/reminders/:id/location", ext2Handler.SetLocation)
-		api.GET("/reminders/:id/location", ext2Handler.GetLocation)
-		api.DELETE("/reminders/:id/location", ext2Handler.RemoveLocation)
-		api.GET("/reminders/nearby", ext2Handler.ListNearby)
+		api.PUT("/reminders/:id/location", edit, locationHandler.SetLocation)
+		api.GET("/reminders/:id/location", view, locationHandler.GetLocation)
+		api.DELETE("/reminders/:id/location", edit, locationHandler.RemoveLocation)
+		api.GET("/reminders/nearby", locationHandler.ListNearby)
+		api.POST("/locations", locationHandler.TrackLocation)
+
+		// -- Snoozes --
+		api.GET("/reminders/:id/snooze-history", view, snoozeHandler.ListSnoozeHistory)
+		api.GET("/reminders/:id/snooze-suggestions", edit, snoozeHandler.SuggestSnoozes)
 
-		// -- Snooze History --
-		api.GET("/reminders/:id/snooze-history", ext2Handler.ListSnoozeHistory)
+		// -- Deliveries --
+		api.GET("/reminders/:id/deliveries", view, deliveryHandler.ListDeliveries)
 
 		// -- Quick Actions --
 		api.POST("/quick-actions", ext2Handler.CreateQuickAction)
 		api.GET("/quick-actions", ext2Handler.ListQuickActions)
-		api.DELETE("/quick-actions/:actionId", ext2Handler.DeleteQuickAction)
+		api.DELETE("/quick-actions/:actionId", authz.Owner(service.ResourceQuickAction, "actionId"), ext2Handler.DeleteQuickAction)
 
 		// -- Extended Stats --
 		api.GET("/users/:user_id/completion-rate", ext2Handler.GetCompletionRate)
 		api.GET("/users/:user_id/streak", ext2Handler.GetStreakInfo)
 	}
+
+	// Admin routes
+	admin := router.Group("/admin", Authenticate(verifier), RequireRole("admin"))
+	{
+		admin.GET("/dlq", adminHandler.ListDeadLetters)
+		admin.GET("/dlq/:id", adminHandler.GetDeadLetter)
+		admin.POST("/dlq/:id/replay", adminHandler.ReplayDeadLetter)
+		admin.POST("/search/reindex", adminHandler.ReindexSearch)
+		admin.GET("/activity/export", analyticsHandler.ExportActivity)
+	}
 }
 
 func (h *Handler) Health(c *gin.Context) {
@@ -248,12 +315,16 @@ func (h *Handler) HealthLive(c *gin.Context) {
 
 func (h *Handler) CreateReminder(c *gin.Context) {
 	var req models.CreateReminderRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindJSONAs(c, &req, func() { req.UserID = callerID(c) }); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	reminder, err := h.service.Create(c.Request.Context(), &req)
+	if errors.Is(err, recurrence.ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -284,6 +355,14 @@ func (h *Handler) UpdateReminder(c *gin.Context) {
 	}
 
 	reminder, err := h.service.Update(c.Request.Context(), id, &req)
+	if errors.Is(err, recurrence.ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrReminderBlocked) || errors.Is(err, models.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -318,7 +397,11 @@ func (h *Handler) SnoozeReminder(c *gin.Context) {
 		return
 	}
 
-	reminder, err := h.service.Snooze(c.Request.Context(), id, duration)
+	reminder, err := h.service.Snooze(c.Request.Context(), id, duration, callerID(c), models.SnoozeSourceAPI)
+	if errors.Is(err, models.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -330,7 +413,12 @@ func (h *Handler) SnoozeReminder(c *gin.Context) {
 func (h *Handler) CancelReminder(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.service.Cancel(c.Request.Context(), id); err != nil {
+	err := h.service.Cancel(c.Request.Context(), id)
+	if errors.Is(err, models.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -354,19 +442,28 @@ func (h *Handler) GetUserReminders(c *gin.Context) {
 	}
 
 	result, err := h.service.GetByUserIDPaginated(c.Request.Context(), userID, status, &page)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": result.Data, "total": result.Total, "page": result.Page, "per_page": result.PerPage, "total_pages": result.TotalPages})
+	respondPage(c, result)
 }
 
 
 func (h *Handler) CompleteReminder(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.service.Complete(c.Request.Context(), id); err != nil {
+	err := h.service.Complete(c.Request.Context(), id)
+	if errors.Is(err, service.ErrReminderBlocked) || errors.Is(err, models.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -374,6 +471,40 @@ func (h *Handler) CompleteReminder(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+// AcknowledgeReminder records that the caller saw a triggered reminder.
+func (h *Handler) AcknowledgeReminder(c *gin.Context) {
+	id := c.Param("id")
+
+	reminder, err := h.service.Acknowledge(c.Request.Context(), id, time.Now())
+	if errors.Is(err, service.ErrNotTriggered) || errors.Is(err, models.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": reminder})
+}
+
+// ArchiveReminder retires a completed or cancelled reminder.
+func (h *Handler) ArchiveReminder(c *gin.Context) {
+	id := c.Param("id")
+
+	reminder, err := h.service.Archive(c.Request.Context(), id)
+	if errors.Is(err, models.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": reminder})
+}
+
 func (h *Handler) BulkCreateReminders(c *gin.Context) {
 	var req models.BulkCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -381,6 +512,10 @@ func (h *Handler) BulkCreateReminders(c *gin.Context) {
 		return
 	}
 
+	for i := range req.Reminders {
+		req.Reminders[i].UserID = callerID(c)
+	}
+
 	result := h.service.BulkCreate(c.Request.Context(), req.Reminders)
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
 }
@@ -392,7 +527,18 @@ func (h *Handler) BulkCancelReminders(c *gin.Context) {
 		return
 	}
 
-	result := h.service.BulkCancel(c.Request.Context(), req.IDs)
+	ids, denied, err := h.authz.allowedIDs(c, req.IDs, service.AccessEdit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := &models.BulkActionResponse{}
+	if len(ids) > 0 {
+		result = h.service.BulkCancel(c.Request.Context(), ids)
+	}
+	result.Failed += len(denied)
+	result.Errors = append(result.Errors, denied...)
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
 }
 
@@ -403,7 +549,18 @@ func (h *Handler) BulkDeleteReminders(c *gin.Context) {
 		return
 	}
 
-	result := h.service.BulkDelete(c.Request.Context(), req.IDs)
+	ids, denied, err := h.authz.allowedIDs(c, req.IDs, service.AccessOwner)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := &models.BulkActionResponse{}
+	if len(ids) > 0 {
+		result = h.service.BulkDelete(c.Request.Context(), ids)
+	}
+	result.Failed += len(denied)
+	result.Errors = append(result.Errors, denied...)
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
 }
 
@@ -419,21 +576,35 @@ func (h *Handler) GetUserReminderStats(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": stats})
 }
 
+// GetChannelReminders lists a channel's reminders to members of the
+// workspace the channel belongs to, named by ?workspace_id.
 func (h *Handler) GetChannelReminders(c *gin.Context) {
 	channelID := c.Param("channel_id")
+	workspaceID := c.Query("workspace_id")
+	if workspaceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "workspace_id is required"})
+		return
+	}
+	if !h.authz.allowWorkspace(c, workspaceID, service.ActionListReminders) {
+		return
+	}
 
 	var page models.PaginationParams
 	if err := c.ShouldBindQuery(&page); err != nil {
 		page = models.PaginationParams{Page: 1, PerPage: 20}
 	}
 
-	result, err := h.service.GetByChannelID(c.Request.Context(), channelID, &page)
+	result, err := h.service.GetByChannelID(c.Request.Context(), workspaceID, channelID, &page)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": result.Data, "total": result.Total, "page": result.Page, "per_page": result.PerPage, "total_pages": result.TotalPages})
+	respondPage(c, result)
 }
 
 func (h *Handler) GetWorkspaceReminders(c *gin.Context) {
@@ -452,10 +623,31 @@ func (h *Handler) GetWorkspaceReminders(c *gin.Context) {
 	}
 
 	result, err := h.service.GetByWorkspaceID(c.Request.Context(), workspaceID, status, &page)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": result.Data, "total": result.Total, "page": result.Page, "per_page": result.PerPage, "total_pages": result.TotalPages})
+	respondPage(c, result)
+}
+
+// respondPage writes one page of a listing. total and total_pages are only
+// present when the listing was counted, and page only for numbered pages.
+func respondPage(c *gin.Context, result *models.PaginatedResponse) {
+	body := gin.H{"success": true, "data": result.Data, "per_page": result.PerPage}
+	if result.Total != nil {
+		body["total"] = *result.Total
+		body["total_pages"] = result.TotalPages
+	}
+	if result.Page > 0 {
+		body["page"] = result.Page
+	}
+	if result.NextCursor != "" {
+		body["next_cursor"] = result.NextCursor
+	}
+	c.JSON(http.StatusOK, body)
 }
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"reminder-service/internal/models"
+	"reminder-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxJobUpload bounds the size of a file submitted for an import job.
+const maxJobUpload = 100 << 20
+
+type JobHandler struct {
+	service *service.JobService
+	authz   *Authorizer
+}
+
+func NewJobHandler(svc *service.JobService, authz *Authorizer) *JobHandler {
+	return &JobHandler{service: svc, authz: authz}
+}
+
+func (h *JobHandler) SubmitExport(c *gin.Context) {
+	var req models.ExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case req.UserID == "" && req.WorkspaceID != "":
+		if !h.authz.allowWorkspace(c, req.WorkspaceID, service.ActionExportWorkspace) {
+			return
+		}
+	case req.UserID == "" || req.UserID == callerID(c):
+		req.UserID = callerID(c)
+	default:
+		forbidden(c)
+		return
+	}
+	req.Viewer = callerID(c)
+
+	job, err := h.service.SubmitExport(c.Request.Context(), callerID(c), &req)
+	if errors.Is(err, service.ErrInvalidExport) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if invalidFilter(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"success": true, "data": job})
+}
+
+// SubmitImport takes the file to import as the "file" field of a multipart
+// form or as the raw body. format is "json" (an ImportRequest) or "ics". The
+// caller must be a member of the workspace the reminders are imported into.
+func (h *JobHandler) SubmitImport(c *gin.Context) {
+	format := c.DefaultQuery("format", models.ImportFormatJSON)
+	workspaceID := c.Query("workspace_id")
+	if workspaceID != "" && !h.authz.allowWorkspace(c, workspaceID, service.ActionImportReminders) {
+		return
+	}
+
+	body, closeBody, err := uploadedFile(c, maxJobUpload)
+	if err != nil {
+		uploadFailed(c, err)
+		return
+	}
+	defer closeBody()
+
+	job, err := h.service.SubmitImport(c.Request.Context(), callerID(c), workspaceID, format, c.Query("timezone"), body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		uploadFailed(c, err)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidJob) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"success": true, "data": job})
+}
+
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	limit := int64(50)
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 64); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	jobs, err := h.service.List(c.Request.Context(), callerID(c), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": jobs})
+}
+
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, err := h.service.Get(c.Request.Context(), c.Param("job_id"))
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": job})
+}
+
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	job, err := h.service.Cancel(c.Request.Context(), c.Param("job_id"))
+	switch {
+	case errors.Is(err, service.ErrResourceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	case errors.Is(err, service.ErrJobFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": job})
+}
+
+func (h *JobHandler) DownloadResult(c *gin.Context) {
+	ctx := c.Request.Context()
+	job, err := h.service.Get(ctx, c.Param("job_id"))
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.OpenResult(ctx, job)
+	if errors.Is(err, service.ErrJobNoResult) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer result.Close()
+
+	c.Header("Content-Type", job.ResultType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, job.ResultName))
+	c.Status(http.StatusOK)
+	_, _ = io.Copy(c.Writer, result)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"reminder-service/internal/models"
+	"reminder-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LocationHandler struct {
+	service *service.LocationService
+	authz   *Authorizer
+}
+
+func NewLocationHandler(svc *service.LocationService, authz *Authorizer) *LocationHandler {
+	return &LocationHandler{service: svc, authz: authz}
+}
+
+func (h *LocationHandler) SetLocation(c *gin.Context) {
+	var req struct {
+		Name      string  `json:"name"`
+		Latitude  float64 `json:"latitude" binding:"required"`
+		Longitude float64 `json:"longitude" binding:"required"`
+		Radius    float64 `json:"radius"`
+		TriggerOn string  `json:"trigger_on"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	loc := &service.ReminderLocation{
+		ReminderID: c.Param("id"),
+		Name:       req.Name,
+		Latitude:   req.Latitude,
+		Longitude:  req.Longitude,
+		Radius:     req.Radius,
+		TriggerOn:  req.TriggerOn,
+	}
+	err := h.service.SetLocation(c.Request.Context(), loc)
+	if errors.Is(err, service.ErrInvalidLocation) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": loc})
+}
+
+func (h *LocationHandler) GetLocation(c *gin.Context) {
+	loc, err := h.service.GetLocation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": loc})
+}
+
+func (h *LocationHandler) RemoveLocation(c *gin.Context) {
+	if err := h.service.RemoveLocation(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ListNearby returns the caller's geofences around ?lat and ?lon, within
+// ?radius meters (1000 by default).
+func (h *LocationHandler) ListNearby(c *gin.Context) {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat must be a number"})
+		return
+	}
+	lon, err := strconv.ParseFloat(c.Query("lon"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lon must be a number"})
+		return
+	}
+	radius, err := strconv.ParseFloat(c.DefaultQuery("radius", "1000"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "radius must be a number"})
+		return
+	}
+
+	results, err := h.service.ListNearby(c.Request.Context(), callerID(c), lat, lon, radius)
+	if errors.Is(err, service.ErrInvalidLocation) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": results})
+}
+
+// TrackLocation takes the caller's position and returns the geofence
+// transitions it caused.
+func (h *LocationHandler) TrackLocation(c *gin.Context) {
+	var update models.LocationUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	update.UserID = callerID(c)
+	if err := update.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transitions, err := h.service.Track(c.Request.Context(), &update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": transitions})
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"reminder-service/internal/audit"
+	"reminder-service/internal/auth"
+	"reminder-service/internal/models"
+	"reminder-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const identityKey = "identity"
+
+// ── Authentication ──
+
+// Authenticate rejects requests without a valid bearer token and stores the
+// caller's identity in the context. The request context carries the caller
+// as the actor of the changes it makes.
+func Authenticate(verifier *auth.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			unauthorized(c, auth.ErrMissingToken)
+			return
+		}
+
+		identity, err := verifier.Verify(strings.TrimSpace(token))
+		if err != nil {
+			unauthorized(c, err)
+			return
+		}
+		c.Set(identityKey, identity)
+		ctx := audit.WithSource(c.Request.Context(), audit.SourceHTTP)
+		c.Request = c.Request.WithContext(audit.WithActor(ctx, identity.UserID))
+		c.Next()
+	}
+}
+
+// RequireRole allows only callers whose token carries role.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identity := callerIdentity(c); identity == nil || !identity.HasRole(role) {
+			forbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSelf rejects routes with a :user_id parameter that names anyone
+// other than the caller.
+func RequireSelf() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID := c.Param("user_id"); userID != "" && userID != callerID(c) {
+			forbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func callerIdentity(c *gin.Context) *auth.Identity {
+	if v, ok := c.Get(identityKey); ok {
+		if identity, ok := v.(*auth.Identity); ok {
+			return identity
+		}
+	}
+	return nil
+}
+
+// callerID returns the authenticated user. Handlers use it instead of any
+// user_id sent by the client.
+func callerID(c *gin.Context) string {
+	if identity := callerIdentity(c); identity != nil {
+		return identity.UserID
+	}
+	return ""
+}
+
+// bindJSONAs decodes the request body, lets stamp fill in caller-derived
+// fields and only then validates, so clients need not send them.
+func bindJSONAs(c *gin.Context, obj any, stamp func()) error {
+	if c.Request.Body == nil {
+		return errors.New("missing request body")
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(obj); err != nil {
+		return err
+	}
+	stamp()
+	return binding.Validator.ValidateStruct(obj)
+}
+
+func unauthorized(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", `Bearer realm="reminder-service"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+func forbidden(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+}
+
+// ── Authorization ──
+
+// Authorizer builds per-route checks on reminders, user-owned resources and
+// workspace-wide data.
+type Authorizer struct {
+	access     *service.AccessService
+	workspaces *service.WorkspaceService
+}
+
+func NewAuthorizer(access *service.AccessService, workspaces *service.WorkspaceService) *Authorizer {
+	return &Authorizer{access: access, workspaces: workspaces}
+}
+
+// Workspace requires the caller's role in :workspace_id to allow action.
+func (a *Authorizer) Workspace(action service.WorkspaceAction) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.allowWorkspace(c, c.Param("workspace_id"), action) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowWorkspace checks the workspace policy for action. Denials are written
+// to the audit trail and answered with 403; the caller must then return.
+func (a *Authorizer) allowWorkspace(c *gin.Context, workspaceID string, action service.WorkspaceAction) bool {
+	ctx := c.Request.Context()
+	role, err := a.workspaces.Role(ctx, workspaceID, callerID(c))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if service.Allows(role, action) {
+		return true
+	}
+
+	denial := &models.AccessDenial{
+		WorkspaceID: workspaceID,
+		UserID:      callerID(c),
+		Role:        role,
+		Action:      string(action),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+	}
+	if err := a.workspaces.RecordDenial(ctx, denial); err != nil {
+		log.Printf("Error recording access denial for %s in %s: %v", denial.UserID, workspaceID, err)
+	}
+	forbidden(c)
+	return false
+}
+
+// Reminder requires at least need access to the reminder named by :id.
+func (a *Authorizer) Reminder(need service.Access) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access, err := a.access.ReminderAccess(c.Request.Context(), c.Param("id"), callerID(c))
+		if a.abortOnError(c, err, "Reminder not found") {
+			return
+		}
+		if access < need {
+			forbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// SavedView requires at least need access to the saved view named by
+// :view_id.
+func (a *Authorizer) SavedView(need service.Access) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access, err := a.access.ViewAccess(c.Request.Context(), c.Param("view_id"), callerID(c))
+		if a.abortOnError(c, err, "View not found") {
+			return
+		}
+		if access < need {
+			forbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Owner requires the caller to own the resource named by param.
+func (a *Authorizer) Owner(kind service.ResourceKind, param string) gin.HandlerFunc {
+	return a.resource(kind, param, func(ctx context.Context, ref *service.ResourceRef, userID string) (bool, error) {
+		return ref.Owner == userID, nil
+	})
+}
+
+// Reader lets anyone read a shared resource and otherwise requires ownership.
+func (a *Authorizer) Reader(kind service.ResourceKind, param string) gin.HandlerFunc {
+	return a.resource(kind, param, func(ctx context.Context, ref *service.ResourceRef, userID string) (bool, error) {
+		return ref.Shared || ref.Owner == userID, nil
+	})
+}
+
+// Parent requires at least need access to the reminder the resource named by
+// param belongs to.
+func (a *Authorizer) Parent(kind service.ResourceKind, param string, need service.Access) gin.HandlerFunc {
+	return a.resource(kind, param, func(ctx context.Context, ref *service.ResourceRef, userID string) (bool, error) {
+		access, err := a.access.ReminderAccess(ctx, ref.ReminderID, userID)
+		if errors.Is(err, service.ErrResourceNotFound) {
+			return false, nil
+		}
+		return access >= need, err
+	})
+}
+
+func (a *Authorizer) resource(kind service.ResourceKind, param string, allowed func(context.Context, *service.ResourceRef, string) (bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		ref, err := a.access.Resource(ctx, kind, c.Param(param))
+		if a.abortOnError(c, err, "Not found") {
+			return
+		}
+		ok, err := allowed(ctx, ref, callerID(c))
+		if a.abortOnError(c, err, "Not found") {
+			return
+		}
+		if !ok {
+			forbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func (a *Authorizer) abortOnError(c *gin.Context, err error, notFound string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, service.ErrResourceNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": notFound})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return true
+}
+
+// allowedIDs splits the reminder ids of a bulk request into those the caller
+// has need access to and error messages for the rest.
+func (a *Authorizer) allowedIDs(c *gin.Context, ids []string, need service.Access) ([]string, []string, error) {
+	var allowed, denied []string
+	for _, id := range ids {
+		access, err := a.access.ReminderAccess(c.Request.Context(), id, callerID(c))
+		if errors.Is(err, service.ErrResourceNotFound) {
+			denied = append(denied, fmt.Sprintf("%s: not found", id))
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if access < need {
+			denied = append(denied, fmt.Sprintf("%s: forbidden", id))
+			continue
+		}
+		allowed = append(allowed, id)
+	}
+	return allowed, denied, nil
+}
+
+// visible returns the subset of reminder ids the caller may view, for
+// listings that are not scoped to a single reminder.
+func (a *Authorizer) visible(c *gin.Context, ids []string) (map[string]bool, error) {
+	allowed, err := a.access.VisibleReminders(c.Request.Context(), ids, callerID(c))
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(allowed))
+	for _, id := range allowed {
+		set[id] = true
+	}
+	return set, nil
+}
@@ -19,7 +19,7 @@ func NewNoteHandler(svc *service.NoteService) *NoteHandler {
 
 func (h *NoteHandler) CreateNote(c *gin.Context) {
 	reminderID := c.Param("id")
-	userID := c.Query("user_id")
+	userID := callerID(c)
 
 	var req models.CreateNoteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"reminder-service/internal/models"
+	"reminder-service/internal/nlparse"
+	"reminder-service/internal/recurrence"
+	"reminder-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type ParseHandler struct {
+	service *service.ParseService
+}
+
+func NewParseHandler(svc *service.ParseService) *ParseHandler {
+	return &ParseHandler{service: svc}
+}
+
+// ParseReminder reads free text as a reminder for the caller. It previews
+// the draft, or creates the reminder when the request sets create. A draft
+// in the past or of low confidence is answered with 422 unless the request
+// also sets confirm.
+func (h *ParseHandler) ParseReminder(c *gin.Context) {
+	var req models.ParseReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	parsed, err := h.service.Parse(ctx, callerID(c), &req)
+	if errors.Is(err, nlparse.ErrNoTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Create {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": parsed})
+		return
+	}
+
+	if err := binding.Validator.ValidateStruct(parsed.Draft); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.service.Create(ctx, parsed, req.Confirm)
+	if errors.Is(err, recurrence.ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrParseUnconfirmed) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": parsed})
+}
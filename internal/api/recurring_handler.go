@@ -1,10 +1,12 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"reminder-service/internal/models"
+	"reminder-service/internal/recurrence"
 	"reminder-service/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -19,7 +21,7 @@ func NewRecurringHandler(svc *service.RecurringService) *RecurringHandler {
 }
 
 func (h *RecurringHandler) CreatePattern(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	workspaceID := c.Query("workspace_id")
 
 	var req models.CreateRecurringPatternRequest
@@ -29,6 +31,10 @@ func (h *RecurringHandler) CreatePattern(c *gin.Context) {
 	}
 
 	pattern, err := h.svc.CreatePattern(c.Request.Context(), userID, workspaceID, &req)
+	if errors.Is(err, recurrence.ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -50,7 +56,7 @@ func (h *RecurringHandler) GetPattern(c *gin.Context) {
 }
 
 func (h *RecurringHandler) ListPatterns(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 
 	patterns, err := h.svc.ListPatterns(c.Request.Context(), userID)
 	if err != nil {
@@ -71,6 +77,10 @@ func (h *RecurringHandler) UpdatePattern(c *gin.Context) {
 	}
 
 	pattern, err := h.svc.UpdatePattern(c.Request.Context(), id, &req)
+	if errors.Is(err, recurrence.ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
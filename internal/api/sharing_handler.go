@@ -19,7 +19,7 @@ func NewSharingHandler(svc *service.SharingService) *SharingHandler {
 
 func (h *SharingHandler) ShareReminder(c *gin.Context) {
 	reminderID := c.Param("id")
-	sharedBy := c.Query("user_id")
+	sharedBy := callerID(c)
 
 	var req models.ShareReminderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"reminder-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SnoozeHandler struct {
+	service   *service.SnoozeService
+	reminders *service.ReminderService
+}
+
+func NewSnoozeHandler(svc *service.SnoozeService, reminders *service.ReminderService) *SnoozeHandler {
+	return &SnoozeHandler{service: svc, reminders: reminders}
+}
+
+func (h *SnoozeHandler) ListSnoozeHistory(c *gin.Context) {
+	results, err := h.service.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": results})
+}
+
+// SuggestSnoozes proposes snooze times for the caller, each with the
+// duration to send to /snooze and the time it lands on.
+func (h *SnoozeHandler) SuggestSnoozes(c *gin.Context) {
+	ctx := c.Request.Context()
+	reminder, err := h.reminders.GetByID(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+		return
+	}
+
+	suggestions, err := h.service.Suggest(ctx, callerID(c), reminder.WorkspaceID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": suggestions})
+}
@@ -19,7 +19,7 @@ func NewSubtaskHandler(svc *service.SubtaskService) *SubtaskHandler {
 
 func (h *SubtaskHandler) AddSubtask(c *gin.Context) {
 	reminderID := c.Param("id")
-	userID := c.Query("user_id")
+	userID := callerID(c)
 
 	var req models.CreateSubtaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
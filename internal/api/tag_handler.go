@@ -11,14 +11,15 @@ import (
 
 type TagHandler struct {
 	service *service.TagService
+	authz   *Authorizer
 }
 
-func NewTagHandler(svc *service.TagService) *TagHandler {
-	return &TagHandler{service: svc}
+func NewTagHandler(svc *service.TagService, authz *Authorizer) *TagHandler {
+	return &TagHandler{service: svc, authz: authz}
 }
 
 func (h *TagHandler) CreateTag(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	workspaceID := c.Query("workspace_id")
 
 	var req models.CreateTagRequest
@@ -135,6 +136,16 @@ func (h *TagHandler) BulkTag(c *gin.Context) {
 		return
 	}
 
+	_, denied, err := h.authz.allowedIDs(c, req.ReminderIDs, service.AccessEdit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(denied) > 0 {
+		forbidden(c)
+		return
+	}
+
 	if err := h.service.BulkTag(c.Request.Context(), req.ReminderIDs, req.TagIDs); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -20,7 +20,7 @@ func NewTemplateHandler(tmplSvc *service.TemplateService, reminderSvc *service.R
 }
 
 func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := callerID(c)
 	workspaceID := c.Query("workspace_id")
 
 	var req models.CreateTemplateRequest
@@ -86,7 +86,7 @@ func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
 
 func (h *TemplateHandler) CreateFromTemplate(c *gin.Context) {
 	var req models.CreateFromTemplateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindJSONAs(c, &req, func() { req.UserID = callerID(c) }); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -96,6 +96,10 @@ func (h *TemplateHandler) CreateFromTemplate(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
 		return
 	}
+	if tmpl.UserID != req.UserID && !tmpl.IsShared {
+		forbidden(c)
+		return
+	}
 
 	reminderReq := &models.CreateReminderRequest{
 		UserID:      req.UserID,
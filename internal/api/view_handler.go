@@ -0,0 +1,187 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"reminder-service/internal/models"
+	"reminder-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ViewHandler struct {
+	service *service.ViewService
+}
+
+func NewViewHandler(svc *service.ViewService) *ViewHandler {
+	return &ViewHandler{service: svc}
+}
+
+func (h *ViewHandler) CreateView(c *gin.Context) {
+	var req models.CreateViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	view, err := h.service.Create(c.Request.Context(), callerID(c), &req)
+	if errors.Is(err, service.ErrInvalidView) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if invalidFilter(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": view})
+}
+
+func (h *ViewHandler) ListViews(c *gin.Context) {
+	views, err := h.service.List(c.Request.Context(), callerID(c), c.Query("workspace_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": views})
+}
+
+func (h *ViewHandler) GetView(c *gin.Context) {
+	view, err := h.service.Get(c.Request.Context(), c.Param("view_id"))
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": view})
+}
+
+func (h *ViewHandler) UpdateView(c *gin.Context) {
+	var req models.UpdateViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	view, err := h.service.Update(c.Request.Context(), c.Param("view_id"), &req)
+	if errors.Is(err, service.ErrInvalidView) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+		return
+	}
+	if invalidFilter(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": view})
+}
+
+func (h *ViewHandler) DeleteView(c *gin.Context) {
+	err := h.service.Delete(c.Request.Context(), c.Param("view_id"))
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "View deleted"})
+}
+
+// RunView returns a page of the reminders matching a view, as /search does.
+func (h *ViewHandler) RunView(c *gin.Context) {
+	var page models.PaginationParams
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	view, err := h.service.Get(ctx, c.Param("view_id"))
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Run(ctx, view, callerID(c), page)
+	if errors.Is(err, service.ErrViewForbidden) {
+		forbidden(c)
+		return
+	}
+	if invalidFilter(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	respondPage(c, result)
+}
+
+// ── Sharing ──
+
+func (h *ViewHandler) ShareView(c *gin.Context) {
+	var req models.ShareReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.SharedWith == callerID(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot share a view with yourself"})
+		return
+	}
+
+	share, err := h.service.Share(c.Request.Context(), c.Param("view_id"), callerID(c), &req)
+	if errors.Is(err, service.ErrInvalidView) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": share})
+}
+
+func (h *ViewHandler) ListViewShares(c *gin.Context) {
+	shares, err := h.service.ListShares(c.Request.Context(), c.Param("view_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": shares})
+}
+
+func (h *ViewHandler) UnshareView(c *gin.Context) {
+	err := h.service.Unshare(c.Request.Context(), c.Param("view_id"), c.Param("shared_with"))
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "View unshared"})
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"reminder-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkspaceHandler struct {
+	service *service.WorkspaceService
+}
+
+func NewWorkspaceHandler(svc *service.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{service: svc}
+}
+
+func (h *WorkspaceHandler) ListAccessDenials(c *gin.Context) {
+	limit := int64(50)
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 64); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	denials, err := h.service.ListDenials(c.Request.Context(), c.Param("workspace_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": denials})
+}
@@ -0,0 +1,160 @@
+// Package audit records writes to MongoDB collections in the activity log.
+// Services wrap the collections they write with Wrap, and every insert,
+// update and delete through the wrapper is recorded with the fields it
+// changed and the actor and source carried by the context, so no service
+// has to log its own changes.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sources of a change.
+const (
+	SourceHTTP      = "http"
+	SourceKafka     = "kafka"
+	SourceScheduler = "scheduler"
+)
+
+// Actions recorded for a write. An update that changes a record's status
+// is recorded as ActionStatusChanged.
+const (
+	ActionCreated       = "created"
+	ActionUpdated       = "updated"
+	ActionDeleted       = "deleted"
+	ActionStatusChanged = "status_changed"
+)
+
+type actorKey struct{}
+
+type sourceKey struct{}
+
+// WithActor returns a context whose writes are attributed to userID.
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// Actor returns the user set with WithActor, or "" for changes the service
+// makes on its own, such as triggering.
+func Actor(ctx context.Context) string {
+	userID, _ := ctx.Value(actorKey{}).(string)
+	return userID
+}
+
+// WithSource returns a context whose writes are recorded as coming from
+// source.
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// Source returns the source set with WithSource, or "".
+func Source(ctx context.Context) string {
+	source, _ := ctx.Value(sourceKey{}).(string)
+	return source
+}
+
+// Sink stores activity log entries. It is called with the context of the
+// write, so in a transaction the entries commit or roll back with it.
+type Sink interface {
+	Record(ctx context.Context, entries ...*models.ReminderActivity) error
+}
+
+// unaudited lists fields whose changes say nothing on their own.
+var unaudited = map[string]bool{"_id": true, "updated_at": true}
+
+// diff returns the fields that differ between before and after. Either may
+// be nil, for created and deleted records.
+func diff(before, after bson.M) map[string]models.FieldChange {
+	changes := make(map[string]models.FieldChange)
+	for k, from := range before {
+		if unaudited[k] {
+			continue
+		}
+		if to, ok := after[k]; !ok || !reflect.DeepEqual(from, to) {
+			changes[k] = models.FieldChange{From: from, To: to}
+		}
+	}
+	for k, to := range after {
+		if _, ok := before[k]; !ok && !unaudited[k] {
+			changes[k] = models.FieldChange{To: to}
+		}
+	}
+	return changes
+}
+
+// decode reads a document with nested documents as maps, so they are
+// stored and served as objects rather than key-value lists.
+func decode(raw bson.Raw) (bson.M, error) {
+	dec, err := bson.NewDecoder(bsonrw.NewBSONDocumentReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	dec.DefaultDocumentM()
+
+	var doc bson.M
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// idString renders a document ID for EntityID.
+func idString(id any) string {
+	switch id := id.(type) {
+	case nil:
+		return ""
+	case primitive.ObjectID:
+		return id.Hex()
+	case string:
+		return id
+	}
+	return fmt.Sprint(id)
+}
+
+// entry builds the activity for a write that turned before into after, or
+// nil if the write changed nothing worth recording.
+func (c *Collection) entry(ctx context.Context, before, after bson.M) *models.ReminderActivity {
+	changes := diff(before, after)
+	doc := after
+	var action string
+	switch {
+	case before == nil:
+		action = ActionCreated
+	case after == nil:
+		action, doc = ActionDeleted, before
+	case len(changes) == 0:
+		return nil
+	default:
+		action = ActionUpdated
+		if _, ok := changes["status"]; ok {
+			action = ActionStatusChanged
+		}
+	}
+
+	e := &models.ReminderActivity{
+		UserID:    Actor(ctx),
+		Action:    action,
+		Entity:    c.entity,
+		EntityID:  idString(doc["_id"]),
+		Source:    Source(ctx),
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+	switch c.reminderField {
+	case "":
+	case "_id":
+		e.ReminderID = e.EntityID
+	default:
+		e.ReminderID, _ = doc[c.reminderField].(string)
+	}
+	return e
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiff(t *testing.T) {
+	at := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		before bson.M
+		after  bson.M
+		want   map[string]models.FieldChange
+	}{
+		{
+			name:   "unchanged",
+			before: bson.M{"_id": 1, "title": "a", "updated_at": at},
+			after:  bson.M{"_id": 1, "title": "a", "updated_at": at.Add(time.Minute)},
+			want:   map[string]models.FieldChange{},
+		},
+		{
+			name:   "changed field",
+			before: bson.M{"title": "a", "status": "pending"},
+			after:  bson.M{"title": "a", "status": "completed"},
+			want:   map[string]models.FieldChange{"status": {From: "pending", To: "completed"}},
+		},
+		{
+			name:   "added and removed fields",
+			before: bson.M{"title": "a", "notify_at": at},
+			after:  bson.M{"title": "a", "snoozed_until": at},
+			want: map[string]models.FieldChange{
+				"notify_at":     {From: at},
+				"snoozed_until": {To: at},
+			},
+		},
+		{
+			name:   "nested documents",
+			before: bson.M{"recurrence": bson.M{"rrule": "FREQ=DAILY", "interval": int32(1)}},
+			after:  bson.M{"recurrence": bson.M{"rrule": "FREQ=DAILY", "interval": int32(2)}},
+			want: map[string]models.FieldChange{
+				"recurrence": {
+					From: bson.M{"rrule": "FREQ=DAILY", "interval": int32(1)},
+					To:   bson.M{"rrule": "FREQ=DAILY", "interval": int32(2)},
+				},
+			},
+		},
+		{
+			name:   "created",
+			before: nil,
+			after:  bson.M{"_id": 1, "title": "a", "updated_at": at},
+			want:   map[string]models.FieldChange{"title": {To: "a"}},
+		},
+		{
+			name:   "deleted",
+			before: bson.M{"_id": 1, "title": "a"},
+			after:  nil,
+			want:   map[string]models.FieldChange{"title": {From: "a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntry(t *testing.T) {
+	id := primitive.NewObjectID()
+	ctx := WithSource(WithActor(context.Background(), "u1"), SourceHTTP)
+
+	tests := []struct {
+		name          string
+		reminderField string
+		before, after bson.M
+		action        string
+		reminderID    string
+	}{
+		{
+			name:          "created reminder",
+			reminderField: "_id",
+			after:         bson.M{"_id": id, "title": "a"},
+			action:        ActionCreated,
+			reminderID:    id.Hex(),
+		},
+		{
+			name:          "updated note",
+			reminderField: "reminder_id",
+			before:        bson.M{"_id": id, "reminder_id": "r1", "content": "a"},
+			after:         bson.M{"_id": id, "reminder_id": "r1", "content": "b"},
+			action:        ActionUpdated,
+			reminderID:    "r1",
+		},
+		{
+			name:          "status change",
+			reminderField: "_id",
+			before:        bson.M{"_id": id, "status": "pending"},
+			after:         bson.M{"_id": id, "status": "completed"},
+			action:        ActionStatusChanged,
+			reminderID:    id.Hex(),
+		},
+		{
+			name:          "deleted record outside a reminder",
+			reminderField: "",
+			before:        bson.M{"_id": id, "name": "rule"},
+			action:        ActionDeleted,
+		},
+		{
+			name:          "only updated_at changed",
+			reminderField: "_id",
+			before:        bson.M{"_id": id, "updated_at": 1},
+			after:         bson.M{"_id": id, "updated_at": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Collection{entity: "thing", reminderField: tt.reminderField}
+			e := c.entry(ctx, tt.before, tt.after)
+			if tt.action == "" {
+				if e != nil {
+					t.Errorf("entry = %+v, want nil", e)
+				}
+				return
+			}
+			if e == nil {
+				t.Fatal("entry = nil")
+			}
+			if e.Action != tt.action || e.ReminderID != tt.reminderID {
+				t.Errorf("Action, ReminderID = %q, %q, want %q, %q", e.Action, e.ReminderID, tt.action, tt.reminderID)
+			}
+			if e.EntityID != id.Hex() || e.Entity != "thing" || e.UserID != "u1" || e.Source != SourceHTTP {
+				t.Errorf("entry = %+v", e)
+			}
+		})
+	}
+}
+
+func TestDecodeUsesMaps(t *testing.T) {
+	raw, err := bson.Marshal(bson.D{
+		{Key: "a", Value: bson.D{{Key: "b", Value: bson.D{{Key: "c", Value: 1}}}}},
+		{Key: "list", Value: bson.A{bson.D{{Key: "d", Value: "x"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bson.M{
+		"a":    bson.M{"b": bson.M{"c": int32(1)}},
+		"list": bson.A{bson.M{"d": "x"}},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("decode = %#v, want %#v", doc, want)
+	}
+}
+
+func TestIDString(t *testing.T) {
+	id := primitive.NewObjectID()
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{nil, ""},
+		{id, id.Hex()},
+		{"abc", "abc"},
+		{int32(7), "7"},
+	}
+	for _, tt := range tests {
+		if got := idString(tt.in); got != tt.want {
+			t.Errorf("idString(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection is a collection whose inserts, updates and deletes are
+// recorded in the activity log. FindOneAndUpdate and deletes of a single
+// record get the record as it was from the write itself; other records are
+// read with the write's context, so inside a transaction they see what it
+// sees.
+// Reads and other operations go straight to the embedded collection.
+// Failing to read or record a change is logged but never fails the write.
+type Collection struct {
+	*mongo.Collection
+	entity        string
+	reminderField string
+	sink          Sink
+}
+
+// Wrap audits the writes to coll, recording them against entity.
+// reminderField names the field holding the reminder a record belongs to:
+// "_id" for reminders themselves and "" for records outside any reminder.
+// With a nil sink nothing is recorded.
+func Wrap(coll *mongo.Collection, entity, reminderField string, sink Sink) *Collection {
+	return &Collection{Collection: coll, entity: entity, reminderField: reminderField, sink: sink}
+}
+
+func (c *Collection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	result, err := c.Collection.InsertOne(ctx, document, opts...)
+	if err == nil && c.sink != nil {
+		c.recordInserts(ctx, []interface{}{document}, []interface{}{result.InsertedID})
+	}
+	return result, err
+}
+
+// InsertMany records the documents that were inserted, leaving out those
+// named in a BulkWriteException and, for ordered inserts, those after the
+// first failure.
+func (c *Collection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	result, err := c.Collection.InsertMany(ctx, documents, opts...)
+	if c.sink == nil || result == nil {
+		return result, err
+	}
+
+	inserted, ids := documents, result.InsertedIDs
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) {
+			return result, err
+		}
+		ordered := true
+		for _, o := range opts {
+			if o != nil && o.Ordered != nil {
+				ordered = *o.Ordered
+			}
+		}
+		failed := make(map[int]bool, len(bulkErr.WriteErrors))
+		first := len(documents)
+		for _, we := range bulkErr.WriteErrors {
+			failed[we.Index] = true
+			first = min(first, we.Index)
+		}
+		inserted, ids = nil, nil
+		for i, doc := range documents {
+			if failed[i] || (ordered && i > first) || i >= len(result.InsertedIDs) {
+				continue
+			}
+			inserted = append(inserted, doc)
+			ids = append(ids, result.InsertedIDs[i])
+		}
+	}
+	c.recordInserts(ctx, inserted, ids)
+	return result, err
+}
+
+// UpdateOne reads the matching record first and then updates that record
+// alone, so the change is recorded against the record that was written. If
+// it stops matching the filter in between, the update falls back to the
+// filter. The caller gets the result of the update itself.
+func (c *Collection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	if c.sink == nil {
+		return c.Collection.UpdateOne(ctx, filter, update, opts...)
+	}
+
+	uo := options.MergeUpdateOptions(opts...)
+	if uo.Upsert == nil || !*uo.Upsert {
+		if before := c.findOne(ctx, filter, nil); before != nil {
+			pinned := bson.M{"$and": bson.A{filter, bson.M{"_id": before["_id"]}}}
+			result, err := c.Collection.UpdateOne(ctx, pinned, update, opts...)
+			if err != nil || result.MatchedCount > 0 {
+				if err == nil && result.ModifiedCount > 0 {
+					c.recordUpdates(ctx, []bson.M{before}, nil)
+				}
+				return result, err
+			}
+		}
+	}
+	return c.updateByFilter(ctx, filter, update, opts...)
+}
+
+// updateByFilter is UpdateOne for upserts and for records that changed
+// while being read, where the record written may not be the one read.
+func (c *Collection) updateByFilter(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	before := c.findOne(ctx, filter, nil)
+	result, err := c.Collection.UpdateOne(ctx, filter, update, opts...)
+	if err != nil {
+		return result, err
+	}
+	switch {
+	case result.UpsertedID != nil:
+		c.recordUpdates(ctx, nil, []interface{}{result.UpsertedID})
+	case result.ModifiedCount > 0 && before != nil:
+		c.recordUpdates(ctx, []bson.M{before}, nil)
+	}
+	return result, nil
+}
+
+func (c *Collection) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	if c.sink == nil {
+		return c.Collection.UpdateMany(ctx, filter, update, opts...)
+	}
+
+	befores := c.findAll(ctx, filter)
+	result, err := c.Collection.UpdateMany(ctx, filter, update, opts...)
+	if err != nil {
+		return result, err
+	}
+	if result.ModifiedCount > 0 || result.UpsertedID != nil {
+		var upserted []interface{}
+		if result.UpsertedID != nil {
+			upserted = append(upserted, result.UpsertedID)
+		}
+		c.recordUpdates(ctx, befores, upserted)
+	}
+	return result, nil
+}
+
+// DeleteOne deletes with FindOneAndDelete to get the deleted record.
+func (c *Collection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	if c.sink == nil {
+		return c.Collection.DeleteOne(ctx, filter, opts...)
+	}
+
+	do := options.MergeDeleteOptions(opts...)
+	fo := options.FindOneAndDelete()
+	fo.Collation = do.Collation
+	fo.Comment = do.Comment
+	fo.Hint = do.Hint
+	fo.Let = do.Let
+	raw, err := c.Collection.FindOneAndDelete(ctx, filter, fo).Raw()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &mongo.DeleteResult{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if before, err := decode(raw); err != nil {
+		log.Printf("Error decoding %s for the activity log: %v", c.entity, err)
+	} else {
+		c.record(ctx, c.entry(ctx, before, nil))
+	}
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+// DeleteMany records the matching records that are gone afterwards, so
+// records inserted or deleted concurrently are not misreported.
+func (c *Collection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	if c.sink == nil {
+		return c.Collection.DeleteMany(ctx, filter, opts...)
+	}
+
+	befores := c.findAll(ctx, filter)
+	result, err := c.Collection.DeleteMany(ctx, filter, opts...)
+	if err != nil || result.DeletedCount == 0 || len(befores) == 0 {
+		return result, err
+	}
+
+	remaining := map[interface{}]bool{}
+	if int(result.DeletedCount) < len(befores) {
+		for _, doc := range c.findAll(ctx, bson.M{"_id": bson.M{"$in": ids(befores)}}) {
+			remaining[doc["_id"]] = true
+		}
+	}
+	var entries []*models.ReminderActivity
+	for _, before := range befores {
+		if !remaining[before["_id"]] {
+			entries = append(entries, c.entry(ctx, before, nil))
+		}
+	}
+	c.record(ctx, entries...)
+	return result, nil
+}
+
+// FindOneAndUpdate always takes the record before the update from the write
+// itself. When the caller asks for the updated record it is read by _id
+// afterwards, or by the filter when the update upserted it.
+func (c *Collection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	if c.sink == nil {
+		return c.Collection.FindOneAndUpdate(ctx, filter, update, opts...)
+	}
+
+	returnsAfter, upsert := false, false
+	read := options.FindOne()
+	for _, o := range opts {
+		if o == nil {
+			continue
+		}
+		if o.ReturnDocument != nil {
+			returnsAfter = *o.ReturnDocument == options.After
+		}
+		if o.Upsert != nil {
+			upsert = *o.Upsert
+		}
+		if o.Sort != nil {
+			read.SetSort(o.Sort)
+		}
+		if o.Projection != nil {
+			read.SetProjection(o.Projection)
+		}
+	}
+	if !returnsAfter {
+		result := c.Collection.FindOneAndUpdate(ctx, filter, update, opts...)
+		if raw, err := result.Raw(); err == nil {
+			if doc, err := decode(raw); err != nil {
+				log.Printf("Error decoding %s for the activity log: %v", c.entity, err)
+			} else {
+				c.recordUpdates(ctx, []bson.M{doc}, nil)
+			}
+		}
+		return result
+	}
+
+	writeOpts := append(opts[:len(opts):len(opts)], options.FindOneAndUpdate().SetReturnDocument(options.Before))
+	result := c.Collection.FindOneAndUpdate(ctx, filter, update, writeOpts...)
+	raw, err := result.Raw()
+	var before bson.M
+	var after *mongo.SingleResult
+	switch {
+	case err == nil:
+		after = c.Collection.FindOne(ctx, bson.M{"_id": raw.Lookup("_id")}, read)
+		if before, err = decode(raw); err != nil {
+			log.Printf("Error decoding %s for the activity log: %v", c.entity, err)
+			return after
+		}
+	case errors.Is(err, mongo.ErrNoDocuments) && upsert:
+		after = c.Collection.FindOne(ctx, filter, read)
+	default:
+		return result
+	}
+
+	raw, err = after.Raw()
+	if err != nil {
+		return after
+	}
+	doc, err := decode(raw)
+	if err != nil {
+		log.Printf("Error decoding %s for the activity log: %v", c.entity, err)
+		return after
+	}
+	c.record(ctx, c.entry(ctx, before, doc))
+	return after
+}
+
+func (c *Collection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
+	result := c.Collection.FindOneAndDelete(ctx, filter, opts...)
+	if c.sink == nil {
+		return result
+	}
+
+	raw, err := result.Raw()
+	if err != nil {
+		return result
+	}
+	doc, err := decode(raw)
+	if err != nil {
+		log.Printf("Error decoding %s for the activity log: %v", c.entity, err)
+		return result
+	}
+	c.record(ctx, c.entry(ctx, doc, nil))
+	return result
+}
+
+// ── Recording ──
+
+func (c *Collection) recordInserts(ctx context.Context, documents, insertedIDs []interface{}) {
+	var entries []*models.ReminderActivity
+	for i, document := range documents {
+		raw, err := bson.Marshal(document)
+		var doc bson.M
+		if err == nil {
+			doc, err = decode(raw)
+		}
+		if err != nil {
+			log.Printf("Error decoding %s for the activity log: %v", c.entity, err)
+			continue
+		}
+		doc["_id"] = insertedIDs[i]
+		entries = append(entries, c.entry(ctx, nil, doc))
+	}
+	c.record(ctx, entries...)
+}
+
+// recordUpdates reads the records in befores again, along with the ones
+// upserted, and records what changed.
+func (c *Collection) recordUpdates(ctx context.Context, befores []bson.M, upserted []interface{}) {
+	afters := make(map[interface{}]bson.M)
+	for _, doc := range c.findAll(ctx, bson.M{"_id": bson.M{"$in": append(ids(befores), upserted...)}}) {
+		afters[doc["_id"]] = doc
+	}
+
+	var entries []*models.ReminderActivity
+	for _, before := range befores {
+		if after, ok := afters[before["_id"]]; ok {
+			entries = append(entries, c.entry(ctx, before, after))
+		}
+	}
+	for _, id := range upserted {
+		if after, ok := afters[id]; ok {
+			entries = append(entries, c.entry(ctx, nil, after))
+		}
+	}
+	c.record(ctx, entries...)
+}
+
+func (c *Collection) record(ctx context.Context, entries ...*models.ReminderActivity) {
+	kept := entries[:0]
+	for _, e := range entries {
+		if e != nil {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+	if err := c.sink.Record(ctx, kept...); err != nil {
+		log.Printf("Error recording %s activity: %v", c.entity, err)
+	}
+}
+
+func (c *Collection) findOne(ctx context.Context, filter, sort interface{}) bson.M {
+	opts := options.FindOne()
+	if sort != nil {
+		opts.SetSort(sort)
+	}
+	raw, err := c.Collection.FindOne(ctx, filter, opts).Raw()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	var doc bson.M
+	if err == nil {
+		doc, err = decode(raw)
+	}
+	if err != nil {
+		log.Printf("Error reading %s for the activity log: %v", c.entity, err)
+		return nil
+	}
+	return doc
+}
+
+func (c *Collection) findAll(ctx context.Context, filter interface{}) []bson.M {
+	cursor, err := c.Collection.Find(ctx, filter)
+	if err != nil {
+		log.Printf("Error reading %s for the activity log: %v", c.entity, err)
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.M
+	for cursor.Next(ctx) {
+		doc, err := decode(cursor.Current)
+		if err != nil {
+			log.Printf("Error decoding %s for the activity log: %v", c.entity, err)
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Error reading %s for the activity log: %v", c.entity, err)
+	}
+	return docs
+}
+
+func ids(docs []bson.M) []interface{} {
+	out := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, doc["_id"])
+	}
+	return out
+}
@@ -0,0 +1,272 @@
+// Package auth verifies the bearer tokens presented to the HTTP API.
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Identity is the authenticated caller, taken from the token claims.
+type Identity struct {
+	UserID      string
+	WorkspaceID string
+	Roles       []string
+}
+
+func (i *Identity) HasRole(role string) bool {
+	for _, r := range i.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Config selects the accepted signing keys. HS256 tokens are checked against
+// Secret and RS256 tokens against the keys in the JWKS file; at least one of
+// the two must be set. Issuer and Audience are only checked when non-empty.
+type Config struct {
+	Secret   string
+	JWKSFile string
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+type Verifier struct {
+	secret   []byte
+	keys     map[string]*rsa.PublicKey
+	issuer   string
+	audience string
+	leeway   time.Duration
+}
+
+func NewVerifier(cfg Config) (*Verifier, error) {
+	v := &Verifier{
+		secret:   []byte(cfg.Secret),
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		leeway:   cfg.Leeway,
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+	}
+	if len(v.secret) == 0 && len(v.keys) == 0 {
+		return nil, errors.New("auth: either a JWT secret or a JWKS file must be configured")
+	}
+	return v, nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type claims struct {
+	Subject     string   `json:"sub"`
+	Issuer      string   `json:"iss"`
+	Audience    audience `json:"aud"`
+	ExpiresAt   int64    `json:"exp"`
+	NotBefore   int64    `json:"nbf"`
+	WorkspaceID string   `json:"workspace_id"`
+	Roles       []string `json:"roles"`
+}
+
+// audience accepts both the string and the array form of "aud".
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Verify checks the signature and registered claims of a compact JWT and
+// returns the caller it identifies. Tokens without "exp" are rejected.
+func (v *Verifier) Verify(token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if err := v.verifySignature(h, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if err := v.validateClaims(&c, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		UserID:      c.Subject,
+		WorkspaceID: c.WorkspaceID,
+		Roles:       c.Roles,
+	}, nil
+}
+
+func (v *Verifier) verifySignature(h header, signed string, sig []byte) error {
+	switch h.Alg {
+	case "HS256":
+		if len(v.secret) == 0 {
+			return fmt.Errorf("%w: HS256 is not accepted", ErrInvalidToken)
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	case "RS256":
+		key := v.rsaKey(h.Kid)
+		if key == nil {
+			return fmt.Errorf("%w: unknown key %q", ErrInvalidToken, h.Kid)
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, h.Alg)
+	}
+}
+
+// rsaKey looks a key up by id. A token without "kid" is accepted when the
+// key set holds exactly one key.
+func (v *Verifier) rsaKey(kid string) *rsa.PublicKey {
+	if kid != "" {
+		return v.keys[kid]
+	}
+	if len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+func (v *Verifier) validateClaims(c *claims, now time.Time) error {
+	if c.Subject == "" {
+		return fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+	if c.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing expiry", ErrInvalidToken)
+	}
+	if now.After(time.Unix(c.ExpiresAt, 0).Add(v.leeway)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if c.NotBefore != 0 && now.Before(time.Unix(c.NotBefore, 0).Add(-v.leeway)) {
+		return fmt.Errorf("%w: token not yet valid", ErrInvalidToken)
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.audience != "" {
+		found := false
+		for _, aud := range c.Audience {
+			if aud == v.audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+		}
+	}
+	return nil
+}
+
+func decodeSegment(seg string, dst any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// ── JWKS ──
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// loadJWKS reads the RSA signing keys from a JWKS document. Keys of other
+// types, or marked for encryption, are ignored.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: reading JWKS: %w", err)
+	}
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("auth: parsing JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("auth: key %q: bad modulus", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("auth: key %q: bad exponent", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("auth: JWKS contains no RSA signing keys")
+	}
+	return keys, nil
+}
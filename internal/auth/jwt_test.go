@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+func segment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret string, hdr, claims map[string]any) string {
+	t.Helper()
+	signed := segment(t, hdr) + "." + segment(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, hdr, claims map[string]any) string {
+	t.Helper()
+	signed := segment(t, hdr) + "." + segment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// writeJWKS writes a key set holding the public halves of keys, by kid.
+func writeJWKS(t *testing.T, keys map[string]*rsa.PrivateKey, extra ...map[string]any) string {
+	t.Helper()
+	var set []map[string]any
+	for kid, key := range keys {
+		set = append(set, map[string]any{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	set = append(set, extra...)
+	data, err := json.Marshal(map[string]any{"keys": set})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestVerify(t *testing.T) {
+	key, other := generateKey(t), generateKey(t)
+	v, err := NewVerifier(Config{
+		Secret:   testSecret,
+		JWKSFile: writeJWKS(t, map[string]*rsa.PrivateKey{"k1": key}),
+		Issuer:   "https://issuer.example.com",
+		Audience: "reminders",
+		Leeway:   30 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	valid := func() map[string]any {
+		return map[string]any{
+			"sub":          "u1",
+			"iss":          "https://issuer.example.com",
+			"aud":          "reminders",
+			"exp":          now.Add(time.Hour).Unix(),
+			"workspace_id": "w1",
+			"roles":        []string{"admin"},
+		}
+	}
+	with := func(key string, value any) map[string]any {
+		c := valid()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+	hs := map[string]any{"alg": "HS256", "typ": "JWT"}
+	rs := map[string]any{"alg": "RS256", "kid": "k1"}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"hs256", signHS256(t, testSecret, hs, valid()), true},
+		{"rs256", signRS256(t, key, rs, valid()), true},
+		{"rs256 without kid", signRS256(t, key, map[string]any{"alg": "RS256"}, valid()), true},
+		{"audience list", signHS256(t, testSecret, hs, with("aud", []string{"other", "reminders"})), true},
+		{"expired within leeway", signHS256(t, testSecret, hs, with("exp", now.Add(-10*time.Second).Unix())), true},
+		{"wrong secret", signHS256(t, "other", hs, valid()), false},
+		{"wrong rsa key", signRS256(t, other, rs, valid()), false},
+		{"unknown kid", signRS256(t, key, map[string]any{"alg": "RS256", "kid": "k2"}, valid()), false},
+		{"alg none", segment(t, map[string]any{"alg": "none"}) + "." + segment(t, valid()) + ".", false},
+		{"hs256 keyed with the public key", signHS256(t, string(key.N.Bytes()), hs, valid()), false},
+		{"expired", signHS256(t, testSecret, hs, with("exp", now.Add(-time.Minute).Unix())), false},
+		{"no expiry", signHS256(t, testSecret, hs, with("exp", nil)), false},
+		{"not yet valid", signHS256(t, testSecret, hs, with("nbf", now.Add(time.Minute).Unix())), false},
+		{"no subject", signHS256(t, testSecret, hs, with("sub", nil)), false},
+		{"wrong issuer", signHS256(t, testSecret, hs, with("iss", "https://evil.example.com")), false},
+		{"wrong audience", signHS256(t, testSecret, hs, with("aud", "other")), false},
+		{"two segments", "a.b", false},
+		{"bad header", "!!." + segment(t, valid()) + ".sig", false},
+		{"bad signature encoding", segment(t, hs) + "." + segment(t, valid()) + ".!!", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := v.Verify(tt.token)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Verify error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			want := &Identity{UserID: "u1", WorkspaceID: "w1", Roles: []string{"admin"}}
+			if !reflect.DeepEqual(id, want) {
+				t.Errorf("Verify = %+v, want %+v", id, want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsUnconfiguredAlgorithm(t *testing.T) {
+	key := generateKey(t)
+	rsOnly, err := NewVerifier(Config{JWKSFile: writeJWKS(t, map[string]*rsa.PrivateKey{"k1": key})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]any{"sub": "u1", "exp": time.Now().Add(time.Hour).Unix()}
+	// An empty secret must not verify HS256 tokens MACed with an empty key.
+	if _, err := rsOnly.Verify(signHS256(t, "", map[string]any{"alg": "HS256"}, claims)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("HS256 without a secret: error = %v, want ErrInvalidToken", err)
+	}
+
+	hsOnly, err := NewVerifier(Config{Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hsOnly.Verify(signRS256(t, key, map[string]any{"alg": "RS256"}, claims)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("RS256 without keys: error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRSAKeyWithoutKidNeedsSingleKey(t *testing.T) {
+	keys := map[string]*rsa.PrivateKey{"k1": generateKey(t), "k2": generateKey(t)}
+	v, err := NewVerifier(Config{JWKSFile: writeJWKS(t, keys)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]any{"sub": "u1", "exp": time.Now().Add(time.Hour).Unix()}
+	if _, err := v.Verify(signRS256(t, keys["k1"], map[string]any{"alg": "RS256"}, claims)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("no kid with two keys: error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := v.Verify(signRS256(t, keys["k2"], map[string]any{"alg": "RS256", "kid": "k2"}, claims)); err != nil {
+		t.Errorf("kid k2: %v", err)
+	}
+}
+
+func TestNewVerifierConfig(t *testing.T) {
+	key := generateKey(t)
+	writeRaw := func(data string) string {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{"secret", Config{Secret: testSecret}, true},
+		{"jwks", Config{JWKSFile: writeJWKS(t, map[string]*rsa.PrivateKey{"k1": key})}, true},
+		{"encryption keys ignored", Config{JWKSFile: writeJWKS(t, map[string]*rsa.PrivateKey{"k1": key},
+			map[string]any{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+			map[string]any{"kty": "EC", "kid": "ec"})}, true},
+		{"nothing configured", Config{}, false},
+		{"missing file", Config{JWKSFile: filepath.Join(t.TempDir(), "missing.json")}, false},
+		{"not json", Config{JWKSFile: writeRaw("nope")}, false},
+		{"no rsa keys", Config{JWKSFile: writeRaw(`{"keys":[{"kty":"EC","kid":"ec"}]}`)}, false},
+		{"bad modulus", Config{JWKSFile: writeRaw(`{"keys":[{"kty":"RSA","kid":"k","n":"!!","e":"AQAB"}]}`)}, false},
+		{"bad exponent", Config{JWKSFile: writeRaw(`{"keys":[{"kty":"RSA","kid":"k","n":"AQAB","e":""}]}`)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewVerifier(tt.cfg)
+			if (err == nil) != tt.ok {
+				t.Errorf("NewVerifier error = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestIdentityHasRole(t *testing.T) {
+	id := &Identity{Roles: []string{"member", "admin"}}
+	tests := []struct {
+		role string
+		want bool
+	}{
+		{"admin", true},
+		{"member", true},
+		{"owner", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := id.HasRole(tt.role); got != tt.want {
+			t.Errorf("HasRole(%q) = %v, want %v", tt.role, got, tt.want)
+		}
+	}
+}
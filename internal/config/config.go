@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
 
 type Config struct {
 	Port         string
@@ -8,6 +13,23 @@ type Config struct {
 	DatabaseName string
 	KafkaBrokers []string
 	RedisURL     string
+
+	InstanceID         string
+	SchedulerLease     time.Duration
+	SchedulerBatchSize int
+
+	CommandMaxAttempts  int
+	CommandRetryBackoff time.Duration
+
+	JobLease     time.Duration
+	JobRetention time.Duration
+
+	ActivityRetention time.Duration
+
+	JWTSecret   string
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
 }
 
 func Load() *Config {
@@ -17,6 +39,23 @@ func Load() *Config {
 		DatabaseName: getEnv("DATABASE_NAME", "quickchat_reminders"),
 		KafkaBrokers: []string{getEnv("KAFKA_BROKERS", "localhost:9092")},
 		RedisURL:     getEnv("REDIS_URL", "localhost:6379"),
+
+		InstanceID:         getEnv("INSTANCE_ID", defaultInstanceID()),
+		SchedulerLease:     getDurationEnv("SCHEDULER_LEASE", 2*time.Minute),
+		SchedulerBatchSize: getIntEnv("SCHEDULER_BATCH_SIZE", 500),
+
+		CommandMaxAttempts:  getIntEnv("COMMAND_MAX_ATTEMPTS", 5),
+		CommandRetryBackoff: getDurationEnv("COMMAND_RETRY_BACKOFF", 500*time.Millisecond),
+
+		JobLease:     getDurationEnv("JOB_LEASE", 2*time.Minute),
+		JobRetention: getDurationEnv("JOB_RETENTION", 7*24*time.Hour),
+
+		ActivityRetention: getDurationEnv("ACTIVITY_RETENTION", 90*24*time.Hour),
+
+		JWTSecret:   getEnv("JWT_SECRET", ""),
+		JWKSFile:    getEnv("JWT_JWKS_FILE", ""),
+		JWTIssuer:   getEnv("JWT_ISSUER", ""),
+		JWTAudience: getEnv("JWT_AUDIENCE", ""),
 	}
 }
 
@@ -26,3 +65,29 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "reminder-service"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,309 @@
+// Package ical writes RFC 5545 calendars.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event statuses allowed on a VEVENT.
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+const (
+	utcFormat   = "20060102T150405Z"
+	localFormat = "20060102T150405"
+
+	// maxLineOctets is the longest content line allowed before folding.
+	maxLineOctets = 75
+)
+
+type Calendar struct {
+	ProdID string
+	// Name is shown by most clients as the calendar title (X-WR-CALNAME).
+	Name   string
+	Events []Event
+}
+
+// Event is a VEVENT. Start is written in UTC unless Location names a zone
+// other than UTC, in which case it is written as wall-clock time with a TZID
+// and a matching VTIMEZONE is added to the calendar.
+type Event struct {
+	UID          string
+	Stamp        time.Time
+	LastModified time.Time
+	Start        time.Time
+	Location     *time.Location
+	Duration     time.Duration
+	Summary      string
+	Description  string
+	Status       string
+	// Priority is 1 (highest) to 9 (lowest); 0 leaves it undefined.
+	Priority int
+	RRule    string
+	ExDates  []time.Time
+	Alarms   []Alarm
+}
+
+// Alarm is a display VALARM that fires Before the event starts.
+type Alarm struct {
+	Before      time.Duration
+	Description string
+}
+
+// Marshal renders the calendar with CRLF line endings and folded lines.
+func Marshal(cal *Calendar) []byte {
+	w := &writer{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", cal.ProdID)
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		w.line("X-WR-CALNAME", Escape(cal.Name))
+	}
+
+	for _, tz := range timezones(cal.Events) {
+		tz.write(w)
+	}
+	for i := range cal.Events {
+		cal.Events[i].write(w)
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.buf.Bytes()
+}
+
+func (e *Event) write(w *writer) {
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", e.UID)
+	w.line("DTSTAMP", e.Stamp.UTC().Format(utcFormat))
+	if !e.LastModified.IsZero() {
+		w.line("LAST-MODIFIED", e.LastModified.UTC().Format(utcFormat))
+	}
+	w.line("DTSTART"+e.tzParam(), e.formatTime(e.Start))
+	if e.Duration > 0 {
+		w.line("DURATION", formatDuration(e.Duration))
+	}
+	w.line("SUMMARY", Escape(e.Summary))
+	if e.Description != "" {
+		w.line("DESCRIPTION", Escape(e.Description))
+	}
+	if e.Status != "" {
+		w.line("STATUS", e.Status)
+	}
+	if e.Priority > 0 {
+		w.line("PRIORITY", fmt.Sprint(e.Priority))
+	}
+	if e.RRule != "" {
+		w.line("RRULE", e.RRule)
+		for _, ex := range e.ExDates {
+			w.line("EXDATE"+e.tzParam(), e.formatTime(ex))
+		}
+	}
+	for _, a := range e.Alarms {
+		w.line("BEGIN", "VALARM")
+		w.line("ACTION", "DISPLAY")
+		w.line("TRIGGER", formatDuration(-a.Before))
+		w.line("DESCRIPTION", Escape(a.Description))
+		w.line("END", "VALARM")
+	}
+	w.line("END", "VEVENT")
+}
+
+func (e *Event) zoned() bool {
+	return e.Location != nil && e.Location != time.UTC && e.Location.String() != "UTC"
+}
+
+func (e *Event) tzParam() string {
+	if !e.zoned() {
+		return ""
+	}
+	return ";TZID=" + e.Location.String()
+}
+
+func (e *Event) formatTime(t time.Time) string {
+	if !e.zoned() {
+		return t.UTC().Format(utcFormat)
+	}
+	return t.In(e.Location).Format(localFormat)
+}
+
+// Escape escapes a TEXT value: backslashes, semicolons, commas and newlines.
+func Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', ';', ',':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\r':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				continue
+			}
+			b.WriteString(`\n`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// formatDuration renders a DURATION value such as PT1H30M or -PT15M.
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	d = d.Truncate(time.Second)
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	b.WriteString(sign + "P")
+	if days := d / (24 * time.Hour); days > 0 && d%(24*time.Hour) == 0 {
+		fmt.Fprintf(&b, "%dD", days)
+		return b.String()
+	}
+	b.WriteString("T")
+	if h := d / time.Hour; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+	}
+	if m := d % time.Hour / time.Minute; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+	}
+	if s := d % time.Minute / time.Second; s > 0 {
+		fmt.Fprintf(&b, "%dS", s)
+	}
+	return b.String()
+}
+
+// ── Writer ──
+
+type writer struct {
+	buf bytes.Buffer
+}
+
+// line writes "name:value", folding it into continuation lines of at most
+// 75 octets without splitting a UTF-8 sequence.
+func (w *writer) line(name, value string) {
+	s := name + ":" + value
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.buf.WriteString(s[:cut])
+		w.buf.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = maxLineOctets - 1
+	}
+	w.buf.WriteString(s)
+	w.buf.WriteString("\r\n")
+}
+
+// ── Timezones ──
+
+// vtimezone describes one zone by listing its actual UTC offset changes
+// over the years the calendar's events cover.
+type vtimezone struct {
+	loc         *time.Location
+	from, until time.Time
+}
+
+// timezones returns a VTIMEZONE for every zone referenced by an event. The
+// covered range runs from a year before the first event to ten years after
+// the last, which is long enough for recurring events in practice.
+func timezones(events []Event) []vtimezone {
+	byName := map[string]*vtimezone{}
+	for _, e := range events {
+		if !e.zoned() {
+			continue
+		}
+		tz, ok := byName[e.Location.String()]
+		if !ok {
+			tz = &vtimezone{loc: e.Location, from: e.Start, until: e.Start}
+			byName[e.Location.String()] = tz
+		}
+		if e.Start.Before(tz.from) {
+			tz.from = e.Start
+		}
+		if e.Start.After(tz.until) {
+			tz.until = e.Start
+		}
+	}
+
+	out := make([]vtimezone, 0, len(byName))
+	for _, tz := range byName {
+		tz.from = tz.from.AddDate(-1, 0, 0)
+		tz.until = tz.until.AddDate(10, 0, 0)
+		out = append(out, *tz)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].loc.String() < out[j].loc.String() })
+	return out
+}
+
+func (tz vtimezone) write(w *writer) {
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", tz.loc.String())
+
+	// The zone in effect at the start of the range, then every change.
+	t := tz.from.In(tz.loc)
+	start, end := t.ZoneBounds()
+	name, offset := t.Zone()
+	onset := "19700101T000000"
+	if !start.IsZero() {
+		onset = start.UTC().Add(time.Duration(offset) * time.Second).Format(localFormat)
+	}
+	writeObservance(w, t.IsDST(), onset, offset, offset, name)
+
+	for !end.IsZero() && end.Before(tz.until) {
+		_, prev := end.Add(-time.Second).In(tz.loc).Zone()
+		next := end.In(tz.loc)
+		name, offset := next.Zone()
+		onset := end.UTC().Add(time.Duration(prev) * time.Second).Format(localFormat)
+		writeObservance(w, next.IsDST(), onset, prev, offset, name)
+		_, end = next.ZoneBounds()
+	}
+
+	w.line("END", "VTIMEZONE")
+}
+
+func writeObservance(w *writer, dst bool, onset string, from, to int, name string) {
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+	w.line("BEGIN", kind)
+	w.line("DTSTART", onset)
+	w.line("TZOFFSETFROM", formatOffset(from))
+	w.line("TZOFFSETTO", formatOffset(to))
+	if name != "" {
+		w.line("TZNAME", Escape(name))
+	}
+	w.line("END", kind)
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+	if sec := seconds % 60; sec != 0 {
+		s += fmt.Sprintf("%02d", sec)
+	}
+	return s
+}
@@ -0,0 +1,223 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone unavailable: %v", err)
+	}
+	stamp := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		event Event
+	}{
+		{
+			name: "utc",
+			event: Event{
+				UID:      "a@example.com",
+				Stamp:    stamp,
+				Start:    time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+				Summary:  "Pay rent",
+				Status:   StatusConfirmed,
+				Priority: 1,
+			},
+		},
+		{
+			name: "zoned series",
+			event: Event{
+				UID:          "b@example.com",
+				Stamp:        stamp,
+				LastModified: stamp.Add(time.Hour),
+				Start:        time.Date(2025, 3, 28, 9, 0, 0, 0, berlin),
+				Location:     berlin,
+				Duration:     30 * time.Minute,
+				Summary:      "Stand-up; daily, with notes\\links",
+				Description:  "line one\nline two",
+				RRule:        "FREQ=WEEKLY;BYDAY=FR",
+				ExDates:      []time.Time{time.Date(2025, 4, 4, 9, 0, 0, 0, berlin)},
+				Alarms:       []Alarm{{Before: 15 * time.Minute, Description: "Stand-up"}},
+			},
+		},
+		{
+			name: "long unicode summary",
+			event: Event{
+				UID:     "c@example.com",
+				Stamp:   stamp,
+				Start:   time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+				Summary: strings.Repeat("Überprüfung der Jahresabrechnung ✓ ", 6),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := Marshal(&Calendar{ProdID: "-//test//EN", Name: "Team, reminders", Events: []Event{tt.event}})
+			for _, line := range strings.Split(string(data), "\r\n") {
+				if len(line) > maxLineOctets {
+					t.Errorf("line longer than %d octets: %q", maxLineOctets, line)
+				}
+			}
+
+			cal, err := Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if got := cal.Text("X-WR-CALNAME"); got != "Team, reminders" {
+				t.Errorf("X-WR-CALNAME = %q", got)
+			}
+			events := cal.Children("VEVENT")
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+			checkEvent(t, cal, events[0], &tt.event)
+		})
+	}
+}
+
+func checkEvent(t *testing.T, cal, got *Component, want *Event) {
+	t.Helper()
+	resolve := cal.Resolver()
+
+	if got.Text("UID") != want.UID {
+		t.Errorf("UID = %q, want %q", got.Text("UID"), want.UID)
+	}
+	if got.Text("SUMMARY") != want.Summary {
+		t.Errorf("SUMMARY = %q, want %q", got.Text("SUMMARY"), want.Summary)
+	}
+	if got.Text("DESCRIPTION") != want.Description {
+		t.Errorf("DESCRIPTION = %q, want %q", got.Text("DESCRIPTION"), want.Description)
+	}
+	if got.Text("STATUS") != want.Status {
+		t.Errorf("STATUS = %q, want %q", got.Text("STATUS"), want.Status)
+	}
+	if got.Text("RRULE") != want.RRule {
+		t.Errorf("RRULE = %q, want %q", got.Text("RRULE"), want.RRule)
+	}
+
+	start, allDay, err := got.Prop("DTSTART").Time(resolve, time.UTC)
+	if err != nil || allDay || !start.Equal(want.Start) {
+		t.Errorf("DTSTART = %v, %v, %v, want %v", start, allDay, err, want.Start)
+	}
+	if want.Location != nil && start.Location().String() != want.Location.String() {
+		t.Errorf("DTSTART zone = %s, want %s", start.Location(), want.Location)
+	}
+
+	var exdates []time.Time
+	for _, p := range got.PropsNamed("EXDATE") {
+		times, err := p.Times(resolve, time.UTC)
+		if err != nil {
+			t.Fatalf("EXDATE: %v", err)
+		}
+		exdates = append(exdates, times...)
+	}
+	if len(exdates) != len(want.ExDates) {
+		t.Fatalf("EXDATE = %v, want %v", exdates, want.ExDates)
+	}
+	for i := range exdates {
+		if !exdates[i].Equal(want.ExDates[i]) {
+			t.Errorf("EXDATE[%d] = %v, want %v", i, exdates[i], want.ExDates[i])
+		}
+	}
+
+	if want.Duration > 0 {
+		d, err := ParseDuration(got.Text("DURATION"))
+		if err != nil || d != want.Duration {
+			t.Errorf("DURATION = %v, %v, want %v", d, err, want.Duration)
+		}
+	}
+
+	alarms := got.Children("VALARM")
+	if len(alarms) != len(want.Alarms) {
+		t.Fatalf("got %d alarms, want %d", len(alarms), len(want.Alarms))
+	}
+	for i, a := range alarms {
+		d, err := ParseDuration(a.Text("TRIGGER"))
+		if err != nil || d != -want.Alarms[i].Before {
+			t.Errorf("alarm %d TRIGGER = %v, %v, want %v", i, d, err, -want.Alarms[i].Before)
+		}
+	}
+}
+
+func TestMarshalWritesTimezoneOnce(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone unavailable: %v", err)
+	}
+	events := []Event{
+		{UID: "1", Start: time.Date(2025, 1, 10, 9, 0, 0, 0, ny), Location: ny},
+		{UID: "2", Start: time.Date(2025, 7, 10, 9, 0, 0, 0, ny), Location: ny},
+		{UID: "3", Start: time.Date(2025, 7, 10, 9, 0, 0, 0, time.UTC), Location: time.UTC},
+	}
+	cal, err := Decode(bytes.NewReader(Marshal(&Calendar{ProdID: "-//test//EN", Events: events})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zones := cal.Children("VTIMEZONE")
+	if len(zones) != 1 || zones[0].Text("TZID") != "America/New_York" {
+		t.Fatalf("VTIMEZONEs = %+v, want one for America/New_York", zones)
+	}
+	if len(zones[0].Children("DAYLIGHT")) == 0 || len(zones[0].Children("STANDARD")) == 0 {
+		t.Errorf("VTIMEZONE lacks DAYLIGHT or STANDARD observances")
+	}
+	if p := cal.Children("VEVENT")[2].Prop("DTSTART"); p.Params["TZID"] != "" || !strings.HasSuffix(p.Value, "Z") {
+		t.Errorf("UTC event DTSTART = %+v, want a UTC time", p)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{`a,b;c\d`, `a\,b\;c\\d`},
+		{"one\r\ntwo\nthree\rfour", `one\ntwo\nthree\nfour`},
+	}
+	for _, tt := range tests {
+		if got := Escape(tt.in); got != tt.want {
+			t.Errorf("Escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "PT0S"},
+		{15 * time.Minute, "PT15M"},
+		{-15 * time.Minute, "-PT15M"},
+		{90*time.Minute + 5*time.Second, "PT1H30M5S"},
+		{48 * time.Hour, "P2D"},
+		{25 * time.Hour, "PT25H"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.in); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatOffset(t *testing.T) {
+	tests := []struct {
+		in   int
+		want string
+	}{
+		{0, "+0000"},
+		{3600, "+0100"},
+		{-5 * 3600, "-0500"},
+		{5*3600 + 1800, "+0530"},
+		{-(3600 + 15), "-010015"},
+	}
+	for _, tt := range tests {
+		if got := formatOffset(tt.in); got != tt.want {
+			t.Errorf("formatOffset(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
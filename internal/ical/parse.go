@@ -0,0 +1,327 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMalformed is returned for input that is not a well-formed iCalendar
+// stream.
+var ErrMalformed = errors.New("malformed iCalendar data")
+
+// Component is a parsed BEGIN/END block such as VCALENDAR, VEVENT or VALARM.
+type Component struct {
+	Name       string
+	Props      []*Property
+	Components []*Component
+}
+
+// Property is one content line. Names and parameter names are upper-cased;
+// Value is left escaped, see Text.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Decode reads a single VCALENDAR object. Folded lines are unfolded and both
+// CRLF and bare LF line endings are accepted.
+func Decode(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for _, l := range lines {
+		prop, err := parseLine(l.text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrMalformed, l.number, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			} else if root != nil {
+				return nil, fmt.Errorf("%w: line %d: content after END:%s", ErrMalformed, l.number, root.Name)
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("%w: line %d: unexpected END:%s", ErrMalformed, l.number, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: line %d: property %s outside a component", ErrMalformed, l.number, prop.Name)
+			}
+			c := stack[len(stack)-1]
+			c.Props = append(c.Props, prop)
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("%w: no VCALENDAR found", ErrMalformed)
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%w: missing END:%s", ErrMalformed, stack[len(stack)-1].Name)
+	}
+	if root.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("%w: expected VCALENDAR, got %s", ErrMalformed, root.Name)
+	}
+	return root, nil
+}
+
+// Prop returns the first property called name, or nil.
+func (c *Component) Prop(name string) *Property {
+	for _, p := range c.Props {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// PropsNamed returns every property called name, in order.
+func (c *Component) PropsNamed(name string) []*Property {
+	var out []*Property
+	for _, p := range c.Props {
+		if p.Name == name {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Children returns the direct sub-components called name.
+func (c *Component) Children(name string) []*Component {
+	var out []*Component
+	for _, child := range c.Components {
+		if child.Name == name {
+			out = append(out, child)
+		}
+	}
+	return out
+}
+
+// Text returns the value of the first property called name, unescaped, or
+// "" if it is absent.
+func (c *Component) Text(name string) string {
+	if p := c.Prop(name); p != nil {
+		return Unescape(p.Value)
+	}
+	return ""
+}
+
+// Unescape reverses Escape.
+func Unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// ── Values ──
+
+// Resolver maps a TZID parameter to a location.
+type Resolver func(tzid string) (*time.Location, error)
+
+// Time reads a DATE or DATE-TIME value. UTC values end in Z, zoned values
+// carry a TZID that resolve maps to a location, and floating values are read
+// in floating. allDay reports a DATE value, returned as midnight.
+func (p *Property) Time(resolve Resolver, floating *time.Location) (t time.Time, allDay bool, err error) {
+	value := strings.TrimSpace(p.Value)
+	loc := floating
+	if tzid := p.Params["TZID"]; tzid != "" && !strings.HasSuffix(value, "Z") {
+		if loc, err = resolve(tzid); err != nil {
+			return time.Time{}, false, err
+		}
+	}
+
+	if p.Params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err = time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse(utcFormat, value)
+		return t, false, err
+	}
+	t, err = time.ParseInLocation(localFormat, value, loc)
+	return t, false, err
+}
+
+// Times reads a property that may hold a comma-separated list of dates, such
+// as EXDATE.
+func (p *Property) Times(resolve Resolver, floating *time.Location) ([]time.Time, error) {
+	var out []time.Time
+	for _, v := range strings.Split(p.Value, ",") {
+		single := &Property{Name: p.Name, Params: p.Params, Value: v}
+		t, _, err := single.Time(resolve, floating)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// ParseDuration reads a DURATION value such as PT15M, -P1D or P1W.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("%w: invalid duration %q", ErrMalformed, s)
+	}
+
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			num += string(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid duration %q", ErrMalformed, s)
+		}
+		num = ""
+		unit := time.Duration(0)
+		switch {
+		case r == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case r == 'H' && inTime:
+			unit = time.Hour
+		case r == 'M' && inTime:
+			unit = time.Minute
+		case r == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("%w: invalid duration %q", ErrMalformed, s)
+		}
+		d += time.Duration(n) * unit
+	}
+	if num != "" {
+		return 0, fmt.Errorf("%w: invalid duration %q", ErrMalformed, s)
+	}
+	return sign * d, nil
+}
+
+// ── Lexing ──
+
+type contentLine struct {
+	number int
+	text   string
+}
+
+func unfold(r io.Reader) ([]contentLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var lines []contentLine
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		lines = append(lines, contentLine{number: number, text: text})
+	}
+	if err := scanner.Err(); err == bufio.ErrTooLong {
+		return nil, fmt.Errorf("%w: line %d is too long", ErrMalformed, number+1)
+	} else if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseLine splits "NAME;PARAM=a;PARAM="b:c":value". Colons and semicolons
+// inside quoted parameter values do not end the parameter.
+func parseLine(s string) (*Property, error) {
+	prop := &Property{Params: map[string]string{}}
+
+	i := strings.IndexAny(s, ";:")
+	if i <= 0 {
+		return nil, fmt.Errorf("missing property name in %q", s)
+	}
+	prop.Name = strings.ToUpper(s[:i])
+
+	for s[i] == ';' {
+		s = s[i+1:]
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("malformed parameter in %s", prop.Name)
+		}
+		name := strings.ToUpper(s[:eq])
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in %s", prop.Name)
+			}
+			value, s = s[1:end+1], s[end+2:]
+			i = 0
+		} else {
+			i = strings.IndexAny(s, ";:")
+			if i < 0 {
+				return nil, fmt.Errorf("missing value in %s", prop.Name)
+			}
+			value = s[:i]
+			s = s[i:]
+			i = 0
+		}
+		if len(s) == 0 {
+			return nil, fmt.Errorf("missing value in %s", prop.Name)
+		}
+		prop.Params[name] = value
+	}
+	if s[i] != ':' {
+		return nil, fmt.Errorf("missing value in %s", prop.Name)
+	}
+	prop.Value = s[i+1:]
+	return prop, nil
+}
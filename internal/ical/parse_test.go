@@ -0,0 +1,193 @@
+package ical
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	input := "BEGIN:VCALENDAR\n" +
+		"VERSION:2.0\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:1\r\n" +
+		"SUMMARY:Folded\r\n" +
+		"  summary\r\n" +
+		"\tline\r\n" +
+		"DTSTART;TZID=\"Europe/Paris\";VALUE=DATE-TIME:20250310T090000\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	events := cal.Children("VEVENT")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	ev := events[0]
+	if got := ev.Text("SUMMARY"); got != "Folded summaryline" {
+		t.Errorf("SUMMARY = %q", got)
+	}
+	start := ev.Prop("DTSTART")
+	want := map[string]string{"TZID": "Europe/Paris", "VALUE": "DATE-TIME"}
+	if !reflect.DeepEqual(start.Params, want) || start.Value != "20250310T090000" {
+		t.Errorf("DTSTART = %+v", start)
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"not a calendar", "BEGIN:VEVENT\nEND:VEVENT\n"},
+		{"missing end", "BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VEVENT\n"},
+		{"mismatched end", "BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VTODO\nEND:VCALENDAR\n"},
+		{"property outside", "VERSION:2.0\nBEGIN:VCALENDAR\nEND:VCALENDAR\n"},
+		{"content after end", "BEGIN:VCALENDAR\nEND:VCALENDAR\nBEGIN:VCALENDAR\nEND:VCALENDAR\n"},
+		{"no value", "BEGIN:VCALENDAR\nVERSION\nEND:VCALENDAR\n"},
+		{"unterminated quote", "BEGIN:VCALENDAR\nX;A=\"b:c\nEND:VCALENDAR\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(strings.NewReader(tt.input)); !errors.Is(err, ErrMalformed) {
+				t.Errorf("Decode error = %v, want ErrMalformed", err)
+			}
+		})
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		in     string
+		name   string
+		params map[string]string
+		value  string
+	}{
+		{"summary:hello", "SUMMARY", map[string]string{}, "hello"},
+		{"DTSTART;tzid=Europe/Paris:20250310T090000", "DTSTART", map[string]string{"TZID": "Europe/Paris"}, "20250310T090000"},
+		{`ATTENDEE;CN="Doe; Jane";ROLE=CHAIR:mailto:jane@example.com`, "ATTENDEE", map[string]string{"CN": "Doe; Jane", "ROLE": "CHAIR"}, "mailto:jane@example.com"},
+		{"DESCRIPTION:a:b", "DESCRIPTION", map[string]string{}, "a:b"},
+	}
+	for _, tt := range tests {
+		p, err := parseLine(tt.in)
+		if err != nil {
+			t.Errorf("parseLine(%q): %v", tt.in, err)
+			continue
+		}
+		if p.Name != tt.name || !reflect.DeepEqual(p.Params, tt.params) || p.Value != tt.value {
+			t.Errorf("parseLine(%q) = %+v", tt.in, p)
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	for _, s := range []string{"plain", `a,b;c\d`, "one\ntwo", `trailing\`} {
+		if got := Unescape(Escape(s)); got != s {
+			t.Errorf("Unescape(Escape(%q)) = %q", s, got)
+		}
+	}
+	if got := Unescape(`a\Nb\,c`); got != "a\nb,c" {
+		t.Errorf(`Unescape("a\Nb\,c") = %q`, got)
+	}
+}
+
+func TestPropertyTime(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("timezone unavailable: %v", err)
+	}
+	resolve := (&Component{}).Resolver()
+	tests := []struct {
+		name   string
+		prop   Property
+		want   time.Time
+		allDay bool
+	}{
+		{"utc", Property{Value: "20250310T090000Z"}, time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), false},
+		{"zoned", Property{Params: map[string]string{"TZID": "Europe/Paris"}, Value: "20250310T090000"}, time.Date(2025, 3, 10, 9, 0, 0, 0, paris), false},
+		{"utc wins over tzid", Property{Params: map[string]string{"TZID": "Europe/Paris"}, Value: "20250310T090000Z"}, time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), false},
+		{"floating", Property{Value: "20250310T090000"}, time.Date(2025, 3, 10, 9, 0, 0, 0, paris), false},
+		{"date", Property{Params: map[string]string{"VALUE": "DATE"}, Value: "20250310"}, time.Date(2025, 3, 10, 0, 0, 0, 0, paris), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, allDay, err := tt.prop.Time(resolve, paris)
+			if err != nil || !got.Equal(tt.want) || allDay != tt.allDay {
+				t.Errorf("Time = %v, %v, %v, want %v, %v", got, allDay, err, tt.want, tt.allDay)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "PT15M", want: 15 * time.Minute},
+		{in: "-PT15M", want: -15 * time.Minute},
+		{in: "+P1D", want: 24 * time.Hour},
+		{in: "P1W", want: 7 * 24 * time.Hour},
+		{in: "P1DT2H30M10S", want: 26*time.Hour + 30*time.Minute + 10*time.Second},
+		{in: "PT", wantErr: true},
+		{in: "15M", wantErr: true},
+		{in: "P1H", wantErr: true},
+		{in: "PT1D", wantErr: true},
+		{in: "PT15", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrMalformed) {
+				t.Errorf("ParseDuration(%q) error = %v, want ErrMalformed", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	cal := &Component{Components: []*Component{{
+		Name: "VTIMEZONE",
+		Props: []*Property{
+			{Name: "TZID", Value: "Custom Zone"},
+			{Name: "X-LIC-LOCATION", Value: "Asia/Tokyo"},
+		},
+	}}}
+	resolve := cal.Resolver()
+
+	tests := []struct {
+		tzid    string
+		want    string
+		wantErr bool
+	}{
+		{tzid: "Europe/Paris", want: "Europe/Paris"},
+		{tzid: "/mozilla.org/20070129_1/Europe/Paris", want: "Europe/Paris"},
+		{tzid: "W. Europe Standard Time", want: "Europe/Berlin"},
+		{tzid: "Custom Zone", want: "Asia/Tokyo"},
+		{tzid: "Nowhere Standard Time", wantErr: true},
+		{tzid: "local", wantErr: true},
+	}
+	for _, tt := range tests {
+		loc, err := resolve(tt.tzid)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("resolve(%q) = %v, want an error", tt.tzid, loc)
+			}
+			continue
+		}
+		if err != nil || loc.String() != tt.want {
+			t.Errorf("resolve(%q) = %v, %v, want %s", tt.tzid, loc, err, tt.want)
+		}
+	}
+}
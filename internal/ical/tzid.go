@@ -0,0 +1,110 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// windowsZones maps the Windows zone names Outlook and Exchange write as
+// TZIDs to IANA zones. It covers the zones our users are in; others fail to
+// resolve and are reported per item.
+var windowsZones = map[string]string{
+	"Dateline Standard Time":          "Etc/GMT+12",
+	"Hawaiian Standard Time":          "Pacific/Honolulu",
+	"Alaskan Standard Time":           "America/Anchorage",
+	"Pacific Standard Time":           "America/Los_Angeles",
+	"Mountain Standard Time":          "America/Denver",
+	"US Mountain Standard Time":       "America/Phoenix",
+	"Central Standard Time":           "America/Chicago",
+	"Central America Standard Time":   "America/Guatemala",
+	"Mexico Standard Time":            "America/Mexico_City",
+	"Canada Central Standard Time":    "America/Regina",
+	"Eastern Standard Time":           "America/New_York",
+	"SA Pacific Standard Time":        "America/Bogota",
+	"Atlantic Standard Time":          "America/Halifax",
+	"Newfoundland Standard Time":      "America/St_Johns",
+	"E. South America Standard Time":  "America/Sao_Paulo",
+	"Argentina Standard Time":         "America/Buenos_Aires",
+	"UTC":                             "UTC",
+	"GMT Standard Time":               "Europe/London",
+	"Greenwich Standard Time":         "Atlantic/Reykjavik",
+	"W. Europe Standard Time":         "Europe/Berlin",
+	"Central Europe Standard Time":    "Europe/Budapest",
+	"Central European Standard Time":  "Europe/Warsaw",
+	"Romance Standard Time":           "Europe/Paris",
+	"E. Europe Standard Time":         "Europe/Chisinau",
+	"FLE Standard Time":               "Europe/Kiev",
+	"GTB Standard Time":               "Europe/Bucharest",
+	"Turkey Standard Time":            "Europe/Istanbul",
+	"Israel Standard Time":            "Asia/Jerusalem",
+	"South Africa Standard Time":      "Africa/Johannesburg",
+	"Egypt Standard Time":             "Africa/Cairo",
+	"Russian Standard Time":           "Europe/Moscow",
+	"Arabian Standard Time":           "Asia/Dubai",
+	"Pakistan Standard Time":          "Asia/Karachi",
+	"India Standard Time":             "Asia/Kolkata",
+	"Bangladesh Standard Time":        "Asia/Dhaka",
+	"SE Asia Standard Time":           "Asia/Bangkok",
+	"China Standard Time":             "Asia/Shanghai",
+	"Singapore Standard Time":         "Asia/Singapore",
+	"Taipei Standard Time":            "Asia/Taipei",
+	"Tokyo Standard Time":             "Asia/Tokyo",
+	"Korea Standard Time":             "Asia/Seoul",
+	"AUS Eastern Standard Time":       "Australia/Sydney",
+	"E. Australia Standard Time":      "Australia/Brisbane",
+	"Cen. Australia Standard Time":    "Australia/Adelaide",
+	"W. Australia Standard Time":      "Australia/Perth",
+	"New Zealand Standard Time":       "Pacific/Auckland",
+	"Central Pacific Standard Time":   "Pacific/Guadalcanal",
+	"Tonga Standard Time":             "Pacific/Tongatapu",
+	"Azores Standard Time":            "Atlantic/Azores",
+	"Morocco Standard Time":           "Africa/Casablanca",
+	"W. Central Africa Standard Time": "Africa/Lagos",
+	"E. Africa Standard Time":         "Africa/Nairobi",
+}
+
+// Resolver returns a TZID resolver for a parsed VCALENDAR. A TZID resolves
+// when it is an IANA name, ends in one (as in "/mozilla.org/.../Europe/Paris"),
+// names a Windows zone, or its VTIMEZONE carries an X-LIC-LOCATION.
+func (c *Component) Resolver() Resolver {
+	hints := map[string]string{}
+	for _, tz := range c.Children("VTIMEZONE") {
+		if loc := tz.Text("X-LIC-LOCATION"); loc != "" {
+			hints[tz.Text("TZID")] = loc
+		}
+	}
+
+	cache := map[string]*time.Location{}
+	return func(tzid string) (*time.Location, error) {
+		if loc, ok := cache[tzid]; ok {
+			return loc, nil
+		}
+		loc, err := resolveTZID(tzid, hints)
+		if err != nil {
+			return nil, err
+		}
+		cache[tzid] = loc
+		return loc, nil
+	}
+}
+
+func resolveTZID(tzid string, hints map[string]string) (*time.Location, error) {
+	candidates := []string{tzid, hints[tzid], windowsZones[tzid]}
+
+	// Strip vendor prefixes one path segment at a time.
+	parts := strings.Split(strings.Trim(tzid, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		candidates = append(candidates, strings.Join(parts[i:], "/"))
+	}
+
+	for _, name := range candidates {
+		if name == "" || strings.EqualFold(name, "local") {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown TZID %q", tzid)
+}
@@ -14,3 +14,9 @@ type ReminderCanceller interface {
 type EventPublisher interface {
 	Publish(topic string, message interface{}) error
 }
+
+// MessagePublisher publishes an already-encoded message with a partition key
+// and headers
+type MessagePublisher interface {
+	PublishMessage(topic, key string, value []byte, headers map[string]string) error
+}
@@ -3,27 +3,93 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/interfaces"
 	"reminder-service/internal/models"
+	"reminder-service/internal/recurrence"
+	"reminder-service/internal/service"
 
 	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin/binding"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultReplyTopic receives command results when a command has no
+	// reply_to. A reply_to must be this topic or one under it, such as
+	// "reminders.commands.replies.billing".
+	DefaultReplyTopic = "reminders.commands.replies"
+	// DeadLetterTopic receives commands that still fail after every retry.
+	DeadLetterTopic = "reminders.commands.dlq"
 )
 
 // ReminderHandler defines the interface for handling reminder commands from Kafka.
 type ReminderHandler interface {
+	Create(ctx context.Context, req *models.CreateReminderRequest) (*models.Reminder, error)
+	Update(ctx context.Context, id string, req *models.UpdateReminderRequest) (*models.Reminder, error)
+	Snooze(ctx context.Context, id string, duration time.Duration, snoozedBy string, source models.SnoozeSource) (*models.Reminder, error)
+	Complete(ctx context.Context, id string) error
 	Cancel(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
+}
+
+// CommandLog remembers the reply sent for each idempotency key. A key is
+// reserved before its command runs, so a redelivered or concurrent copy is
+// never run alongside it, and is then either finished with the reply or
+// released for a retry.
+type CommandLog interface {
+	Reserve(ctx context.Context, reply *models.CommandReply) (*models.CommandReply, error)
+	Finish(ctx context.Context, reply *models.CommandReply) error
+	Release(ctx context.Context, key string) error
+}
+
+// DeadLetterStore keeps dead-lettered commands for inspection and replay.
+type DeadLetterStore interface {
+	Record(ctx context.Context, dl *models.DeadLetter) error
+}
+
+// Publisher is what the consumer needs from the producer: JSON replies and
+// raw republishing for the dead-letter topic.
+type Publisher interface {
+	interfaces.EventPublisher
+	interfaces.MessagePublisher
+}
+
+// RetryPolicy controls in-process retries of a failing command. The delay
+// doubles after each attempt, starting at Backoff.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	return p.Backoff * time.Duration(1<<(attempt-1))
 }
 
+// ErrInvalidCommand marks commands that fail schema validation. They are
+// answered with an error and never retried.
+var ErrInvalidCommand = errors.New("invalid command")
+
 type Consumer struct {
-	consumer sarama.ConsumerGroup
-	service  ReminderHandler
-	topics   []string
-	ready    chan bool
+	consumer    sarama.ConsumerGroup
+	service     ReminderHandler
+	commands    CommandLog
+	deadLetters DeadLetterStore
+	publisher   Publisher
+	retry       RetryPolicy
+	topics      []string
+	ready       chan bool
 }
 
-func NewConsumer(brokers []string, groupID string, svc ReminderHandler) (*Consumer, error) {
+func NewConsumer(brokers []string, groupID string, svc ReminderHandler, commands CommandLog, deadLetters DeadLetterStore, publisher Publisher, retry RetryPolicy) (*Consumer, error) {
 	config := sarama.NewConfig()
 	config.Consumer.Group.Rebalance.Strategy = sarama.NewBalanceStrategyRoundRobin()
 	config.Consumer.Offsets.Initial = sarama.OffsetNewest
@@ -33,16 +99,24 @@ func NewConsumer(brokers []string, groupID string, svc ReminderHandler) (*Consum
 		return nil, err
 	}
 
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+
 	return &Consumer{
-		consumer:  consumer,
-		canceller: canceller,
-		topics:    []string{"reminders.commands"},
-		ready:     make(chan bool),
+		consumer:    consumer,
+		service:     svc,
+		commands:    commands,
+		deadLetters: deadLetters,
+		publisher:   publisher,
+		retry:       retry,
+		topics:      []string{"reminders.commands"},
+		ready:       make(chan bool),
 	}, nil
 }
 
 func (c *Consumer) Start() {
-	ctx := context.Background()
+	ctx := audit.WithSource(context.Background(), audit.SourceKafka)
 
 	for {
 		if err := c.consumer.Consume(ctx, c.topics, c); err != nil {
@@ -57,7 +131,12 @@ func (c *Consumer) Close() error {
 
 // ConsumerGroupHandler implementation
 func (c *Consumer) Setup(sarama.ConsumerGroupSession) error {
-	close(c.ready)
+	// Setup runs on every rebalance; only the first one closes ready.
+	select {
+	case <-c.ready:
+	default:
+		close(c.ready)
+	}
 	return nil
 }
 
@@ -65,64 +144,282 @@ func (c *Consumer) Cleanup(sarama.ConsumerGroupSession) error {
 	return nil
 }
 
+// ConsumeClaim only marks a message once it has either been handled or
+// parked on the dead-letter topic. If neither happened the session ends and
+// the message is redelivered after the rebalance.
 func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	for message := range claim.Messages() {
-		c.handleMessage(message)
+		if err := c.processWithRetry(session.Context(), message); err != nil {
+			log.Printf("Giving up on %s/%d@%d without committing: %v", message.Topic, message.Partition, message.Offset, err)
+			return err
+		}
 		session.MarkMessage(message, "")
 	}
 	return nil
 }
 
-func (c *Consumer) handleMessage(message *sarama.ConsumerMessage) {
-	var event map[string]any
-	if err := json.Unmarshal(message.Value, &event); err != nil {
-		log.Printf("Error unmarshaling message: %v", err)
-		return
+func (c *Consumer) processWithRetry(ctx context.Context, message *sarama.ConsumerMessage) error {
+	var err error
+	attempts := 0
+	for attempts < c.retry.MaxAttempts {
+		attempts++
+		if err = c.handleMessage(ctx, message); err == nil {
+			return nil
+		}
+		if errors.Is(err, ErrInvalidCommand) || attempts == c.retry.MaxAttempts {
+			break
+		}
+
+		log.Printf("Attempt %d for %s/%d@%d failed: %v", attempts, message.Topic, message.Partition, message.Offset, err)
+		select {
+		case <-time.After(c.retry.delay(attempts)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 
-	action, ok := event["action"].(string)
-	if !ok {
-		return
+	if !errors.Is(err, ErrInvalidCommand) {
+		c.replyFailure(message, err)
+	}
+	return c.deadLetter(ctx, message, err, attempts)
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, message *sarama.ConsumerMessage, cause error, attempts int) error {
+	dl := &models.DeadLetter{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+		Key:       string(message.Key),
+		Value:     string(message.Value),
+		Headers:   make(map[string]string, len(message.Headers)),
+		Error:     cause.Error(),
+		Attempts:  attempts,
+		FailedAt:  time.Now(),
+	}
+	for _, h := range message.Headers {
+		dl.Headers[string(h.Key)] = string(h.Value)
+	}
+
+	data, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{
+		"x-error":          dl.Error,
+		"x-attempts":       strconv.Itoa(attempts),
+		"x-original-topic": dl.Topic,
+	}
+	if err := c.publisher.PublishMessage(DeadLetterTopic, dl.Key, data, headers); err != nil {
+		return fmt.Errorf("failed to dead-letter message: %w", err)
+	}
+
+	if err := c.deadLetters.Record(ctx, dl); err != nil {
+		log.Printf("Error recording dead letter for %s/%d@%d: %v", dl.Topic, dl.Partition, dl.Offset, err)
+	}
+	return nil
+}
+
+// handleMessage processes one command. Undecodable messages return
+// ErrInvalidCommand and retryable failures are returned without replying;
+// the caller replies once retries are exhausted. A command whose key is
+// held by another consumer is retried like any other failure.
+func (c *Consumer) handleMessage(parent context.Context, message *sarama.ConsumerMessage) error {
+	var cmd models.ReminderCommand
+	if err := json.Unmarshal(message.Value, &cmd); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCommand, err)
+	}
+	if _, ok := replyTopic(cmd.ReplyTo); !ok {
+		return fmt.Errorf("%w: reply_to %q is not a reply topic", ErrInvalidCommand, cmd.ReplyTo)
+	}
+
+	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
+	defer cancel()
+
+	key := cmd.IdempotencyKey
+	if key == "" {
+		key = cmd.CommandID
+	}
+
+	reply := &models.CommandReply{
+		CommandID:      cmd.CommandID,
+		IdempotencyKey: key,
+		Action:         cmd.Action,
+		Status:         "ok",
+		ReminderID:     cmd.ReminderID,
 	}
 
-	ctx := context.Background()
+	if key != "" {
+		prior, err := c.commands.Reserve(ctx, reply)
+		if err != nil {
+			return fmt.Errorf("failed to reserve command %s: %w", key, err)
+		}
+		if prior != nil {
+			c.reply(&cmd, prior)
+			return nil
+		}
+	}
+
+	reminder, err := c.execute(ctx, &cmd)
+	if permanent(err) {
+		err = fmt.Errorf("%w: %v", ErrInvalidCommand, err)
+	}
+	if err != nil && !errors.Is(err, ErrInvalidCommand) {
+		if key != "" {
+			c.release(parent, key)
+		}
+		return err
+	}
+	if reminder != nil {
+		reply.ReminderID = reminder.ID.Hex()
+		reply.Reminder = reminder
+	}
+	if err != nil {
+		reply.Status = "error"
+		reply.Error = err.Error()
+		log.Printf("Error handling %s command %s: %v", cmd.Action, cmd.CommandID, err)
+	}
+	reply.ProcessedAt = time.Now()
+
+	if key != "" {
+		if err := c.commands.Finish(ctx, reply); err != nil {
+			log.Printf("Error recording command %s: %v", key, err)
+		}
+	}
+
+	// Rejected commands have been answered, so they are not dead-lettered.
+	c.reply(&cmd, reply)
+	return nil
+}
+
+// permanent reports whether a command failed in a way retrying cannot fix:
+// a bad rule, a forbidden status change, a reminder blocked by open
+// prerequisites or one that does not exist.
+func permanent(err error) bool {
+	return errors.Is(err, recurrence.ErrInvalidRule) ||
+		errors.Is(err, models.ErrInvalidTransition) ||
+		errors.Is(err, service.ErrReminderBlocked) ||
+		errors.Is(err, service.ErrResourceNotFound) ||
+		errors.Is(err, mongo.ErrNoDocuments) ||
+		errors.Is(err, primitive.ErrInvalidHex)
+}
+
+// release drops the reservation of a command that failed, so the next
+// attempt or a replay from the DLQ can run it. It gets its own deadline as
+// the command's may be what ran out.
+func (c *Consumer) release(parent context.Context, key string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), 5*time.Second)
+	defer cancel()
+	if err := c.commands.Release(ctx, key); err != nil {
+		log.Printf("Error releasing command %s: %v", key, err)
+	}
+}
+
+func (c *Consumer) execute(ctx context.Context, cmd *models.ReminderCommand) (*models.Reminder, error) {
+	if cmd.Action != "create" && cmd.ReminderID == "" {
+		return nil, fmt.Errorf("%w: reminder_id is required for %q", ErrInvalidCommand, cmd.Action)
+	}
 
-	switch action {
+	switch cmd.Action {
 	case "create":
-		c.handleCreate(ctx, event)
-	case "cancel":
-		c.handleCancel(ctx, event)
+		return c.handleCreate(ctx, cmd)
+	case "update":
+		return c.handleUpdate(ctx, cmd)
 	case "snooze":
-		c.handleSnooze(ctx, event)
+		return c.handleSnooze(ctx, cmd)
+	case "complete":
+		return nil, c.service.Complete(ctx, cmd.ReminderID)
+	case "cancel":
+		return nil, c.service.Cancel(ctx, cmd.ReminderID)
+	case "delete":
+		return nil, c.service.Delete(ctx, cmd.ReminderID)
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidCommand, cmd.Action)
 	}
 }
 
-func (c *Consumer) handleCreate(ctx context.Context, event map[string]any) {
-	// Parse and create reminder from event
-	log.Printf("Received create reminder command: %v", event)
+func (c *Consumer) handleCreate(ctx context.Context, cmd *models.ReminderCommand) (*models.Reminder, error) {
+	var req models.CreateReminderRequest
+	if err := decodePayload(cmd.Payload, &req); err != nil {
+		return nil, err
+	}
+	return c.service.Create(ctx, &req)
 }
 
-func (c *Consumer) handleCancel(ctx context.Context, event map[string]any) {
-	reminderID, ok := event["reminder_id"].(string)
-	if !ok {
+func (c *Consumer) handleUpdate(ctx context.Context, cmd *models.ReminderCommand) (*models.Reminder, error) {
+	var req models.UpdateReminderRequest
+	if err := decodePayload(cmd.Payload, &req); err != nil {
+		return nil, err
+	}
+	return c.service.Update(ctx, cmd.ReminderID, &req)
+}
+
+func (c *Consumer) handleSnooze(ctx context.Context, cmd *models.ReminderCommand) (*models.Reminder, error) {
+	duration, err := time.ParseDuration(cmd.Duration)
+	if err != nil || duration <= 0 {
+		return nil, fmt.Errorf("%w: invalid duration %q", ErrInvalidCommand, cmd.Duration)
+	}
+	// Commands carry no user, so the snooze is recorded as the owner's.
+	return c.service.Snooze(ctx, cmd.ReminderID, duration, "", models.SnoozeSourceKafka)
+}
+
+// decodePayload unmarshals a command payload and applies the same binding
+// rules the HTTP API uses for the request type.
+func decodePayload(payload json.RawMessage, dst any) error {
+	if len(payload) == 0 {
+		return fmt.Errorf("%w: payload is required", ErrInvalidCommand)
+	}
+	if err := json.Unmarshal(payload, dst); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCommand, err)
+	}
+	if err := binding.Validator.ValidateStruct(dst); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCommand, err)
+	}
+	return nil
+}
+
+// replyFailure answers a command whose retries ran out. Failures are not
+// recorded in the command log so a replay from the DLQ can still succeed.
+func (c *Consumer) replyFailure(message *sarama.ConsumerMessage, cause error) {
+	var cmd models.ReminderCommand
+	if err := json.Unmarshal(message.Value, &cmd); err != nil {
 		return
 	}
 
-	if err := c.canceller.Cancel(ctx, reminderID); err != nil {
-		log.Printf("Error canceling reminder: %v", err)
+	key := cmd.IdempotencyKey
+	if key == "" {
+		key = cmd.CommandID
 	}
+	c.reply(&cmd, &models.CommandReply{
+		CommandID:      cmd.CommandID,
+		IdempotencyKey: key,
+		Action:         cmd.Action,
+		Status:         "error",
+		ReminderID:     cmd.ReminderID,
+		Error:          cause.Error(),
+		ProcessedAt:    time.Now(),
+	})
 }
 
-func (c *Consumer) handleSnooze(ctx context.Context, event map[string]any) {
-	reminderID, ok := event["reminder_id"].(string)
+func (c *Consumer) reply(cmd *models.ReminderCommand, reply *models.CommandReply) {
+	topic, ok := replyTopic(cmd.ReplyTo)
 	if !ok {
+		log.Printf("Not replying to command %s on %q: not a reply topic", cmd.CommandID, cmd.ReplyTo)
 		return
 	}
-
-	var req models.SnoozeRequest
-	if duration, ok := event["duration"].(string); ok {
-		req.Duration = duration
+	if err := c.publisher.Publish(topic, reply); err != nil {
+		log.Printf("Error publishing reply for command %s: %v", cmd.CommandID, err)
 	}
+}
 
-	log.Printf("Snooze reminder %s for %s", reminderID, req.Duration)
+// replyTopic returns the topic to answer a command on, and false if
+// replyTo names a topic outside DefaultReplyTopic, so a command cannot make
+// the service produce to arbitrary topics.
+func replyTopic(replyTo string) (string, bool) {
+	if replyTo == "" {
+		return DefaultReplyTopic, true
+	}
+	if replyTo == DefaultReplyTopic || (strings.HasPrefix(replyTo, DefaultReplyTopic+".") && len(replyTo) > len(DefaultReplyTopic)+1) {
+		return replyTo, true
+	}
+	return "", false
 }
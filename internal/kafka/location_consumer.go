@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"reminder-service/internal/audit"
+	"reminder-service/internal/models"
+
+	"github.com/IBM/sarama"
+)
+
+// LocationsTopic carries user positions from the mobile clients.
+const LocationsTopic = "locations.updates"
+
+// LocationTracker evaluates geofences against a user's position.
+type LocationTracker interface {
+	Track(ctx context.Context, u *models.LocationUpdate) ([]models.GeofenceTransition, error)
+}
+
+// LocationConsumer feeds location updates to the geofence evaluation.
+// Invalid updates are logged and skipped; storage failures end the session
+// so the message is redelivered.
+type LocationConsumer struct {
+	consumer sarama.ConsumerGroup
+	tracker  LocationTracker
+}
+
+func NewLocationConsumer(brokers []string, groupID string, tracker LocationTracker) (*LocationConsumer, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Group.Rebalance.Strategy = sarama.NewBalanceStrategyRoundRobin()
+
+	consumer, err := sarama.NewConsumerGroup(brokers, groupID, config)
+	if err != nil {
+		return nil, err
+	}
+	return &LocationConsumer{consumer: consumer, tracker: tracker}, nil
+}
+
+func (c *LocationConsumer) Start() {
+	ctx := audit.WithSource(context.Background(), audit.SourceKafka)
+
+	for {
+		if err := c.consumer.Consume(ctx, []string{LocationsTopic}, c); err != nil {
+			log.Printf("Error from location consumer: %v", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (c *LocationConsumer) Close() error {
+	return c.consumer.Close()
+}
+
+func (c *LocationConsumer) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (c *LocationConsumer) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (c *LocationConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for message := range claim.Messages() {
+		var update models.LocationUpdate
+		err := json.Unmarshal(message.Value, &update)
+		if err == nil {
+			err = update.Validate()
+		}
+		if err != nil {
+			log.Printf("Skipping location update %s/%d@%d: %v", message.Topic, message.Partition, message.Offset, err)
+			session.MarkMessage(message, "")
+			continue
+		}
+		if update.RecordedAt.IsZero() {
+			update.RecordedAt = message.Timestamp
+		}
+
+		ctx, cancel := context.WithTimeout(session.Context(), 10*time.Second)
+		_, err = c.tracker.Track(ctx, &update)
+		cancel()
+		if err != nil {
+			log.Printf("Error tracking location update %s/%d@%d: %v", message.Topic, message.Partition, message.Offset, err)
+			return err
+		}
+		session.MarkMessage(message, "")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"reminder-service/internal/audit"
+	"reminder-service/internal/models"
+
+	"github.com/IBM/sarama"
+)
+
+// MembersTopic carries workspace membership changes from the membership
+// service.
+const MembersTopic = "workspace.members"
+
+// MembershipStore applies membership events. It must be idempotent, since
+// events are redelivered after a failed session.
+type MembershipStore interface {
+	ApplyMemberEvent(ctx context.Context, ev *models.WorkspaceMemberEvent) error
+}
+
+// MemberConsumer keeps the local copy of workspace roles in sync. Events
+// that cannot be applied are logged and skipped; storage failures end the
+// session so the message is redelivered.
+type MemberConsumer struct {
+	consumer sarama.ConsumerGroup
+	store    MembershipStore
+}
+
+func NewMemberConsumer(brokers []string, groupID string, store MembershipStore) (*MemberConsumer, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Group.Rebalance.Strategy = sarama.NewBalanceStrategyRoundRobin()
+	// Roles are state, not commands: a new group must replay the topic.
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	consumer, err := sarama.NewConsumerGroup(brokers, groupID, config)
+	if err != nil {
+		return nil, err
+	}
+	return &MemberConsumer{consumer: consumer, store: store}, nil
+}
+
+func (c *MemberConsumer) Start() {
+	ctx := audit.WithSource(context.Background(), audit.SourceKafka)
+
+	for {
+		if err := c.consumer.Consume(ctx, []string{MembersTopic}, c); err != nil {
+			log.Printf("Error from member consumer: %v", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (c *MemberConsumer) Close() error {
+	return c.consumer.Close()
+}
+
+func (c *MemberConsumer) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (c *MemberConsumer) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (c *MemberConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for message := range claim.Messages() {
+		var ev models.WorkspaceMemberEvent
+		err := json.Unmarshal(message.Value, &ev)
+		if err == nil {
+			err = ev.Validate()
+		}
+		if err != nil {
+			log.Printf("Skipping member event %s/%d@%d: %v", message.Topic, message.Partition, message.Offset, err)
+			session.MarkMessage(message, "")
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(session.Context(), 10*time.Second)
+		err = c.store.ApplyMemberEvent(ctx, &ev)
+		cancel()
+		if err != nil {
+			log.Printf("Error applying member event %s/%d@%d: %v", message.Topic, message.Partition, message.Offset, err)
+			return err
+		}
+		session.MarkMessage(message, "")
+	}
+	return nil
+}
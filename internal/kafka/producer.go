@@ -45,6 +45,29 @@ func (p *Producer) Publish(topic string, message any) error {
 	return nil
 }
 
+// PublishMessage sends an already-encoded message. The key pins related
+// messages to one partition so they stay ordered.
+func (p *Producer) PublishMessage(topic, key string, value []byte, headers map[string]string) error {
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(value),
+	}
+	if key != "" {
+		msg.Key = sarama.StringEncoder(key)
+	}
+	for k, v := range headers {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+
+	_, _, err := p.producer.SendMessage(msg)
+	if err != nil {
+		log.Printf("Failed to publish to topic %s: %v", topic, err)
+		return err
+	}
+
+	return nil
+}
+
 func (p *Producer) Close() error {
 	return p.producer.Close()
 }
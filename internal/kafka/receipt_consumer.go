@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"reminder-service/internal/audit"
+	"reminder-service/internal/models"
+
+	"github.com/IBM/sarama"
+)
+
+// Receipts the notification service publishes for notifications.send.
+const (
+	DeliveredTopic = "notifications.delivered"
+	ReadTopic      = "notifications.read"
+)
+
+// ReceiptRecorder applies delivery receipts to reminders.
+type ReceiptRecorder interface {
+	RecordReceipt(ctx context.Context, status string, receipt *models.DeliveryReceipt) error
+}
+
+// ReceiptConsumer feeds delivered and read receipts to the reminders.
+// Invalid receipts are logged and skipped; storage failures end the
+// session so the message is redelivered.
+type ReceiptConsumer struct {
+	consumer sarama.ConsumerGroup
+	recorder ReceiptRecorder
+}
+
+func NewReceiptConsumer(brokers []string, groupID string, recorder ReceiptRecorder) (*ReceiptConsumer, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Group.Rebalance.Strategy = sarama.NewBalanceStrategyRoundRobin()
+
+	consumer, err := sarama.NewConsumerGroup(brokers, groupID, config)
+	if err != nil {
+		return nil, err
+	}
+	return &ReceiptConsumer{consumer: consumer, recorder: recorder}, nil
+}
+
+func (c *ReceiptConsumer) Start() {
+	ctx := audit.WithSource(context.Background(), audit.SourceKafka)
+
+	for {
+		if err := c.consumer.Consume(ctx, []string{DeliveredTopic, ReadTopic}, c); err != nil {
+			log.Printf("Error from receipt consumer: %v", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (c *ReceiptConsumer) Close() error {
+	return c.consumer.Close()
+}
+
+func (c *ReceiptConsumer) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (c *ReceiptConsumer) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (c *ReceiptConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for message := range claim.Messages() {
+		status := models.DeliveryDelivered
+		if message.Topic == ReadTopic {
+			status = models.DeliveryRead
+		}
+
+		var receipt models.DeliveryReceipt
+		err := json.Unmarshal(message.Value, &receipt)
+		if err == nil {
+			err = receipt.Validate()
+		}
+		if err != nil {
+			log.Printf("Skipping receipt %s/%d@%d: %v", message.Topic, message.Partition, message.Offset, err)
+			session.MarkMessage(message, "")
+			continue
+		}
+		if receipt.At.IsZero() {
+			receipt.At = message.Timestamp
+		}
+
+		ctx, cancel := context.WithTimeout(session.Context(), 10*time.Second)
+		err = c.recorder.RecordReceipt(ctx, status, &receipt)
+		cancel()
+		if err != nil {
+			log.Printf("Error recording receipt %s/%d@%d: %v", message.Topic, message.Partition, message.Offset, err)
+			return err
+		}
+		session.MarkMessage(message, "")
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package models
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -22,8 +26,37 @@ const (
 	StatusCompleted ReminderStatus = "completed"
 	StatusCancelled ReminderStatus = "cancelled"
 	StatusSnoozed   ReminderStatus = "snoozed"
+
+	// StatusProcessing marks a reminder that a scheduler replica has leased
+	// and is in the middle of triggering.
+	StatusProcessing ReminderStatus = "processing"
+	// StatusAcknowledged follows triggered once the user has seen the
+	// notification.
+	StatusAcknowledged ReminderStatus = "acknowledged"
+	// StatusArchived retires a completed or cancelled reminder for good.
+	StatusArchived ReminderStatus = "archived"
 )
 
+// ErrInvalidTransition is returned when a reminder may not move from its
+// status to the one requested.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// TransitionError is an ErrInvalidTransition with the reason.
+type TransitionError struct {
+	From   ReminderStatus
+	To     ReminderStatus
+	Reason string
+}
+
+func (e *TransitionError) Error() string {
+	if e.From == "" {
+		return fmt.Sprintf("cannot move reminder to %s: %s", e.To, e.Reason)
+	}
+	return fmt.Sprintf("cannot move reminder from %s to %s: %s", e.From, e.To, e.Reason)
+}
+
+func (e *TransitionError) Unwrap() error { return ErrInvalidTransition }
+
 type ReminderPriority string
 
 const (
@@ -39,24 +72,63 @@ type Reminder struct {
 	WorkspaceID string             `bson:"workspace_id" json:"workspace_id"`
 	ChannelID   string             `bson:"channel_id,omitempty" json:"channel_id,omitempty"`
 	MessageID   string             `bson:"message_id,omitempty" json:"message_id,omitempty"`
+	ExternalUID string             `bson:"external_uid,omitempty" json:"external_uid,omitempty"`
 	Type        ReminderType       `bson:"type" json:"type"`
 	Title       string             `bson:"title" json:"title"`
 	Description string             `bson:"description,omitempty" json:"description,omitempty"`
 	RemindAt    time.Time          `bson:"remind_at" json:"remind_at"`
 	Status      ReminderStatus     `bson:"status" json:"status"`
 	Priority    ReminderPriority   `bson:"priority,omitempty" json:"priority,omitempty"`
+	CategoryID  string             `bson:"category_id,omitempty" json:"category_id,omitempty"`
 	Recurrence  *Recurrence        `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
 	Metadata    map[string]any     `bson:"metadata,omitempty" json:"metadata,omitempty"`
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
 	TriggeredAt *time.Time         `bson:"triggered_at,omitempty" json:"triggered_at,omitempty"`
-}
 
+	// NotifyAt is when the notification goes out: RemindAt minus the user's
+	// advance lead, or the end of their quiet hours. Nil means RemindAt.
+	NotifyAt       *time.Time `bson:"notify_at,omitempty" json:"notify_at,omitempty"`
+	AcknowledgedAt *time.Time `bson:"acknowledged_at,omitempty" json:"acknowledged_at,omitempty"`
+
+	// RenotifyAt is when a triggered reminder nobody acknowledged is sent
+	// again. RenotifyCount counts the repeats since it last triggered.
+	RenotifyAt    *time.Time `bson:"renotify_at,omitempty" json:"renotify_at,omitempty"`
+	RenotifyCount int        `bson:"renotify_count,omitempty" json:"renotify_count,omitempty"`
+
+	// BlockedBy lists the open prerequisites the reminder waits on before
+	// it may trigger.
+	BlockedBy []string `bson:"blocked_by,omitempty" json:"blocked_by,omitempty"`
+	// Geofenced reminders trigger when the user crosses their location
+	// rather than at RemindAt.
+	Geofenced bool `bson:"geofenced,omitempty" json:"geofenced,omitempty"`
+
+	// SearchNotes and SearchComments copy the text of the reminder's notes
+	// and comments so the text index covers them.
+	SearchNotes    []string `bson:"search_notes,omitempty" json:"-"`
+	SearchComments []string `bson:"search_comments,omitempty" json:"-"`
+
+	LeaseOwner     string     `bson:"lease_owner,omitempty" json:"-"`
+	LeaseExpiresAt *time.Time `bson:"lease_expires_at,omitempty" json:"-"`
+	// ClaimedFrom is the status a reminder being triggered had before the
+	// scheduler claimed it, restored if the trigger is deferred.
+	ClaimedFrom ReminderStatus `bson:"claimed_from,omitempty" json:"-"`
+}
+
+// Recurrence describes a repeating reminder. RRule takes precedence over
+// the legacy Pattern/Interval/DaysOfWeek fields. Occurrences are computed in
+// Timezone (UTC when empty).
 type Recurrence struct {
-	Pattern    string     `bson:"pattern" json:"pattern"`
-	Interval   int        `bson:"interval" json:"interval"`
-	EndDate    *time.Time `bson:"end_date,omitempty" json:"end_date,omitempty"`
-	DaysOfWeek []int      `bson:"days_of_week,omitempty" json:"days_of_week,omitempty"`
+	Pattern    string      `bson:"pattern" json:"pattern"`
+	Interval   int         `bson:"interval" json:"interval"`
+	EndDate    *time.Time  `bson:"end_date,omitempty" json:"end_date,omitempty"`
+	DaysOfWeek []int       `bson:"days_of_week,omitempty" json:"days_of_week,omitempty"`
+	RRule      string      `bson:"rrule,omitempty" json:"rrule,omitempty"`
+	Timezone   string      `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	Count      int         `bson:"count,omitempty" json:"count,omitempty"`
+	ExDates    []time.Time `bson:"exdates,omitempty" json:"exdates,omitempty"`
+	Start      *time.Time  `bson:"start,omitempty" json:"start,omitempty"`
+	Occurrence int         `bson:"occurrence,omitempty" json:"occurrence,omitempty"`
 }
 
 type CreateReminderRequest struct {
@@ -68,26 +140,39 @@ type CreateReminderRequest struct {
 	Title       string         `json:"title" binding:"required"`
 	Description string         `json:"description,omitempty"`
 	RemindAt    time.Time      `json:"remind_at" binding:"required"`
+	CategoryID  string         `json:"category_id,omitempty"`
 	Recurrence  *Recurrence    `json:"recurrence,omitempty"`
 	Metadata    map[string]any `json:"metadata,omitempty"`
 }
 
 type UpdateReminderRequest struct {
-	Title       string         `json:"title,omitempty"`
-	Description string         `json:"description,omitempty"`
-	RemindAt    *time.Time     `json:"remind_at,omitempty"`
-	Status      ReminderStatus `json:"status,omitempty"`
-	Recurrence  *Recurrence    `json:"recurrence,omitempty"`
-	Metadata    map[string]any `json:"metadata,omitempty"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	RemindAt    *time.Time       `json:"remind_at,omitempty"`
+	Status      ReminderStatus   `json:"status,omitempty"`
+	Priority    ReminderPriority `json:"priority,omitempty"`
+	CategoryID  string           `json:"category_id,omitempty"`
+	Recurrence  *Recurrence      `json:"recurrence,omitempty"`
+	Metadata    map[string]any   `json:"metadata,omitempty"`
+
+	// NotifyAt is derived by the service whenever RemindAt changes, unless
+	// an internal caller such as the calendar import sets it.
+	NotifyAt *time.Time `json:"-"`
 }
 
 type SnoozeRequest struct {
 	Duration string `json:"duration" binding:"required"`
 }
 
+// PaginationParams selects a page either by number or, for stable paging
+// while reminders change, by the Cursor returned with the previous page.
+// Numbered pages are counted unless IncludeTotal is false; cursor pages only
+// when it is true.
 type PaginationParams struct {
-	Page    int `form:"page" json:"page"`
-	PerPage int `form:"per_page" json:"per_page"`
+	Page         int    `form:"page" json:"page"`
+	PerPage      int    `form:"per_page" json:"per_page"`
+	Cursor       string `form:"cursor" json:"cursor,omitempty"`
+	IncludeTotal *bool  `form:"include_total" json:"include_total,omitempty"`
 }
 
 func (p *PaginationParams) Validate() {
@@ -107,12 +192,78 @@ func (p *PaginationParams) Limit() int64 {
 	return int64(p.PerPage)
 }
 
+func (p *PaginationParams) WantsTotal() bool {
+	if p.IncludeTotal != nil {
+		return *p.IncludeTotal
+	}
+	return p.Cursor == ""
+}
+
+// Response wraps one page of data. Page is left out for cursor requests.
+func (p *PaginationParams) Response(data interface{}, info *PageInfo) *PaginatedResponse {
+	resp := &PaginatedResponse{
+		Data:       data,
+		Total:      info.Total,
+		PerPage:    p.PerPage,
+		NextCursor: info.NextCursor,
+	}
+	if p.Cursor == "" {
+		resp.Page = p.Page
+	}
+	if info.Total != nil && *info.Total > 0 {
+		resp.TotalPages = int((*info.Total + p.Limit() - 1) / p.Limit())
+	}
+	return resp
+}
+
+// PageInfo is what a listing reports besides its data. Total is nil when
+// it was not counted; NextCursor is empty on the last page.
+type PageInfo struct {
+	Total      *int64
+	NextCursor string
+}
+
 type PaginatedResponse struct {
 	Data       interface{} `json:"data"`
-	Total      int64       `json:"total"`
-	Page       int         `json:"page"`
+	Total      *int64      `json:"total,omitempty"`
+	Page       int         `json:"page,omitempty"`
 	PerPage    int         `json:"per_page"`
 	TotalPages int         `json:"total_pages"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// ErrInvalidCursor is returned for a cursor that was not issued by the API.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageCursor is a position in a listing ordered by remind_at then _id.
+// Listings without such a key, like search by relevance, page by Offset.
+// Clients see it only as an opaque token.
+type PageCursor struct {
+	RemindAt time.Time          `json:"t"`
+	ID       primitive.ObjectID `json:"i"`
+	Offset   int64              `json:"o,omitempty"`
+}
+
+// IsOffset reports whether c is an Offset cursor rather than a position.
+func (c *PageCursor) IsOffset() bool {
+	return c.ID.IsZero()
+}
+
+func (c PageCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(token string) (*PageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c PageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Offset < 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
 }
 
 type BulkCreateRequest struct {
@@ -218,30 +369,58 @@ type CreateFromTemplateRequest struct {
 }
 
 type NotificationPreference struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID      string             `bson:"user_id" json:"user_id"`
-	WorkspaceID string             `bson:"workspace_id" json:"workspace_id"`
-	Channels    []string           `bson:"channels" json:"channels"`
-	QuietStart  string             `bson:"quiet_start,omitempty" json:"quiet_start,omitempty"`
-	QuietEnd    string             `bson:"quiet_end,omitempty" json:"quiet_end,omitempty"`
-	Timezone    string             `bson:"timezone,omitempty" json:"timezone,omitempty"`
-	AdvanceLead int                `bson:"advance_lead" json:"advance_lead"`
-	Enabled     bool               `bson:"enabled" json:"enabled"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       string             `bson:"user_id" json:"user_id"`
+	WorkspaceID  string             `bson:"workspace_id" json:"workspace_id"`
+	Channels     []string           `bson:"channels" json:"channels"`
+	QuietStart   string             `bson:"quiet_start,omitempty" json:"quiet_start,omitempty"`
+	QuietEnd     string             `bson:"quiet_end,omitempty" json:"quiet_end,omitempty"`
+	Timezone     string             `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	AdvanceLead  int                `bson:"advance_lead" json:"advance_lead"`
+	Enabled      bool               `bson:"enabled" json:"enabled"`
+	UrgentBypass bool               `bson:"urgent_bypass" json:"urgent_bypass"`
+
+	// RenotifyMins re-sends a triggered reminder that is still
+	// unacknowledged every RenotifyMins minutes, at most RenotifyMax times
+	// (3 when unset). Zero turns re-notification off.
+	RenotifyMins int       `bson:"renotify_mins,omitempty" json:"renotify_mins,omitempty"`
+	RenotifyMax  int       `bson:"renotify_max,omitempty" json:"renotify_max,omitempty"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// NewNotificationPreference returns the preferences of a user who has not
+// set any: in-app notifications, no quiet hours and urgent reminders
+// allowed through.
+func NewNotificationPreference(userID, workspaceID string) *NotificationPreference {
+	return &NotificationPreference{
+		UserID:       userID,
+		WorkspaceID:  workspaceID,
+		Channels:     []string{"in_app"},
+		Enabled:      true,
+		UrgentBypass: true,
+	}
 }
 
 type UpdateNotificationPrefRequest struct {
-	Channels    []string `json:"channels,omitempty"`
-	QuietStart  string   `json:"quiet_start,omitempty"`
-	QuietEnd    string   `json:"quiet_end,omitempty"`
-	Timezone    string   `json:"timezone,omitempty"`
-	AdvanceLead *int     `json:"advance_lead,omitempty"`
-	Enabled     *bool    `json:"enabled,omitempty"`
+	Channels     []string `json:"channels,omitempty"`
+	QuietStart   string   `json:"quiet_start,omitempty"`
+	QuietEnd     string   `json:"quiet_end,omitempty"`
+	Timezone     string   `json:"timezone,omitempty"`
+	AdvanceLead  *int     `json:"advance_lead,omitempty"`
+	Enabled      *bool    `json:"enabled,omitempty"`
+	UrgentBypass *bool    `json:"urgent_bypass,omitempty"`
+	RenotifyMins *int     `json:"renotify_mins,omitempty"`
+	RenotifyMax  *int     `json:"renotify_max,omitempty"`
 }
 
 // -- Sharing Models --
 
+const (
+	SharePermissionView = "view"
+	SharePermissionEdit = "edit"
+)
+
 type ReminderShare struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ReminderID string             `bson:"reminder_id" json:"reminder_id"`
@@ -253,7 +432,7 @@ type ReminderShare struct {
 
 type ShareReminderRequest struct {
 	SharedWith string `json:"shared_with" binding:"required"`
-	Permission string `json:"permission" binding:"required"`
+	Permission string `json:"permission" binding:"required,oneof=view edit"`
 }
 
 // -- Note Models --
@@ -277,21 +456,138 @@ type UpdateNoteRequest struct {
 
 // -- Activity Models --
 
+// ReminderActivity is an entry in the audit log. UserID is the actor, or
+// empty for changes the service makes on its own; Source says whether the
+// change came over HTTP, from Kafka or from the scheduler. Entity and
+// EntityID name the record written, and ReminderID the reminder it belongs
+// to, if any.
 type ReminderActivity struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ReminderID string             `bson:"reminder_id" json:"reminder_id"`
-	UserID     string             `bson:"user_id" json:"user_id"`
-	Action     string             `bson:"action" json:"action"`
-	Details    map[string]any     `bson:"details,omitempty" json:"details,omitempty"`
-	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ReminderID string                 `bson:"reminder_id" json:"reminder_id"`
+	UserID     string                 `bson:"user_id" json:"user_id"`
+	Action     string                 `bson:"action" json:"action"`
+	Entity     string                 `bson:"entity,omitempty" json:"entity,omitempty"`
+	EntityID   string                 `bson:"entity_id,omitempty" json:"entity_id,omitempty"`
+	Source     string                 `bson:"source,omitempty" json:"source,omitempty"`
+	Changes    map[string]FieldChange `bson:"changes,omitempty" json:"changes,omitempty"`
+	Details    map[string]any         `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt  time.Time              `bson:"created_at" json:"created_at"`
+	ExpiresAt  *time.Time             `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// FieldChange is a field's value before and after a write. From is nil
+// for created records and To for deleted ones.
+type FieldChange struct {
+	From any `bson:"from" json:"from"`
+	To   any `bson:"to" json:"to"`
+}
+
+// ActivityExportRequest selects the audit log entries to export. DateFrom
+// and DateTo are inclusive days in UTC.
+type ActivityExportRequest struct {
+	Format     string `form:"format" json:"format"`
+	UserID     string `form:"user_id" json:"user_id"`
+	ReminderID string `form:"reminder_id" json:"reminder_id"`
+	Entity     string `form:"entity" json:"entity"`
+	Source     string `form:"source" json:"source"`
+	DateFrom   string `form:"date_from" json:"date_from"`
+	DateTo     string `form:"date_to" json:"date_to"`
+}
+
+// -- Command Models --
+
+// ReminderCommand is the envelope other services publish to
+// reminders.commands. Payload carries a CreateReminderRequest for "create"
+// and an UpdateReminderRequest for "update".
+type ReminderCommand struct {
+	CommandID      string          `json:"command_id"`
+	Action         string          `json:"action"`
+	IdempotencyKey string          `json:"idempotency_key,omitempty"`
+	ReminderID     string          `json:"reminder_id,omitempty"`
+	Duration       string          `json:"duration,omitempty"`
+	ReplyTo        string          `json:"reply_to,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+}
+
+// CommandInProgress is the status of a command log entry reserved by a
+// consumer that has not finished the command yet.
+const CommandInProgress = "in_progress"
+
+type CommandReply struct {
+	CommandID      string    `bson:"command_id" json:"command_id"`
+	IdempotencyKey string    `bson:"idempotency_key,omitempty" json:"idempotency_key,omitempty"`
+	Action         string    `bson:"action" json:"action"`
+	Status         string    `bson:"status" json:"status"`
+	ReminderID     string    `bson:"reminder_id,omitempty" json:"reminder_id,omitempty"`
+	Reminder       *Reminder `bson:"reminder,omitempty" json:"reminder,omitempty"`
+	Error          string    `bson:"error,omitempty" json:"error,omitempty"`
+	ReservedAt     time.Time `bson:"reserved_at,omitempty" json:"-"`
+	ProcessedAt    time.Time `bson:"processed_at" json:"processed_at"`
+}
+
+// -- Dead Letter Models --
+
+// DeadLetter is a command that kept failing after every retry. Value and
+// Headers are the original message, so a replay is byte-for-byte identical.
+type DeadLetter struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Topic       string             `bson:"topic" json:"topic"`
+	Partition   int32              `bson:"partition" json:"partition"`
+	Offset      int64              `bson:"offset" json:"offset"`
+	Key         string             `bson:"key,omitempty" json:"key,omitempty"`
+	Value       string             `bson:"value" json:"value"`
+	Headers     map[string]string  `bson:"headers,omitempty" json:"headers,omitempty"`
+	Error       string             `bson:"error" json:"error"`
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	FailedAt    time.Time          `bson:"failed_at" json:"failed_at"`
+	ReplayCount int                `bson:"replay_count" json:"replay_count"`
+	ReplayedAt  *time.Time         `bson:"replayed_at,omitempty" json:"replayed_at,omitempty"`
+}
+
+// -- Outbox Models --
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxPublished OutboxStatus = "published"
+	// OutboxFailed events ran out of attempts and are kept for inspection.
+	OutboxFailed OutboxStatus = "failed"
+)
+
+// OutboxEvent is a Kafka message recorded in the same transaction as the
+// state change that produced it. Key orders delivery (one partition per
+// reminder); DedupeKey lets consumers drop redeliveries.
+type OutboxEvent struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Topic         string             `bson:"topic" json:"topic"`
+	Key           string             `bson:"key" json:"key"`
+	DedupeKey     string             `bson:"dedupe_key" json:"dedupe_key"`
+	Payload       string             `bson:"payload" json:"payload"`
+	Status        OutboxStatus       `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	PublishedAt   *time.Time         `bson:"published_at,omitempty" json:"published_at,omitempty"`
 }
 
 // -- Export/Import Models --
 
+const (
+	ExportJSON   = "json"
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+)
+
+// ExportRequest selects the reminders to export. Columns picks and orders
+// the fields of CSV and NDJSON rows; Include adds related records (tags,
+// notes, subtasks, comments) as extra columns.
 type ExportRequest struct {
-	UserID string `json:"user_id"`
-	Format string `json:"format"`
-	Status string `json:"status,omitempty"`
+	ReminderFilter `bson:",inline"`
+	Format         string   `form:"format" json:"format"`
+	Columns        []string `form:"columns" json:"columns,omitempty"`
+	Include        []string `form:"include" json:"include,omitempty"`
 }
 
 type ExportResponse struct {
@@ -306,9 +602,32 @@ type ImportRequest struct {
 }
 
 type ImportResponse struct {
-	Imported int      `json:"imported"`
-	Failed   int      `json:"failed"`
-	Errors   []string `json:"errors,omitempty"`
+	Imported int                `json:"imported"`
+	Updated  int                `json:"updated,omitempty"`
+	Skipped  int                `json:"skipped,omitempty"`
+	Failed   int                `json:"failed"`
+	Errors   []string           `json:"errors,omitempty"`
+	Items    []ImportItemResult `json:"items,omitempty"`
+}
+
+type ImportItemStatus string
+
+const (
+	ImportCreated ImportItemStatus = "created"
+	ImportUpdated ImportItemStatus = "updated"
+	ImportSkipped ImportItemStatus = "skipped"
+	ImportFailed  ImportItemStatus = "failed"
+)
+
+// ImportItemResult reports what happened to one calendar component of an
+// ICS import.
+type ImportItemResult struct {
+	UID        string           `json:"uid,omitempty"`
+	Component  string           `json:"component"`
+	Title      string           `json:"title,omitempty"`
+	Status     ImportItemStatus `json:"status"`
+	ReminderID string           `json:"reminder_id,omitempty"`
+	Message    string           `json:"message,omitempty"`
 }
 
 // -- Analytics Models --
@@ -330,27 +649,68 @@ type UserActivity struct {
 
 // -- Search Models --
 
+// ReminderFilter selects reminders for search and export. Query is a text
+// search: words match any of them, "quoted phrases" must appear as written
+// and -word excludes. A reminder must carry every tag ID and label given.
+// Expression is written in the query language (see package query) and is
+// applied on top of the other fields; its dates are read in Timezone.
+type ReminderFilter struct {
+	UserID      string   `form:"user_id" json:"user_id" bson:"user_id,omitempty"`
+	WorkspaceID string   `form:"workspace_id" json:"workspace_id" bson:"workspace_id,omitempty"`
+	Query       string   `form:"q" json:"q" bson:"q,omitempty"`
+	Expression  string   `form:"filter" json:"filter" bson:"filter,omitempty"`
+	Timezone    string   `form:"timezone" json:"timezone" bson:"timezone,omitempty"`
+	Status      string   `form:"status" json:"status" bson:"status,omitempty"`
+	Type        string   `form:"type" json:"type" bson:"type,omitempty"`
+	Priority    string   `form:"priority" json:"priority" bson:"priority,omitempty"`
+	CategoryID  string   `form:"category_id" json:"category_id" bson:"category_id,omitempty"`
+	Tags        []string `form:"tag" json:"tags" bson:"tags,omitempty"`
+	Labels      []string `form:"label" json:"labels" bson:"labels,omitempty"`
+	DateFrom    string   `form:"date_from" json:"date_from" bson:"date_from,omitempty"`
+	DateTo      string   `form:"date_to" json:"date_to" bson:"date_to,omitempty"`
+
+	// Viewer is the caller, whom "me" refers to in Expression.
+	Viewer string `form:"-" json:"-" bson:"viewer,omitempty"`
+}
+
+const (
+	SearchSortRemindAt  = "remind_at"
+	SearchSortRelevance = "relevance"
+)
+
 type ReminderSearchParams struct {
-	UserID      string `form:"user_id" json:"user_id"`
-	WorkspaceID string `form:"workspace_id" json:"workspace_id"`
-	Query       string `form:"q" json:"q"`
-	Status      string `form:"status" json:"status"`
-	Type        string `form:"type" json:"type"`
-	DateFrom    string `form:"date_from" json:"date_from"`
-	DateTo      string `form:"date_to" json:"date_to"`
-	Page        int    `form:"page" json:"page"`
-	PerPage     int    `form:"per_page" json:"per_page"`
+	ReminderFilter
+	PaginationParams
+	Sort string `form:"sort" json:"sort"`
 }
 
 func (p *ReminderSearchParams) Validate() {
-	if p.Page < 1 {
-		p.Page = 1
-	}
-	if p.PerPage < 1 || p.PerPage > 100 {
-		p.PerPage = 20
+	p.PaginationParams.Validate()
+	// Relevance needs a query to rank by.
+	if p.Sort != SearchSortRemindAt && (p.Query != "" || p.Expression != "") {
+		p.Sort = SearchSortRelevance
+	} else {
+		p.Sort = SearchSortRemindAt
 	}
 }
 
+// SearchHit is a reminder in search results. Score is the text relevance
+// when the search has a query; Highlights holds one snippet per field that
+// matched it.
+type SearchHit struct {
+	*Reminder
+	Score      float64           `json:"score,omitempty"`
+	Highlights []SearchHighlight `json:"highlights,omitempty"`
+}
+
+// SearchHighlight is an excerpt of a matching field. Matches are [start, end)
+// rune offsets into Snippet.
+type SearchHighlight struct {
+	Field   string   `json:"field"`
+	Snippet string   `json:"snippet"`
+	Matches [][2]int `json:"matches"`
+}
+
 type UpcomingRemindersRequest struct {
 	Days  int `form:"days" json:"days"`
 	Limit int `form:"limit" json:"limit"`
@@ -404,6 +764,8 @@ type CalendarSync struct {
 	FeedToken    string             `bson:"feed_token,omitempty" json:"feed_token,omitempty"`
 	SyncEnabled  bool               `bson:"sync_enabled" json:"sync_enabled"`
 	LastSyncedAt *time.Time         `bson:"last_synced_at,omitempty" json:"last_synced_at,omitempty"`
+	RevokedAt    *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	ViewID       string             `bson:"view_id,omitempty" json:"view_id,omitempty"`
 	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
 }
@@ -420,6 +782,8 @@ type CalendarEvent struct {
 
 type CalendarFeedRequest struct {
 	Provider string `json:"provider" binding:"required"`
+	// ViewID limits the feed to a saved view.
+	ViewID string `json:"view_id,omitempty"`
 }
 
 type CalendarViewRequest struct {
@@ -429,30 +793,56 @@ type CalendarViewRequest struct {
 
 // -- Escalation Rule Models --
 
+const (
+	EscalationNotify       = "notify"
+	EscalationRenotify     = "renotify"
+	EscalationPostChannel  = "post_channel"
+	EscalationBumpPriority = "bump_priority"
+)
+
+const (
+	EscalationScheduled = "scheduled"
+	EscalationExecuted  = "executed"
+	EscalationSkipped   = "skipped"
+	EscalationCancelled = "cancelled"
+	EscalationFailed    = "failed"
+)
+
+// Escalation conditions. By default an action runs DelayMins after the
+// trigger only if the reminder is still unacknowledged by then; "open"
+// actions run as long as it is not completed or cancelled.
+const (
+	EscalationWhenUnacknowledged = "unacknowledged"
+	EscalationWhenOpen           = "open"
+)
+
 type EscalationAction struct {
 	Type      string `bson:"type" json:"type"`
 	Target    string `bson:"target" json:"target"`
 	DelayMins int    `bson:"delay_mins" json:"delay_mins"`
+	Condition string `bson:"condition,omitempty" json:"condition,omitempty"`
 	Message   string `bson:"message,omitempty" json:"message,omitempty"`
 }
 
 type EscalationRule struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID      string             `bson:"user_id" json:"user_id"`
-	WorkspaceID string             `bson:"workspace_id" json:"workspace_id"`
-	Name        string             `bson:"name" json:"name"`
-	Priority    ReminderPriority   `bson:"priority,omitempty" json:"priority,omitempty"`
-	Enabled     bool               `bson:"enabled" json:"enabled"`
-	Actions     []EscalationAction `bson:"actions" json:"actions"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        string             `bson:"user_id" json:"user_id"`
+	WorkspaceID   string             `bson:"workspace_id" json:"workspace_id"`
+	WorkspaceWide bool               `bson:"workspace_wide" json:"workspace_wide"`
+	Name          string             `bson:"name" json:"name"`
+	Priority      ReminderPriority   `bson:"priority,omitempty" json:"priority,omitempty"`
+	Enabled       bool               `bson:"enabled" json:"enabled"`
+	Actions       []EscalationAction `bson:"actions" json:"actions"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
 type CreateEscalationRequest struct {
-	Name     string             `json:"name" binding:"required"`
-	Priority ReminderPriority   `json:"priority,omitempty"`
-	Enabled  bool               `json:"enabled"`
-	Actions  []EscalationAction `json:"actions" binding:"required"`
+	Name          string             `json:"name" binding:"required"`
+	Priority      ReminderPriority   `json:"priority,omitempty"`
+	Enabled       bool               `json:"enabled"`
+	WorkspaceWide bool               `json:"workspace_wide"`
+	Actions       []EscalationAction `json:"actions" binding:"required"`
 }
 
 type UpdateEscalationRequest struct {
@@ -466,9 +856,71 @@ type EscalationEvent struct {
 	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ReminderID       string             `bson:"reminder_id" json:"reminder_id"`
 	EscalationRuleID string             `bson:"escalation_rule_id" json:"escalation_rule_id"`
+	ActionIndex      int                `bson:"action_index" json:"action_index"`
 	Action           EscalationAction   `bson:"action" json:"action"`
 	Status           string             `bson:"status" json:"status"`
-	ExecutedAt       time.Time          `bson:"executed_at" json:"executed_at"`
+	Reason           string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Attempts         int                `bson:"attempts" json:"attempts"`
+	ScheduledAt      time.Time          `bson:"scheduled_at" json:"scheduled_at"`
+	ExecutedAt       *time.Time         `bson:"executed_at,omitempty" json:"executed_at,omitempty"`
+
+	LeaseOwner     string     `bson:"lease_owner,omitempty" json:"-"`
+	LeaseExpiresAt *time.Time `bson:"lease_expires_at,omitempty" json:"-"`
+}
+
+// -- Saved View Models --
+
+// A personal view runs over the reminders of whoever opens it; a workspace
+// view runs over the whole workspace and needs the workspace search role.
+const (
+	ViewScopePersonal  = "personal"
+	ViewScopeWorkspace = "workspace"
+)
+
+// SavedView is a named search. UserID and WorkspaceID of Filter are not
+// stored; they come from the view's scope when it runs.
+type SavedView struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      string             `bson:"user_id" json:"user_id"`
+	WorkspaceID string             `bson:"workspace_id" json:"workspace_id"`
+	Name        string             `bson:"name" json:"name"`
+	Scope       string             `bson:"scope" json:"scope"`
+	Filter      ReminderFilter     `bson:"filter" json:"filter"`
+	Sort        string             `bson:"sort,omitempty" json:"sort,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+
+	// Permission is the caller's share permission on a view they do not
+	// own, and Count the number of matching reminders; both are filled in
+	// when listing.
+	Permission string `bson:"-" json:"permission,omitempty"`
+	Count      *int64 `bson:"-" json:"count,omitempty"`
+}
+
+type CreateViewRequest struct {
+	Name        string         `json:"name" binding:"required"`
+	WorkspaceID string         `json:"workspace_id" binding:"required"`
+	Scope       string         `json:"scope,omitempty" binding:"omitempty,oneof=personal workspace"`
+	Filter      ReminderFilter `json:"filter"`
+	Sort        string         `json:"sort,omitempty" binding:"omitempty,oneof=remind_at relevance"`
+}
+
+type UpdateViewRequest struct {
+	Name   string          `json:"name,omitempty"`
+	Scope  string          `json:"scope,omitempty" binding:"omitempty,oneof=personal workspace"`
+	Filter *ReminderFilter `json:"filter,omitempty"`
+	Sort   string          `json:"sort,omitempty" binding:"omitempty,oneof=remind_at relevance"`
+}
+
+// ViewShare gives another user access to a saved view, with the same view
+// and edit permissions as a ReminderShare.
+type ViewShare struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ViewID     string             `bson:"view_id" json:"view_id"`
+	SharedBy   string             `bson:"shared_by" json:"shared_by"`
+	SharedWith string             `bson:"shared_with" json:"shared_with"`
+	Permission string             `bson:"permission" json:"permission"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
 }
 
 // -- Category Models --
@@ -537,6 +989,7 @@ type RecurringPattern struct {
 	Name            string             `bson:"name" json:"name"`
 	Pattern         string             `bson:"pattern" json:"pattern"`
 	Interval        int                `bson:"interval" json:"interval"`
+	RRule           string             `bson:"rrule,omitempty" json:"rrule,omitempty"`
 	DaysOfWeek      []int              `bson:"days_of_week,omitempty" json:"days_of_week,omitempty"`
 	DaysOfMonth     []int              `bson:"days_of_month,omitempty" json:"days_of_month,omitempty"`
 	MonthsOfYear    []int              `bson:"months_of_year,omitempty" json:"months_of_year,omitempty"`
@@ -552,41 +1005,58 @@ type RecurringPattern struct {
 	ReminderDesc    string             `bson:"reminder_desc,omitempty" json:"reminder_desc,omitempty"`
 	LastTriggered   *time.Time         `bson:"last_triggered,omitempty" json:"last_triggered,omitempty"`
 	NextOccurrence  *time.Time         `bson:"next_occurrence,omitempty" json:"next_occurrence,omitempty"`
+	CatchUp         CatchUpPolicy      `bson:"catch_up,omitempty" json:"catch_up,omitempty"`
 	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+
+	LeaseOwner     string     `bson:"lease_owner,omitempty" json:"-"`
+	LeaseExpiresAt *time.Time `bson:"lease_expires_at,omitempty" json:"-"`
 }
 
+// CatchUpPolicy decides what happens to occurrences a pattern missed while
+// the service was down. Skip is the default.
+type CatchUpPolicy string
+
+const (
+	CatchUpSkip     CatchUpPolicy = "skip"
+	CatchUpBackfill CatchUpPolicy = "backfill"
+)
+
 type CreateRecurringPatternRequest struct {
-	Name           string       `json:"name" binding:"required"`
-	Pattern        string       `json:"pattern" binding:"required"`
-	Interval       int          `json:"interval" binding:"required"`
-	DaysOfWeek     []int        `json:"days_of_week,omitempty"`
-	DaysOfMonth    []int        `json:"days_of_month,omitempty"`
-	MonthsOfYear   []int        `json:"months_of_year,omitempty"`
-	TimeOfDay      string       `json:"time_of_day,omitempty"`
-	Timezone       string       `json:"timezone,omitempty"`
-	StartDate      time.Time    `json:"start_date" binding:"required"`
-	EndDate        *time.Time   `json:"end_date,omitempty"`
-	MaxOccurrences int          `json:"max_occurrences"`
-	ReminderTitle  string       `json:"reminder_title" binding:"required"`
-	ReminderType   ReminderType `json:"reminder_type" binding:"required"`
-	ReminderDesc   string       `json:"reminder_desc,omitempty"`
+	Name           string        `json:"name" binding:"required"`
+	Pattern        string        `json:"pattern,omitempty"`
+	Interval       int           `json:"interval,omitempty"`
+	RRule          string        `json:"rrule,omitempty"`
+	DaysOfWeek     []int         `json:"days_of_week,omitempty"`
+	DaysOfMonth    []int         `json:"days_of_month,omitempty"`
+	MonthsOfYear   []int         `json:"months_of_year,omitempty"`
+	TimeOfDay      string        `json:"time_of_day,omitempty"`
+	Timezone       string        `json:"timezone,omitempty"`
+	StartDate      time.Time     `json:"start_date" binding:"required"`
+	EndDate        *time.Time    `json:"end_date,omitempty"`
+	MaxOccurrences int           `json:"max_occurrences"`
+	ReminderTitle  string        `json:"reminder_title" binding:"required"`
+	ReminderType   ReminderType  `json:"reminder_type" binding:"required"`
+	ReminderDesc   string        `json:"reminder_desc,omitempty"`
+	CatchUp        CatchUpPolicy `json:"catch_up,omitempty" binding:"omitempty,oneof=skip backfill"`
 }
 
 type UpdateRecurringPatternRequest struct {
-	Name           string     `json:"name,omitempty"`
-	Pattern        string     `json:"pattern,omitempty"`
-	Interval       *int       `json:"interval,omitempty"`
-	DaysOfWeek     []int      `json:"days_of_week,omitempty"`
-	DaysOfMonth    []int      `json:"days_of_month,omitempty"`
-	MonthsOfYear   []int      `json:"months_of_year,omitempty"`
-	TimeOfDay      string     `json:"time_of_day,omitempty"`
-	Timezone       string     `json:"timezone,omitempty"`
-	EndDate        *time.Time `json:"end_date,omitempty"`
-	MaxOccurrences *int       `json:"max_occurrences,omitempty"`
-	IsActive       *bool      `json:"is_active,omitempty"`
-	ReminderTitle  string     `json:"reminder_title,omitempty"`
-	ReminderDesc   string     `json:"reminder_desc,omitempty"`
+	Name           string        `json:"name,omitempty"`
+	Pattern        string        `json:"pattern,omitempty"`
+	Interval       *int          `json:"interval,omitempty"`
+	RRule          *string       `json:"rrule,omitempty"`
+	DaysOfWeek     []int         `json:"days_of_week,omitempty"`
+	DaysOfMonth    []int         `json:"days_of_month,omitempty"`
+	MonthsOfYear   []int         `json:"months_of_year,omitempty"`
+	TimeOfDay      string        `json:"time_of_day,omitempty"`
+	Timezone       string        `json:"timezone,omitempty"`
+	EndDate        *time.Time    `json:"end_date,omitempty"`
+	MaxOccurrences *int          `json:"max_occurrences,omitempty"`
+	IsActive       *bool         `json:"is_active,omitempty"`
+	ReminderTitle  string        `json:"reminder_title,omitempty"`
+	ReminderDesc   string        `json:"reminder_desc,omitempty"`
+	CatchUp        CatchUpPolicy `json:"catch_up,omitempty" binding:"omitempty,oneof=skip backfill"`
 }
 
 type RecurringOccurrence struct {
@@ -727,3 +1197,278 @@ type HabitSummary struct {
 	TodayDone    int     `json:"today_done"`
 	TodayTotal   int     `json:"today_total"`
 }
+
+// -- Workspace Membership Models --
+
+type WorkspaceRole string
+
+const (
+	WorkspaceOwner  WorkspaceRole = "owner"
+	WorkspaceAdmin  WorkspaceRole = "admin"
+	WorkspaceMember WorkspaceRole = "member"
+	WorkspaceGuest  WorkspaceRole = "guest"
+)
+
+// WorkspaceMembership mirrors the membership service. Removed members are
+// kept as tombstones so a late, older event cannot bring them back.
+type WorkspaceMembership struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WorkspaceID string             `bson:"workspace_id" json:"workspace_id"`
+	UserID      string             `bson:"user_id" json:"user_id"`
+	Role        WorkspaceRole      `bson:"role" json:"role"`
+	Removed     bool               `bson:"removed" json:"removed"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// WorkspaceMemberEvent is a message on the workspace.members topic. Type is
+// "member.upserted" or "member.removed". OccurredAt orders events for the
+// same member, so it is required.
+type WorkspaceMemberEvent struct {
+	Type        string        `json:"type"`
+	WorkspaceID string        `json:"workspace_id"`
+	UserID      string        `json:"user_id"`
+	Role        WorkspaceRole `json:"role,omitempty"`
+	OccurredAt  time.Time     `json:"occurred_at"`
+}
+
+const (
+	MemberUpserted = "member.upserted"
+	MemberRemoved  = "member.removed"
+)
+
+func (e *WorkspaceMemberEvent) Validate() error {
+	if e.WorkspaceID == "" || e.UserID == "" {
+		return fmt.Errorf("member event requires workspace_id and user_id")
+	}
+	if e.OccurredAt.IsZero() {
+		return fmt.Errorf("member event requires occurred_at")
+	}
+	switch e.Type {
+	case MemberUpserted:
+		switch e.Role {
+		case WorkspaceOwner, WorkspaceAdmin, WorkspaceMember, WorkspaceGuest:
+			return nil
+		}
+		return fmt.Errorf("unknown workspace role %q", e.Role)
+	case MemberRemoved:
+		return nil
+	}
+	return fmt.Errorf("unknown member event type %q", e.Type)
+}
+
+type AccessDenial struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WorkspaceID string             `bson:"workspace_id" json:"workspace_id"`
+	UserID      string             `bson:"user_id" json:"user_id"`
+	Role        WorkspaceRole      `bson:"role,omitempty" json:"role,omitempty"`
+	Action      string             `bson:"action" json:"action"`
+	Method      string             `bson:"method" json:"method"`
+	Path        string             `bson:"path" json:"path"`
+	DeniedAt    time.Time          `bson:"denied_at" json:"denied_at"`
+}
+
+// -- Job Models --
+
+type JobKind string
+
+const (
+	JobExport JobKind = "export"
+	JobImport JobKind = "import"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Import job input formats.
+const (
+	ImportFormatJSON = "json"
+	ImportFormatICS  = "ics"
+)
+
+// Job is an export or import that runs in the background. Its input and
+// result files live in GridFS.
+type Job struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID          string              `bson:"user_id" json:"user_id"`
+	WorkspaceID     string              `bson:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	Kind            JobKind             `bson:"kind" json:"kind"`
+	Status          JobStatus           `bson:"status" json:"status"`
+	Export          *ExportRequest      `bson:"export,omitempty" json:"export,omitempty"`
+	ImportFormat    string              `bson:"import_format,omitempty" json:"import_format,omitempty"`
+	Timezone        string              `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	InputFileID     *primitive.ObjectID `bson:"input_file_id,omitempty" json:"-"`
+	ResultFileID    *primitive.ObjectID `bson:"result_file_id,omitempty" json:"-"`
+	ResultName      string              `bson:"result_name,omitempty" json:"result_name,omitempty"`
+	ResultType      string              `bson:"result_type,omitempty" json:"-"`
+	Total           int                 `bson:"total" json:"total"`
+	Processed       int                 `bson:"processed" json:"processed"`
+	Failed          int                 `bson:"failed" json:"failed"`
+	Errors          []string            `bson:"errors,omitempty" json:"errors,omitempty"`
+	Reason          string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	CancelRequested bool                `bson:"cancel_requested" json:"cancel_requested"`
+	Attempts        int                 `bson:"attempts" json:"attempts"`
+	CreatedAt       time.Time           `bson:"created_at" json:"created_at"`
+	StartedAt       *time.Time          `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt      *time.Time          `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+
+	LeaseOwner     string     `bson:"lease_owner,omitempty" json:"-"`
+	LeaseExpiresAt *time.Time `bson:"lease_expires_at,omitempty" json:"-"`
+}
+
+func (j *Job) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled
+}
+
+// -- Location Models --
+
+const (
+	GeofenceEnter = "enter"
+	GeofenceExit  = "exit"
+)
+
+// LocationUpdate is a user's position, posted to /locations or sent on the
+// locations.updates topic. Accuracy is in meters; RecordedAt defaults to
+// the time of receipt.
+type LocationUpdate struct {
+	UserID     string    `json:"user_id"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Accuracy   float64   `json:"accuracy,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+func (u *LocationUpdate) Validate() error {
+	if u.UserID == "" {
+		return fmt.Errorf("location update requires user_id")
+	}
+	if u.Latitude < -90 || u.Latitude > 90 || u.Longitude < -180 || u.Longitude > 180 {
+		return fmt.Errorf("latitude must be within ±90 and longitude within ±180")
+	}
+	if u.Accuracy < 0 {
+		return fmt.Errorf("accuracy must not be negative")
+	}
+	return nil
+}
+
+// GeofenceTransition is a user entering or leaving a reminder's geofence.
+// Triggered reports whether it fired the reminder.
+type GeofenceTransition struct {
+	ReminderID string    `json:"reminder_id"`
+	LocationID string    `json:"location_id"`
+	Event      string    `json:"event"`
+	Triggered  bool      `json:"triggered"`
+	At         time.Time `json:"at"`
+}
+
+// -- Snooze Models --
+
+// SnoozeSource records how a snooze reached the service.
+type SnoozeSource string
+
+const (
+	SnoozeSourceAPI   SnoozeSource = "api"
+	SnoozeSourceBulk  SnoozeSource = "bulk"
+	SnoozeSourceKafka SnoozeSource = "kafka"
+)
+
+// SnoozeSuggestion proposes a snooze both as a duration for
+// POST /reminders/:id/snooze and as the time it lands on.
+type SnoozeSuggestion struct {
+	Key      string    `json:"key"`
+	Label    string    `json:"label"`
+	Duration string    `json:"duration"`
+	Until    time.Time `json:"until"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// -- Delivery Models --
+
+const (
+	DeliverySent      = "sent"
+	DeliveryDelivered = "delivered"
+	DeliveryRead      = "read"
+)
+
+// DeliveryAttempt is one notification of a triggered reminder on one
+// channel. Attempt 0 is the trigger itself; later attempts are
+// re-notifications.
+type DeliveryAttempt struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ReminderID  string             `bson:"reminder_id" json:"reminder_id"`
+	UserID      string             `bson:"user_id" json:"user_id"`
+	WorkspaceID string             `bson:"workspace_id" json:"workspace_id"`
+	Channel     string             `bson:"channel" json:"channel"`
+	Attempt     int                `bson:"attempt" json:"attempt"`
+	Status      string             `bson:"status" json:"status"`
+	SentAt      time.Time          `bson:"sent_at" json:"sent_at"`
+	DeliveredAt *time.Time         `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	ReadAt      *time.Time         `bson:"read_at,omitempty" json:"read_at,omitempty"`
+}
+
+// DeliveryReceipt is a notifications.delivered or notifications.read
+// message. DeliveryID echoes the delivery_id of notifications.send;
+// receipts without one apply to the latest attempt on Channel.
+type DeliveryReceipt struct {
+	DeliveryID string    `json:"delivery_id,omitempty"`
+	ReminderID string    `json:"reminder_id"`
+	Channel    string    `json:"channel,omitempty"`
+	At         time.Time `json:"at,omitempty"`
+}
+
+func (r *DeliveryReceipt) Validate() error {
+	if r.ReminderID == "" {
+		return errors.New("reminder_id is required")
+	}
+	if _, err := primitive.ObjectIDFromHex(r.ReminderID); err != nil {
+		return fmt.Errorf("invalid reminder_id %q", r.ReminderID)
+	}
+	if r.DeliveryID != "" {
+		if _, err := primitive.ObjectIDFromHex(r.DeliveryID); err != nil {
+			return fmt.Errorf("invalid delivery_id %q", r.DeliveryID)
+		}
+	}
+	return nil
+}
+
+// AcknowledgementAnalytics reports how quickly a workspace's reminders
+// triggered since Since were acknowledged. UnacknowledgedAfter counts
+// those not acknowledged within AfterMins minutes, still open or not.
+type AcknowledgementAnalytics struct {
+	Since               time.Time              `json:"since"`
+	AfterMins           int                    `json:"after_mins"`
+	Triggered           int64                  `json:"triggered"`
+	Acknowledged        int64                  `json:"acknowledged"`
+	UnacknowledgedAfter int64                  `json:"unacknowledged_after"`
+	AckRate             float64                `json:"ack_rate"`
+	AvgAckSeconds       float64                `json:"avg_ack_seconds"`
+	ByChannel           []ChannelDeliveryStats `json:"by_channel"`
+}
+
+type ChannelDeliveryStats struct {
+	Channel   string `json:"channel" bson:"_id"`
+	Sent      int64  `json:"sent" bson:"sent"`
+	Delivered int64  `json:"delivered" bson:"delivered"`
+	Read      int64  `json:"read" bson:"read"`
+}
+
+// -- Parse Models --
+
+// ParseReminderRequest is free text to read as a reminder. The draft is
+// only previewed unless Create is set, and a draft in the past or read with
+// low confidence is only created with Confirm. Type defaults to task.
+type ParseReminderRequest struct {
+	Text        string       `json:"text" binding:"required"`
+	WorkspaceID string       `json:"workspace_id,omitempty"`
+	ChannelID   string       `json:"channel_id,omitempty"`
+	MessageID   string       `json:"message_id,omitempty"`
+	Type        ReminderType `json:"type,omitempty"`
+	Create      bool         `json:"create"`
+	Confirm     bool         `json:"confirm"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		cursor PageCursor
+		offset bool
+	}{
+		{"position", PageCursor{RemindAt: at, ID: id}, false},
+		{"offset", PageCursor{Offset: 40}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor.Encode())
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+			if !got.RemindAt.Equal(tt.cursor.RemindAt) || got.ID != tt.cursor.ID || got.Offset != tt.cursor.Offset {
+				t.Errorf("DecodeCursor(Encode(%+v)) = %+v", tt.cursor, got)
+			}
+			if got.IsOffset() != tt.offset {
+				t.Errorf("IsOffset() = %v, want %v", got.IsOffset(), tt.offset)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"not base64", "!!!"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("nope"))},
+		{"bad id", base64.RawURLEncoding.EncodeToString([]byte(`{"i":"xyz"}`))},
+		{"negative offset", base64.RawURLEncoding.EncodeToString([]byte(`{"o":-1}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", tt.token, err)
+			}
+		})
+	}
+}
+
+func TestPaginationParams(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name      string
+		params    PaginationParams
+		skip      int64
+		limit     int64
+		wantTotal bool
+	}{
+		{"defaults", PaginationParams{}, 0, 20, true},
+		{"third page", PaginationParams{Page: 3, PerPage: 10}, 20, 10, true},
+		{"per page too large", PaginationParams{Page: 2, PerPage: 500}, 20, 20, true},
+		{"cursor", PaginationParams{Cursor: "x"}, 0, 20, false},
+		{"cursor with total", PaginationParams{Cursor: "x", IncludeTotal: &yes}, 0, 20, true},
+		{"page without total", PaginationParams{IncludeTotal: &no}, 0, 20, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.params
+			p.Validate()
+			if p.Skip() != tt.skip || p.Limit() != tt.limit || p.WantsTotal() != tt.wantTotal {
+				t.Errorf("Skip, Limit, WantsTotal = %d, %d, %v, want %d, %d, %v",
+					p.Skip(), p.Limit(), p.WantsTotal(), tt.skip, tt.limit, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,524 @@
+// Package nlparse turns English reminder phrases such as
+//
+//	remind me every Friday at 4pm to send the status report
+//	in 2 hours check the deploy
+//	next month on the 1st pay rent
+//
+// into a time, an optional recurrence and a title. It is rule based and
+// deterministic: the same text parsed at the same instant in the same zone
+// always gives the same result.
+package nlparse
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"reminder-service/internal/models"
+	"reminder-service/internal/recurrence"
+)
+
+// ErrNoTime is returned when the text names no time, date or recurrence.
+var ErrNoTime = errors.New("no reminder time found in text")
+
+// Kind is what a span of the input was read as.
+type Kind string
+
+const (
+	KindFiller     Kind = "filler"
+	KindTime       Kind = "time"
+	KindDate       Kind = "date"
+	KindRecurrence Kind = "recurrence"
+	KindTitle      Kind = "title"
+)
+
+// Span is a part of the input and how it was read. Start and End are
+// 0-based rune offsets, End exclusive.
+type Span struct {
+	Kind       Kind    `json:"kind"`
+	Text       string  `json:"text"`
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Result is a parsed reminder. Confidence is the lowest confidence of the
+// spans the time was built from, reduced when parts had to be assumed.
+type Result struct {
+	Title      string             `json:"title"`
+	RemindAt   time.Time          `json:"remind_at"`
+	Recurrence *models.Recurrence `json:"recurrence,omitempty"`
+	Confidence float64            `json:"confidence"`
+	Spans      []Span             `json:"spans"`
+}
+
+// Times written without a clock are at defaultHour. Parts of the day map
+// to fixed hours.
+const defaultHour = 9
+
+var partOfDay = map[string]int{
+	"morning":   9,
+	"noon":      12,
+	"midday":    12,
+	"afternoon": 14,
+	"evening":   18,
+	"night":     20,
+	"tonight":   20,
+	"midnight":  0,
+}
+
+// Parse reads text relative to now. Times are resolved in now's location,
+// which also becomes the recurrence's timezone.
+func Parse(text string, now time.Time) (*Result, error) {
+	p := &parser{input: []rune(text), now: now, clockSpan: -1}
+	p.toks = tokenize(p.input)
+	p.used = make([]bool, len(p.toks))
+
+	p.lead()
+	for i := 0; i < len(p.toks); {
+		if p.used[i] {
+			i++
+			continue
+		}
+		if n := p.match(i); n > 0 {
+			i += n
+			continue
+		}
+		i++
+	}
+	if !p.d.hasTime() {
+		return nil, ErrNoTime
+	}
+
+	remindAt, rec, conf := p.resolve()
+	title := p.title()
+	if title == "" {
+		conf *= 0.5
+	}
+
+	spans := p.spans
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	return &Result{
+		Title:      title,
+		RemindAt:   remindAt,
+		Recurrence: rec,
+		Confidence: round2(conf),
+		Spans:      spans,
+	}, nil
+}
+
+// ── Tokens ──
+
+type token struct {
+	// text is the lower-cased word without surrounding punctuation, raw the
+	// word as written including it.
+	text       string
+	raw        string
+	start, end int
+	comma      bool
+}
+
+func tokenize(input []rune) []token {
+	var toks []token
+	for i := 0; i < len(input); {
+		if unicode.IsSpace(input[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(input) && !unicode.IsSpace(input[i]) {
+			i++
+		}
+		end := i
+
+		core := input[start:end]
+		lo, hi := 0, len(core)
+		for lo < hi && isEdgePunct(core[lo]) {
+			lo++
+		}
+		for hi > lo && isEdgePunct(core[hi-1]) {
+			hi--
+		}
+		if lo == hi {
+			continue
+		}
+		toks = append(toks, token{
+			text:  strings.ToLower(string(core[lo:hi])),
+			raw:   string(core),
+			start: start,
+			end:   end,
+			comma: strings.ContainsRune(string(core[hi:]), ','),
+		})
+	}
+	return toks
+}
+
+func isEdgePunct(r rune) bool {
+	return strings.ContainsRune(`,.;:!?()"'`, r)
+}
+
+// ── Parser ──
+
+type parser struct {
+	input []rune
+	now   time.Time
+	toks  []token
+	used  []bool
+	spans []Span
+	d     draft
+
+	// clockSpan indexes the span an explicit clock time was read from.
+	clockSpan int
+}
+
+// word returns the token at i, or "" past the end or when already used.
+func (p *parser) word(i int) string {
+	if i < 0 || i >= len(p.toks) || p.used[i] {
+		return ""
+	}
+	return p.toks[i].text
+}
+
+// take marks tokens [i, i+n) as read as kind and returns n.
+func (p *parser) take(i, n int, kind Kind, conf float64) int {
+	for j := i; j < i+n; j++ {
+		p.used[j] = true
+	}
+	start, end := p.toks[i].start, p.toks[i+n-1].end
+	text := strings.TrimRightFunc(string(p.input[start:end]), isEdgePunct)
+	p.spans = append(p.spans, Span{
+		Kind:       kind,
+		Text:       text,
+		Start:      start,
+		End:        start + len([]rune(text)),
+		Confidence: conf,
+	})
+	return n
+}
+
+// lead drops an opening "remind me to" and the like.
+func (p *parser) lead() {
+	n := 0
+	switch p.word(0) {
+	case "remind":
+		n = 1
+		switch p.word(1) {
+		case "me", "us", "everyone", "@here", "@channel":
+			n = 2
+		}
+	case "reminder":
+		n = 1
+	}
+	if n == 0 {
+		return
+	}
+	switch p.word(n) {
+	case "to", "that", "about":
+		n++
+	}
+	p.take(0, n, KindFiller, 1)
+}
+
+func (p *parser) match(i int) int {
+	if n := p.matchRecurrence(i); n > 0 {
+		return n
+	}
+	if n := p.matchRelative(i); n > 0 {
+		return n
+	}
+	if n := p.matchDate(i); n > 0 {
+		return n
+	}
+	return p.matchClock(i)
+}
+
+// title joins the words nothing else claimed, dropping connecting words
+// left at either end.
+func (p *parser) title() string {
+	var idx []int
+	for i := range p.toks {
+		if !p.used[i] {
+			idx = append(idx, i)
+		}
+	}
+	for len(idx) > 0 && isConnector(p.toks[idx[0]].text) {
+		p.take(idx[0], 1, KindFiller, 1)
+		idx = idx[1:]
+	}
+	for len(idx) > 0 && isConnector(p.toks[idx[len(idx)-1]].text) {
+		p.take(idx[len(idx)-1], 1, KindFiller, 1)
+		idx = idx[:len(idx)-1]
+	}
+	if len(idx) == 0 {
+		return ""
+	}
+
+	words := make([]string, len(idx))
+	for k, i := range idx {
+		words[k] = p.toks[i].raw
+	}
+	joined := strings.Join(words, " ")
+	title := strings.TrimLeftFunc(joined, isEdgePunct)
+	start := p.toks[idx[0]].start + len([]rune(joined)) - len([]rune(title))
+	title = strings.TrimRightFunc(title, isEdgePunct)
+
+	last := p.toks[idx[len(idx)-1]]
+	p.spans = append(p.spans, Span{
+		Kind:       KindTitle,
+		Text:       title,
+		Start:      start,
+		End:        last.start + len([]rune(strings.TrimRightFunc(last.raw, isEdgePunct))),
+		Confidence: 0.9,
+	})
+	return title
+}
+
+func isConnector(w string) bool {
+	switch w {
+	case "to", "that", "about", "at", "on", "in", "by", "and", "for", "of", "starting", "from":
+		return true
+	}
+	return false
+}
+
+func round2(f float64) float64 {
+	return float64(int(f*100+0.5)) / 100
+}
+
+// ── Resolution ──
+
+type clock struct {
+	hour, min int
+}
+
+// draft collects what the matchers found before it is resolved to a time.
+type draft struct {
+	// "in 2 hours", "in 3 days"
+	hasRel            bool
+	rel               time.Duration
+	relDays, relMonth int
+
+	// An explicit calendar date; year is false when the text left it out.
+	hasDate bool
+	date    time.Time
+	year    bool
+
+	hasWeekday bool
+	weekday    time.Weekday
+	// nextWeekday skips today: "next friday" on a Friday is a week out.
+	nextWeekday bool
+
+	monthDay    int
+	monthOffset int
+	nextWeek    bool
+
+	hasClock bool
+	clock    clock
+	// fromPart is set when the clock came from "morning" and the like, and
+	// ambiguous when an hour without am/pm was read as one of them.
+	fromPart  bool
+	ambiguous bool
+
+	rule  *recurrence.Rule
+	until time.Time
+}
+
+func (d *draft) hasTime() bool {
+	return d.hasRel || d.hasDate || d.hasWeekday || d.monthDay > 0 || d.monthOffset > 0 ||
+		d.nextWeek || d.hasClock || d.rule != nil
+}
+
+func (d *draft) hasDay() bool {
+	return d.hasDate || d.hasWeekday || d.monthDay > 0 || d.monthOffset > 0 || d.nextWeek
+}
+
+// resolve turns the draft into the first reminder time, the recurrence if
+// any and the confidence of the result.
+func (p *parser) resolve() (time.Time, *models.Recurrence, float64) {
+	d := &p.d
+	now := p.now
+	loc := now.Location()
+
+	conf := 1.0
+	for _, s := range p.spans {
+		if s.Kind != KindFiller && s.Kind != KindTitle && s.Confidence < conf {
+			conf = s.Confidence
+		}
+	}
+
+	c := d.clock
+	if !d.hasClock {
+		c = clock{hour: defaultHour}
+	}
+	at := func(day time.Time) time.Time {
+		y, m, dd := day.Date()
+		return wallTime(y, m, dd, c.hour, c.min, loc)
+	}
+
+	var t time.Time
+	switch {
+	case d.hasRel:
+		t = now.Add(d.rel).AddDate(0, d.relMonth, d.relDays)
+		if d.hasClock && d.rel == 0 {
+			t = at(t)
+		}
+	case d.rule != nil && d.rule.Freq == recurrence.Hourly && !d.hasClock && !d.hasDay():
+		t = now.Add(time.Duration(d.rule.Interval) * time.Hour).Truncate(time.Minute)
+	default:
+		// The clock was assumed, or the text named a time already past.
+		if !d.hasClock {
+			conf *= 0.8
+		}
+		t = p.dayTime(at)
+		if !t.After(now) {
+			conf = min(conf, 0.3)
+		}
+	}
+
+	if d.rule == nil {
+		return t, nil, conf
+	}
+	return p.series(t, loc, conf)
+}
+
+// wallTime returns the clock time hour:minute on a day in loc. A time skipped
+// by a daylight-saving jump is moved forward by the length of the jump, so
+// 02:30 on the night clocks go from 02:00 to 03:00 becomes 03:30.
+func wallTime(y int, m time.Month, d, hour, minute int, loc *time.Location) time.Time {
+	t := time.Date(y, m, d, hour, minute, 0, 0, loc)
+	if t.Hour() == hour && t.Minute() == minute {
+		return t
+	}
+	// Read the clock with the offset in force before the jump.
+	_, offset := time.Date(y, m, d-1, 12, 0, 0, 0, loc).Zone()
+	return time.Date(y, m, d, hour, minute, 0, 0, time.UTC).Add(-time.Duration(offset) * time.Second).In(loc)
+}
+
+// dayTime picks the day the draft describes and the clock on it, moving
+// to the next matching day when that time has passed.
+func (p *parser) dayTime(at func(time.Time) time.Time) time.Time {
+	d := &p.d
+	now := p.now
+	y, m, dd := now.Date()
+	today := time.Date(y, m, dd, 0, 0, 0, 0, now.Location())
+
+	switch {
+	case d.hasDate:
+		day := d.date
+		if !d.year && day.Before(today) {
+			day = day.AddDate(1, 0, 0)
+		}
+		t := at(day)
+		if !t.After(now) && day.Equal(today) && !d.hasClock {
+			// "today" with no clock means soon: the top of the next hour.
+			return now.Truncate(time.Hour).Add(time.Hour)
+		}
+		return t
+
+	case d.hasWeekday:
+		offset := (int(d.weekday) - int(today.Weekday()) + 7) % 7
+		if d.nextWeekday && offset == 0 {
+			offset = 7
+		}
+		t := at(today.AddDate(0, 0, offset))
+		if !t.After(now) {
+			t = at(today.AddDate(0, 0, offset+7))
+		}
+		return t
+
+	case d.monthDay > 0:
+		t := at(monthDate(today, d.monthOffset, d.monthDay))
+		if !t.After(now) && d.monthOffset == 0 {
+			t = at(monthDate(today, 1, d.monthDay))
+		}
+		return t
+
+	case d.monthOffset > 0:
+		return at(monthDate(today, d.monthOffset, today.Day()))
+
+	case d.nextWeek:
+		offset := (int(time.Monday) - int(today.Weekday()) + 7) % 7
+		if offset == 0 {
+			offset = 7
+		}
+		return at(today.AddDate(0, 0, offset))
+	}
+
+	t := at(today)
+	if !t.After(now) {
+		t = at(today.AddDate(0, 0, 1))
+	}
+	return t
+}
+
+// monthDate returns day of the month offset months after today's, clamped
+// to the month's last day.
+func monthDate(today time.Time, offset, day int) time.Time {
+	first := time.Date(today.Year(), today.Month()+time.Month(offset), 1, 0, 0, 0, 0, today.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, last)-1)
+}
+
+// series fills the recurrence rule in from the date parts and returns its
+// first occurrence after now, starting the search at anchor.
+func (p *parser) series(anchor time.Time, loc *time.Location, conf float64) (time.Time, *models.Recurrence, float64) {
+	d := &p.d
+	rule := d.rule
+
+	switch rule.Freq {
+	case recurrence.Weekly:
+		if len(rule.ByDay) == 0 && d.hasWeekday {
+			rule.ByDay = []recurrence.Weekday{{Day: d.weekday}}
+		}
+	case recurrence.Monthly:
+		if len(rule.ByMonthDay) == 0 && d.monthDay > 0 {
+			rule.ByMonthDay = []int{d.monthDay}
+		}
+	case recurrence.Yearly:
+		if d.hasDate {
+			rule.ByMonth = []int{int(d.date.Month())}
+			rule.ByMonthDay = []int{d.date.Day()}
+		}
+	}
+	if !d.until.IsZero() {
+		rule.Until = d.until
+	}
+
+	// The first occurrence starts the series, so it is searched for
+	// without the interval: "every other Monday" starts this Monday.
+	first := anchor
+	probe := *rule
+	probe.Interval = 1
+	schedule := recurrence.NewSchedule(&probe, anchor, nil)
+	if !anchor.After(p.now) || !schedule.Matches(anchor) {
+		from := anchor
+		if p.now.After(from) {
+			from = p.now
+		}
+		next, ok := schedule.Next(from)
+		if !ok {
+			conf = min(conf, 0.3)
+		} else {
+			first = next
+		}
+	}
+
+	rec := &models.Recurrence{
+		Pattern:  strings.ToLower(rule.Freq.String()),
+		Interval: rule.Interval,
+		RRule:    rule.String(),
+		Timezone: loc.String(),
+	}
+	if rule.Freq == recurrence.Weekly {
+		for _, wd := range rule.ByDay {
+			rec.DaysOfWeek = append(rec.DaysOfWeek, int(wd.Day))
+		}
+	}
+	if !rule.Until.IsZero() {
+		until := rule.Until
+		rec.EndDate = &until
+	}
+	return first, rec, conf
+}
@@ -0,0 +1,176 @@
+package nlparse
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// now is a Wednesday morning.
+var now = time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		title    string
+		remindAt string
+		rrule    string
+	}{
+		{
+			name:     "relative",
+			text:     "in 2 hours check the deploy",
+			title:    "check the deploy",
+			remindAt: "2025-03-05T12:00:00Z",
+		},
+		{
+			name:     "tomorrow with clock",
+			text:     "remind me tomorrow at 3pm to call mom",
+			title:    "call mom",
+			remindAt: "2025-03-06T15:00:00Z",
+		},
+		{
+			name:     "weekday without clock",
+			text:     "friday submit timesheet",
+			title:    "submit timesheet",
+			remindAt: "2025-03-07T09:00:00Z",
+		},
+		{
+			name:     "part of day",
+			text:     "water the plants this evening",
+			title:    "water the plants",
+			remindAt: "2025-03-05T18:00:00Z",
+		},
+		{
+			name:     "next month on a day",
+			text:     "next month on the 1st pay rent",
+			title:    "pay rent",
+			remindAt: "2025-04-01T09:00:00Z",
+		},
+		{
+			name:     "weekly series",
+			text:     "remind me every Friday at 4pm to send the status report",
+			title:    "send the status report",
+			remindAt: "2025-03-07T16:00:00Z",
+			rrule:    "FREQ=WEEKLY;BYDAY=FR",
+		},
+		{
+			name:     "daily series",
+			text:     "every day at 8am stand-up",
+			title:    "stand-up",
+			remindAt: "2025-03-06T08:00:00Z",
+			rrule:    "FREQ=DAILY",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Parse(tt.text, now)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.text, err)
+			}
+			if res.Title != tt.title {
+				t.Errorf("Title = %q, want %q", res.Title, tt.title)
+			}
+			if got := res.RemindAt.Format(time.RFC3339); got != tt.remindAt {
+				t.Errorf("RemindAt = %s, want %s", got, tt.remindAt)
+			}
+			switch {
+			case tt.rrule == "" && res.Recurrence != nil:
+				t.Errorf("Recurrence = %+v, want none", res.Recurrence)
+			case tt.rrule != "" && res.Recurrence == nil:
+				t.Errorf("Recurrence = nil, want %s", tt.rrule)
+			case tt.rrule != "" && res.Recurrence.RRule != tt.rrule:
+				t.Errorf("RRule = %s, want %s", res.Recurrence.RRule, tt.rrule)
+			}
+		})
+	}
+}
+
+func TestParseConfidence(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		min  float64
+		max  float64
+	}{
+		{"explicit", "tomorrow at 3pm call mom", 0.9, 1},
+		{"assumed clock", "tomorrow call mom", 0.5, 0.9},
+		{"past time", "today at 8am call mom", 0, 0.3},
+		{"no title", "tomorrow at 3pm", 0, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Parse(tt.text, now)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.text, err)
+			}
+			if res.Confidence < tt.min || res.Confidence > tt.max {
+				t.Errorf("Confidence = %v, want within [%v, %v]", res.Confidence, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestParseNoTime(t *testing.T) {
+	for _, text := range []string{"", "call bob", "buy milk and eggs"} {
+		if _, err := Parse(text, now); !errors.Is(err, ErrNoTime) {
+			t.Errorf("Parse(%q) error = %v, want ErrNoTime", text, err)
+		}
+	}
+}
+
+func TestParseKeepsSpanOffsets(t *testing.T) {
+	text := "in 2 hours check the deploy"
+	res, err := Parse(text, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runes := []rune(text)
+	for _, s := range res.Spans {
+		if got := string(runes[s.Start:s.End]); got != s.Text {
+			t.Errorf("span %+v covers %q", s, got)
+		}
+	}
+}
+
+func TestWallTime(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone unavailable: %v", err)
+	}
+	tests := []struct {
+		name   string
+		day    time.Time
+		hour   int
+		minute int
+		want   string
+	}{
+		{"ordinary", time.Date(2025, 3, 5, 0, 0, 0, 0, ny), 9, 0, "2025-03-05T09:00:00-05:00"},
+		{"in the spring gap", time.Date(2025, 3, 9, 0, 0, 0, 0, ny), 2, 30, "2025-03-09T03:30:00-04:00"},
+		{"start of the gap", time.Date(2025, 3, 9, 0, 0, 0, 0, ny), 2, 0, "2025-03-09T03:00:00-04:00"},
+		{"after the gap", time.Date(2025, 3, 9, 0, 0, 0, 0, ny), 3, 0, "2025-03-09T03:00:00-04:00"},
+		{"repeated autumn hour", time.Date(2025, 11, 2, 0, 0, 0, 0, ny), 1, 30, "2025-11-02T01:30:00-04:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			y, m, d := tt.day.Date()
+			if got := wallTime(y, m, d, tt.hour, tt.minute, ny).Format(time.RFC3339); got != tt.want {
+				t.Errorf("wallTime = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInDSTGap(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone unavailable: %v", err)
+	}
+	res, err := Parse("tomorrow at 2:30am run the backup", time.Date(2025, 3, 8, 10, 0, 0, 0, ny))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := res.RemindAt.Format(time.RFC3339), "2025-03-09T03:30:00-04:00"; got != want {
+		t.Errorf("RemindAt = %s, want %s", got, want)
+	}
+}
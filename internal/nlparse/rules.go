@@ -0,0 +1,628 @@
+package nlparse
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"reminder-service/internal/recurrence"
+)
+
+// ── Vocabulary ──
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday,
+	"wednesday": time.Wednesday, "thursday": time.Thursday, "friday": time.Friday,
+	"saturday": time.Saturday,
+}
+
+// Short weekday names are also English words ("sat", "sun"), so they only
+// count after on, next, this or every.
+var weekdayAbbrevs = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wed": time.Wednesday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday,
+}
+
+var monthNames = map[string]time.Month{
+	"january": time.January, "jan": time.January, "february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March, "april": time.April, "apr": time.April,
+	"may": time.May, "june": time.June, "jun": time.June, "july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August, "september": time.September, "sep": time.September,
+	"sept": time.September, "october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November, "december": time.December, "dec": time.December,
+}
+
+var countWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+	"fifteen": 15, "twenty": 20, "thirty": 30, "forty-five": 45,
+}
+
+var ordinalWords = map[string]int{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5,
+	"sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10,
+}
+
+type unit int
+
+const (
+	unitMinute unit = iota
+	unitHour
+	unitDay
+	unitWeek
+	unitMonth
+	unitYear
+)
+
+var unitNames = map[string]unit{
+	"minute": unitMinute, "minutes": unitMinute, "min": unitMinute, "mins": unitMinute, "m": unitMinute,
+	"hour": unitHour, "hours": unitHour, "hr": unitHour, "hrs": unitHour, "h": unitHour,
+	"day": unitDay, "days": unitDay, "d": unitDay,
+	"week": unitWeek, "weeks": unitWeek, "wk": unitWeek, "wks": unitWeek, "w": unitWeek,
+	"month": unitMonth, "months": unitMonth,
+	"year": unitYear, "years": unitYear,
+}
+
+var unitFrequency = map[unit]recurrence.Frequency{
+	unitHour:  recurrence.Hourly,
+	unitDay:   recurrence.Daily,
+	unitWeek:  recurrence.Weekly,
+	unitMonth: recurrence.Monthly,
+	unitYear:  recurrence.Yearly,
+}
+
+var (
+	workWeek = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	weekend  = []time.Weekday{time.Saturday, time.Sunday}
+)
+
+func weekdayOf(w string, abbrev bool) (time.Weekday, bool) {
+	if wd, ok := weekdayNames[strings.TrimSuffix(w, "s")]; ok {
+		return wd, true
+	}
+	if abbrev {
+		wd, ok := weekdayAbbrevs[w]
+		return wd, ok
+	}
+	return 0, false
+}
+
+func parseCount(w string) (int, bool) {
+	if n, ok := countWords[w]; ok {
+		return n, true
+	}
+	n, err := strconv.Atoi(w)
+	return n, err == nil && n > 0 && n < 1000
+}
+
+// parseOrdinal reads a day of the month: 1st, 22nd, third, or a plain
+// number when plain is true.
+func parseOrdinal(w string, plain bool) (int, bool) {
+	if n, ok := ordinalWords[w]; ok {
+		return n, true
+	}
+	digits := w
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		if strings.HasSuffix(w, suffix) {
+			digits = strings.TrimSuffix(w, suffix)
+			break
+		}
+	}
+	if digits == w && !plain {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	return n, err == nil && n >= 1 && n <= 31
+}
+
+// compactAmount reads "2h", "30min" and the like.
+func compactAmount(w string) (int, unit, bool) {
+	i := 0
+	for i < len(w) && w[i] >= '0' && w[i] <= '9' {
+		i++
+	}
+	if i == 0 || i == len(w) {
+		return 0, 0, false
+	}
+	n, err := strconv.Atoi(w[:i])
+	u, ok := unitNames[w[i:]]
+	return n, u, err == nil && ok && n > 0
+}
+
+// amount reads a count and unit at i, returning the number of tokens.
+func (p *parser) amount(i int) (int, unit, int) {
+	if n, u, ok := compactAmount(p.word(i)); ok {
+		return n, u, 1
+	}
+	n, ok := parseCount(p.word(i))
+	if !ok {
+		return 0, 0, 0
+	}
+	u, ok := unitNames[p.word(i+1)]
+	if !ok || len(p.word(i+1)) == 1 {
+		return 0, 0, 0
+	}
+	return n, u, 2
+}
+
+// ── Recurrence ──
+
+func isEvery(w string) bool {
+	return w == "every" || w == "each"
+}
+
+// matchRecurrence reads "every Friday", "every other week", "every 3
+// days", "every weekday", "daily" and the like, optionally introduced by
+// "of" ("the 1st of every month").
+func (p *parser) matchRecurrence(i int) int {
+	if p.d.rule != nil {
+		return 0
+	}
+	j := i
+	if p.word(j) == "of" && isEvery(p.word(j+1)) {
+		j++
+	}
+
+	switch p.word(j) {
+	case "daily", "nightly":
+		return p.setRule(i, j+1, recurrence.Daily, 1, nil)
+	case "weekly":
+		return p.setRule(i, j+1, recurrence.Weekly, 1, nil)
+	case "monthly":
+		return p.setRule(i, j+1, recurrence.Monthly, 1, nil)
+	case "yearly", "annually":
+		return p.setRule(i, j+1, recurrence.Yearly, 1, nil)
+	case "hourly":
+		return p.setRule(i, j+1, recurrence.Hourly, 1, nil)
+	case "on":
+		switch p.word(j + 1) {
+		case "weekdays":
+			return p.setRule(i, j+2, recurrence.Weekly, 1, workWeek)
+		case "weekends":
+			return p.setRule(i, j+2, recurrence.Weekly, 1, weekend)
+		}
+		return 0
+	}
+	if !isEvery(p.word(j)) {
+		return 0
+	}
+	j++
+
+	interval := 1
+	if p.word(j) == "other" {
+		interval = 2
+		j++
+	} else if n, ok := parseCount(p.word(j)); ok && n > 1 {
+		if _, ok := unitNames[p.word(j+1)]; ok {
+			interval = n
+			j++
+		}
+	}
+
+	w := p.word(j)
+	if u, ok := unitNames[w]; ok && len(w) > 1 {
+		freq, ok := unitFrequency[u]
+		if !ok {
+			return 0
+		}
+		return p.setRule(i, j+1, freq, interval, nil)
+	}
+	switch w {
+	case "weekday", "weekdays":
+		return p.setRule(i, j+1, recurrence.Weekly, interval, workWeek)
+	case "weekend", "weekends":
+		return p.setRule(i, j+1, recurrence.Weekly, interval, weekend)
+	}
+	if isPart(w) {
+		n := p.setRule(i, j+1, recurrence.Daily, interval, nil)
+		p.setPart(partOfDay[w])
+		return n
+	}
+
+	wd, ok := weekdayOf(w, true)
+	if !ok {
+		return 0
+	}
+	days := []time.Weekday{wd}
+	for {
+		k := j + 1
+		sep := p.toks[j].comma
+		if p.word(k) == "and" {
+			k++
+			sep = true
+		}
+		next, ok := weekdayOf(p.word(k), true)
+		if !sep || !ok {
+			break
+		}
+		days = append(days, next)
+		j = k
+	}
+	return p.setRule(i, j+1, recurrence.Weekly, interval, days)
+}
+
+func (p *parser) setRule(i, end int, freq recurrence.Frequency, interval int, days []time.Weekday) int {
+	rule := &recurrence.Rule{Freq: freq, Interval: interval, WeekStart: time.Monday}
+	for _, d := range days {
+		rule.ByDay = append(rule.ByDay, recurrence.Weekday{Day: d})
+	}
+	p.d.rule = rule
+	return p.take(i, end-i, KindRecurrence, 0.95)
+}
+
+// ── Relative Times ──
+
+// matchRelative reads "in 2 hours", "in half an hour", "3 days from now"
+// and "20 minutes later".
+func (p *parser) matchRelative(i int) int {
+	if p.d.hasRel {
+		return 0
+	}
+	if p.word(i) == "in" {
+		if p.word(i+1) == "half" && (p.word(i+2) == "an" || p.word(i+2) == "a") && p.word(i+3) == "hour" {
+			p.addRel(30, unitMinute)
+			return p.take(i, 4, KindTime, 1)
+		}
+		if n, u, k := p.amount(i + 1); k > 0 {
+			p.addRel(n, u)
+			return p.take(i, 1+k, KindTime, 1)
+		}
+		return 0
+	}
+
+	n, u, k := p.amount(i)
+	if k == 0 {
+		return 0
+	}
+	switch {
+	case p.word(i+k) == "from" && p.word(i+k+1) == "now":
+		p.addRel(n, u)
+		return p.take(i, k+2, KindTime, 1)
+	case p.word(i+k) == "later":
+		p.addRel(n, u)
+		return p.take(i, k+1, KindTime, 1)
+	}
+	return 0
+}
+
+func (p *parser) addRel(n int, u unit) {
+	p.d.hasRel = true
+	switch u {
+	case unitMinute:
+		p.d.rel += time.Duration(n) * time.Minute
+	case unitHour:
+		p.d.rel += time.Duration(n) * time.Hour
+	case unitDay:
+		p.d.relDays += n
+	case unitWeek:
+		p.d.relDays += 7 * n
+	case unitMonth:
+		p.d.relMonth += n
+	case unitYear:
+		p.d.relMonth += 12 * n
+	}
+}
+
+// ── Dates ──
+
+func (p *parser) today() time.Time {
+	y, m, d := p.now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, p.now.Location())
+}
+
+// matchDate reads today, tomorrow, weekdays, "next week", "next month",
+// "on the 1st", calendar dates and, for recurrences, "until <date>".
+func (p *parser) matchDate(i int) int {
+	d := &p.d
+	switch p.word(i) {
+	case "until", "till", "through":
+		if d.rule == nil || !d.until.IsZero() {
+			return 0
+		}
+		date, _, n := p.calendarDate(i + 1)
+		if n == 0 {
+			return 0
+		}
+		d.until = date.AddDate(0, 0, 1).Add(-time.Second)
+		return p.take(i, 1+n, KindDate, 0.9)
+	}
+
+	j := i
+	next := false
+	switch p.word(j) {
+	case "next":
+		next = true
+		j++
+	case "on", "by", "this", "coming":
+		j++
+	}
+	if p.word(j) == "the" && p.word(j+1) == "day" {
+		j++
+	}
+	w := p.word(j)
+	dated := d.hasDate || d.hasWeekday
+
+	switch {
+	case next && w == "week" && !dated && !d.nextWeek:
+		d.nextWeek = true
+		return p.take(i, j+1-i, KindDate, 0.9)
+	case next && w == "month" && !d.hasDate && d.monthOffset == 0:
+		d.monthOffset = 1
+		return p.take(i, j+1-i, KindDate, 0.9)
+	case next && w == "year" && !d.hasDate && d.monthOffset == 0:
+		d.monthOffset = 12
+		return p.take(i, j+1-i, KindDate, 0.85)
+	}
+
+	if wd, ok := weekdayOf(w, j > i); ok && !dated && !strings.HasSuffix(w, "days") {
+		d.hasWeekday, d.weekday, d.nextWeekday = true, wd, next
+		return p.take(i, j+1-i, KindDate, 0.95)
+	}
+	if next || dated {
+		return 0
+	}
+
+	switch w {
+	case "today", "tonight":
+		p.setDate(p.today(), true)
+		if w == "tonight" {
+			p.setPart(partOfDay["tonight"])
+		}
+		return p.take(i, j+1-i, KindDate, 1)
+	case "tomorrow", "tmrw", "tmr":
+		p.setDate(p.today().AddDate(0, 0, 1), true)
+		return p.take(i, j+1-i, KindDate, 1)
+	case "day":
+		if p.word(j+1) == "after" && p.word(j+2) == "tomorrow" {
+			p.setDate(p.today().AddDate(0, 0, 2), true)
+			return p.take(i, j+3-i, KindDate, 1)
+		}
+		return 0
+	}
+
+	if date, year, n := p.calendarDate(j); n > 0 {
+		p.setDate(date, year)
+		conf := 0.95
+		if !year {
+			conf = 0.9
+		}
+		return p.take(i, j+n-i, KindDate, conf)
+	}
+
+	// "the 1st", "the 15th of the month", "the 1st of next month"
+	if w == "the" && d.monthDay == 0 {
+		day, ok := parseOrdinal(p.word(j+1), false)
+		if !ok {
+			return 0
+		}
+		d.monthDay = day
+		end := j + 2
+		switch {
+		case p.word(end) == "of" && p.word(end+1) == "the" && p.word(end+2) == "month":
+			end += 3
+		case p.word(end) == "of" && p.word(end+1) == "next" && p.word(end+2) == "month" && d.monthOffset == 0:
+			d.monthOffset = 1
+			end += 3
+		}
+		return p.take(i, end-i, KindDate, 0.9)
+	}
+	return 0
+}
+
+func (p *parser) setDate(date time.Time, year bool) {
+	p.d.hasDate, p.d.date, p.d.year = true, date, year
+}
+
+// calendarDate reads 2026-03-05, "March 5", "March 5th 2027", "5 March"
+// and "the 5th of March" at i. It returns the date, whether the year was
+// written and the number of tokens read.
+func (p *parser) calendarDate(i int) (time.Time, bool, int) {
+	loc := p.now.Location()
+	if t, err := time.ParseInLocation("2006-01-02", p.word(i), loc); err == nil {
+		return t, true, 1
+	}
+
+	j := i
+	if p.word(j) == "the" {
+		j++
+	}
+	var (
+		month time.Month
+		day   int
+		ok    bool
+	)
+	if m, isMonth := monthNames[p.word(j)]; isMonth {
+		if day, ok = parseOrdinal(p.word(j+1), true); !ok {
+			return time.Time{}, false, 0
+		}
+		month = m
+		j += 2
+	} else {
+		if day, ok = parseOrdinal(p.word(j), true); !ok {
+			return time.Time{}, false, 0
+		}
+		k := j + 1
+		if p.word(k) == "of" {
+			k++
+		}
+		if month, ok = monthNames[p.word(k)]; !ok {
+			return time.Time{}, false, 0
+		}
+		j = k + 1
+	}
+
+	year, hasYear := p.now.Year(), false
+	if y, err := strconv.Atoi(p.word(j)); err == nil && len(p.word(j)) == 4 && y >= 1970 && y <= 2200 {
+		year, hasYear = y, true
+		j++
+	}
+	t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	if t.Day() != day {
+		return time.Time{}, false, 0
+	}
+	return t, hasYear, j - i
+}
+
+// ── Clock Times ──
+
+// matchClock reads "at 4pm", "16:30", "noon", "at 9", "in the morning",
+// "this evening" and, right after a day, a bare part of the day
+// ("tomorrow morning").
+func (p *parser) matchClock(i int) int {
+	j := i
+	switch p.word(j) {
+	case "at", "@", "around", "by":
+		j++
+	}
+	w := p.word(j)
+
+	// Parts of the day.
+	switch {
+	case p.word(i) == "in" && p.word(i+1) == "the" && isPart(p.word(i+2)):
+		p.setPart(partOfDay[p.word(i+2)])
+		return p.take(i, 3, KindTime, 0.8)
+	case p.word(i) == "this" && isPart(p.word(i+1)):
+		p.setDate(p.today(), true)
+		p.setPart(partOfDay[p.word(i+1)])
+		return p.take(i, 2, KindTime, 0.8)
+	case w == "night" && j > i, isPart(w) && j == i && i > 0 && p.used[i-1] && p.d.hasDay():
+		p.setPart(partOfDay[w])
+		return p.take(i, j+1-i, KindTime, 0.8)
+	case w == "noon" || w == "midday" || w == "midnight":
+		if _, ok := p.setClock(clock{hour: partOfDay[w]}, false, 0.95); !ok {
+			return 0
+		}
+		n := p.take(i, j+1-i, KindTime, 0.95)
+		p.clockSpan = len(p.spans) - 1
+		return n
+	}
+
+	c, ambiguous, n := p.clockAt(j, j > i)
+	if n == 0 {
+		return 0
+	}
+	conf := 0.95
+	if ambiguous {
+		conf = 0.6
+	}
+	conf, ok := p.setClock(c, ambiguous, conf)
+	if !ok {
+		return 0
+	}
+	n = p.take(i, j+n-i, KindTime, conf)
+	p.clockSpan = len(p.spans) - 1
+	return n
+}
+
+func isPart(w string) bool {
+	switch w {
+	case "morning", "afternoon", "evening", "night":
+		return true
+	}
+	return false
+}
+
+// clockAt reads a clock time at i: 4pm, 4:30 pm, 16:00, 9 o'clock, or a
+// bare hour when bare is set. Hours written without am/pm that could be
+// either are reported as ambiguous and read as the likelier one for a
+// reminder (1-6 in the afternoon, 7-11 in the morning).
+func (p *parser) clockAt(i int, bare bool) (clock, bool, int) {
+	w := p.word(i)
+	n := 1
+	suffix := ""
+	for _, s := range []string{"am", "pm", "a.m", "p.m"} {
+		if strings.HasSuffix(w, s) && len(w) > len(s) {
+			w, suffix = strings.TrimSuffix(w, s), s[:1]
+			break
+		}
+	}
+	if suffix == "" {
+		switch p.word(i + 1) {
+		case "am", "a.m":
+			suffix, n = "a", 2
+		case "pm", "p.m":
+			suffix, n = "p", 2
+		case "o'clock":
+			n = 2
+		}
+	}
+
+	hourText, minText, hasMin := strings.Cut(w, ":")
+	if !hasMin && suffix != "" {
+		hourText, minText, hasMin = strings.Cut(w, ".")
+	}
+	if suffix == "" && !hasMin && !bare && n == 1 {
+		return clock{}, false, 0
+	}
+	hour, err := strconv.Atoi(hourText)
+	if err != nil || len(hourText) > 2 {
+		return clock{}, false, 0
+	}
+	minute := 0
+	if hasMin {
+		if minute, err = strconv.Atoi(minText); err != nil || len(minText) != 2 || minute > 59 {
+			return clock{}, false, 0
+		}
+	}
+
+	switch {
+	case suffix != "":
+		if hour < 1 || hour > 12 {
+			return clock{}, false, 0
+		}
+		hour %= 12
+		if suffix == "p" {
+			hour += 12
+		}
+		return clock{hour, minute}, false, n
+	case hour > 23:
+		return clock{}, false, 0
+	case hour >= 13 || hour == 0 || (hasMin && len(hourText) == 2 && hourText[0] == '0'):
+		return clock{hour, minute}, false, n
+	case hour == 12:
+		return clock{12, minute}, false, n
+	case hour <= 6:
+		return clock{hour + 12, minute}, true, n
+	}
+	return clock{hour, minute}, true, n
+}
+
+// setClock records an explicit clock time and returns its confidence. A
+// part of the day already read ("tomorrow evening at 7") settles an
+// ambiguous hour.
+func (p *parser) setClock(c clock, ambiguous bool, conf float64) (float64, bool) {
+	d := &p.d
+	if d.hasClock && !d.fromPart {
+		return 0, false
+	}
+	if d.fromPart && ambiguous {
+		c.hour = settle(c.hour, d.clock.hour)
+		ambiguous, conf = false, 0.9
+	}
+	d.hasClock, d.clock, d.fromPart, d.ambiguous = true, c, false, ambiguous
+	return conf, true
+}
+
+// setPart records a part of the day, which only sets the clock when none
+// was given and otherwise settles an ambiguous one ("at 8 in the evening").
+func (p *parser) setPart(hour int) {
+	d := &p.d
+	switch {
+	case !d.hasClock:
+		d.hasClock, d.clock, d.fromPart = true, clock{hour: hour}, true
+	case d.ambiguous:
+		d.clock.hour = settle(d.clock.hour, hour)
+		d.ambiguous = false
+		p.spans[p.clockSpan].Confidence = 0.9
+	}
+}
+
+// settle moves a 12-hour clock hour to the half of the day partHour is in.
+func settle(hour, partHour int) int {
+	hour %= 12
+	if partHour >= 12 {
+		hour += 12
+	}
+	return hour
+}
@@ -0,0 +1,209 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Op is the comparison in a field term such as due:<7d.
+type Op string
+
+const (
+	OpEq  Op = ":"
+	OpLt  Op = "<"
+	OpLte Op = "<="
+	OpGt  Op = ">"
+	OpGte Op = ">="
+)
+
+// Term is one space-separated part of a query. Field is empty for free
+// text. Values holds the comma-separated alternatives of a field term, or
+// the single word or phrase of free text.
+type Term struct {
+	Field   string
+	Op      Op
+	Values  []string
+	Negated bool
+	Quoted  bool
+
+	// Pos and ValuePos are 1-based rune offsets of the term and its value.
+	Pos      int
+	ValuePos int
+}
+
+// Error is a syntax or value error in a query.
+type Error struct {
+	// Pos is the 1-based rune offset the error refers to.
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("query error at position %d: %s", e.Pos, e.Msg)
+}
+
+func errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Parse splits a query into terms:
+//
+//	query = term { " " term }
+//	term  = [ "-" ] ( field ":" [ "<" | "<=" | ">" | ">=" ] value | value )
+//	value = word | '"' text '"'
+//
+// A field value may list alternatives separated by commas. Inside quotes,
+// \" and \\ escape a quote and a backslash. Words that merely contain a
+// colon, such as 10:30, are free text when the part before the colon is not
+// a plain name.
+func Parse(q string) ([]Term, error) {
+	p := &parser{input: []rune(q)}
+	var terms []Term
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return terms, nil
+		}
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+}
+
+type parser struct {
+	input []rune
+	pos   int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *parser) term() (Term, error) {
+	t := Term{Pos: p.pos + 1, Op: OpEq}
+	if p.peek() == '-' && p.pos+1 < len(p.input) && !unicode.IsSpace(p.input[p.pos+1]) {
+		t.Negated = true
+		p.pos++
+	}
+
+	if p.peek() == '"' {
+		t.ValuePos = p.pos + 1
+		text, err := p.quoted()
+		if err != nil {
+			return t, err
+		}
+		t.Values, t.Quoted = []string{text}, true
+		return t, nil
+	}
+
+	if name, ok := p.fieldName(); ok {
+		t.Field = strings.ToLower(name)
+		t.Op = p.op()
+		t.ValuePos = p.pos + 1
+		switch {
+		case p.eof() || unicode.IsSpace(p.peek()):
+			return t, errorf(t.ValuePos, "missing value for %s", t.Field)
+		case p.peek() == '"':
+			text, err := p.quoted()
+			if err != nil {
+				return t, err
+			}
+			t.Values, t.Quoted = []string{text}, true
+		default:
+			values, err := p.list()
+			if err != nil {
+				return t, err
+			}
+			t.Values = values
+		}
+		return t, nil
+	}
+
+	t.ValuePos = p.pos + 1
+	t.Values = []string{p.word()}
+	return t, nil
+}
+
+// fieldName consumes "name:" when the input continues with one.
+func (p *parser) fieldName() (string, bool) {
+	end := p.pos
+	for end < len(p.input) && (unicode.IsLetter(p.input[end]) || p.input[end] == '_') {
+		end++
+	}
+	if end == p.pos || end >= len(p.input) || p.input[end] != ':' {
+		return "", false
+	}
+	name := string(p.input[p.pos:end])
+	p.pos = end + 1
+	return name, true
+}
+
+func (p *parser) op() Op {
+	for _, op := range []Op{OpLte, OpGte, OpLt, OpGt} {
+		if strings.HasPrefix(string(p.input[p.pos:]), string(op)) {
+			p.pos += len(op)
+			return op
+		}
+	}
+	return OpEq
+}
+
+func (p *parser) word() string {
+	start := p.pos
+	for !p.eof() && !unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+func (p *parser) list() ([]string, error) {
+	start := p.pos
+	var values []string
+	for _, v := range strings.Split(p.word(), ",") {
+		if v == "" {
+			return nil, errorf(start+1, "empty value in list")
+		}
+		start += len([]rune(v)) + 1
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func (p *parser) quoted() (string, error) {
+	open := p.pos + 1
+	p.pos++
+	var b strings.Builder
+	for !p.eof() {
+		r := p.peek()
+		p.pos++
+		switch {
+		case r == '"':
+			if !p.eof() && !unicode.IsSpace(p.peek()) {
+				return "", errorf(p.pos+1, "expected a space after the closing quote")
+			}
+			return b.String(), nil
+		case r == '\\' && !p.eof() && (p.peek() == '"' || p.peek() == '\\'):
+			b.WriteRune(p.peek())
+			p.pos++
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return "", errorf(open, "unterminated quote")
+}
@@ -0,0 +1,106 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Term
+	}{
+		{"empty", "  ", nil},
+		{
+			name: "field and free text",
+			in:   "status:pending launch",
+			want: []Term{
+				{Field: "status", Op: OpEq, Values: []string{"pending"}, Pos: 1, ValuePos: 8},
+				{Op: OpEq, Values: []string{"launch"}, Pos: 16, ValuePos: 16},
+			},
+		},
+		{
+			name: "comparison and alternatives",
+			in:   "Priority:>=high tag:a,b",
+			want: []Term{
+				{Field: "priority", Op: OpGte, Values: []string{"high"}, Pos: 1, ValuePos: 12},
+				{Field: "tag", Op: OpEq, Values: []string{"a", "b"}, Pos: 17, ValuePos: 21},
+			},
+		},
+		{
+			name: "negation",
+			in:   "-draft -status:done",
+			want: []Term{
+				{Op: OpEq, Values: []string{"draft"}, Negated: true, Pos: 1, ValuePos: 2},
+				{Field: "status", Op: OpEq, Values: []string{"done"}, Negated: true, Pos: 8, ValuePos: 16},
+			},
+		},
+		{
+			name: "phrase with escapes",
+			in:   `"say \"hi\" \\ now"`,
+			want: []Term{
+				{Op: OpEq, Values: []string{`say "hi" \ now`}, Quoted: true, Pos: 1, ValuePos: 1},
+			},
+		},
+		{
+			name: "quoted field value",
+			in:   `category:"home office"`,
+			want: []Term{
+				{Field: "category", Op: OpEq, Values: []string{"home office"}, Quoted: true, Pos: 1, ValuePos: 10},
+			},
+		},
+		{
+			name: "time is free text",
+			in:   "10:30",
+			want: []Term{{Op: OpEq, Values: []string{"10:30"}, Pos: 1, ValuePos: 1}},
+		},
+		{
+			name: "lone dash is free text",
+			in:   "a - b",
+			want: []Term{
+				{Op: OpEq, Values: []string{"a"}, Pos: 1, ValuePos: 1},
+				{Op: OpEq, Values: []string{"-"}, Pos: 3, ValuePos: 3},
+				{Op: OpEq, Values: []string{"b"}, Pos: 5, ValuePos: 5},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) =\n%+v\nwant\n%+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		pos  int
+	}{
+		{"missing value", "status: x", 8},
+		{"missing value at end", "due:<", 6},
+		{"empty list value", "tag:a,,b", 7},
+		{"unterminated quote", `x "open`, 3},
+		{"text after quote", `"a"b`, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.in)
+			var qerr *Error
+			if !errors.As(err, &qerr) {
+				t.Fatalf("Parse(%q) error = %v, want *Error", tt.in, err)
+			}
+			if qerr.Pos != tt.pos {
+				t.Errorf("Parse(%q) error at %d, want %d (%v)", tt.in, qerr.Pos, tt.pos, qerr)
+			}
+		})
+	}
+}
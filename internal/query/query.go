@@ -0,0 +1,312 @@
+// Package query implements the reminder search language, e.g.
+//
+//	status:pending priority:>=high tag:release due:<7d "launch" -draft
+//
+// Field terms are ANDed; comma-separated values within a term are ORed.
+// Free words and "phrases" become a text search.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// LookupKind names a field whose values live outside the reminder document
+// and must be resolved to reminder or category IDs by the caller.
+type LookupKind string
+
+const (
+	LookupTag      LookupKind = "tag"
+	LookupLabel    LookupKind = "label"
+	LookupCategory LookupKind = "category"
+	LookupAssignee LookupKind = "assignee"
+)
+
+// Lookup matches reminders related to any of Values. Tags and categories
+// may be given by name or ID; assignee "me" is already replaced by the
+// viewer.
+type Lookup struct {
+	Kind    LookupKind
+	Values  []string
+	Negated bool
+}
+
+// Env is what a query is compiled against.
+type Env struct {
+	Now time.Time
+	// Location sets day boundaries for today, 2024-05-01 and the like. UTC
+	// when nil.
+	Location *time.Location
+	// Viewer is the user "me" refers to.
+	Viewer string
+}
+
+// Query is a compiled query. A reminder matches when it satisfies every
+// condition and lookup and, if Text is set, the text search.
+type Query struct {
+	Conditions []bson.M
+	Text       string
+	Lookups    []Lookup
+}
+
+// Filter returns the conditions as a single filter, or an empty one.
+func (q *Query) Filter() bson.M {
+	switch len(q.Conditions) {
+	case 0:
+		return bson.M{}
+	case 1:
+		return q.Conditions[0]
+	}
+	return bson.M{"$and": q.Conditions}
+}
+
+var (
+	statuses = []string{
+		string(models.StatusPending), string(models.StatusTriggered), string(models.StatusCompleted),
+		string(models.StatusCancelled), string(models.StatusSnoozed), string(models.StatusAcknowledged),
+		string(models.StatusArchived),
+	}
+	types = []string{
+		string(models.ReminderTypeMessage), string(models.ReminderTypeTask), string(models.ReminderTypeCustom),
+	}
+	// priorities is in ascending order for priority:>high and the like.
+	priorities = []string{
+		string(models.PriorityLow), string(models.PriorityMedium), string(models.PriorityHigh), string(models.PriorityUrgent),
+	}
+)
+
+// Compile parses q and compiles it against env.
+func Compile(q string, env Env) (*Query, error) {
+	terms, err := Parse(q)
+	if err != nil {
+		return nil, err
+	}
+	if env.Location == nil {
+		env.Location = time.UTC
+	}
+
+	out := &Query{}
+	var text []string
+	for _, t := range terms {
+		if t.Field == "" {
+			text = append(text, textTerm(t))
+			continue
+		}
+
+		var cond bson.M
+		switch t.Field {
+		case "status":
+			cond, err = enumCondition(t, "status", statuses, false)
+		case "type":
+			cond, err = enumCondition(t, "type", types, false)
+		case "priority":
+			cond, err = enumCondition(t, "priority", priorities, true)
+		case "due":
+			cond, err = dateCondition(t, "remind_at", env)
+		case "created":
+			cond, err = dateCondition(t, "created_at", env)
+		case "tag", "label", "category", "assignee":
+			if t.Op != OpEq {
+				return nil, errorf(t.ValuePos, "%s does not support %s", t.Field, t.Op)
+			}
+			values := t.Values
+			if t.Field == "assignee" {
+				values = replaceMe(values, env.Viewer)
+			}
+			out.Lookups = append(out.Lookups, Lookup{Kind: LookupKind(t.Field), Values: values, Negated: t.Negated})
+			continue
+		default:
+			return nil, errorf(t.Pos, "unknown field %q (expected status, type, priority, tag, label, category, assignee, due or created)", t.Field)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if t.Negated {
+			cond = bson.M{"$nor": []bson.M{cond}}
+		}
+		out.Conditions = append(out.Conditions, cond)
+	}
+	out.Text = strings.Join(text, " ")
+	return out, nil
+}
+
+// textTerm writes free text back in the syntax $text understands.
+func textTerm(t Term) string {
+	s := t.Values[0]
+	if t.Quoted {
+		s = `"` + strings.ReplaceAll(s, `"`, "") + `"`
+	}
+	if t.Negated {
+		s = "-" + s
+	}
+	return s
+}
+
+func replaceMe(values []string, viewer string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		if strings.EqualFold(v, "me") {
+			v = viewer
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// enumCondition matches a field against a fixed set of values. ordered
+// allows comparisons by position in allowed.
+func enumCondition(t Term, field string, allowed []string, ordered bool) (bson.M, error) {
+	var matched []string
+	for _, v := range t.Values {
+		i := indexOf(allowed, strings.ToLower(v))
+		if i < 0 {
+			return nil, errorf(t.ValuePos, "invalid %s %q (expected one of %s)", t.Field, v, strings.Join(allowed, ", "))
+		}
+		if t.Op == OpEq {
+			matched = append(matched, allowed[i])
+			continue
+		}
+		if !ordered {
+			return nil, errorf(t.ValuePos, "%s does not support %s", t.Field, t.Op)
+		}
+		if len(t.Values) > 1 {
+			return nil, errorf(t.ValuePos, "%s%s takes a single value", t.Field, t.Op)
+		}
+		switch t.Op {
+		case OpLt:
+			matched = allowed[:i]
+		case OpLte:
+			matched = allowed[:i+1]
+		case OpGt:
+			matched = allowed[i+1:]
+		case OpGte:
+			matched = allowed[i:]
+		}
+	}
+	if len(matched) == 1 {
+		return bson.M{field: matched[0]}, nil
+	}
+	return bson.M{field: bson.M{"$in": matched}}, nil
+}
+
+func indexOf(values []string, v string) int {
+	for i, candidate := range values {
+		if candidate == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// ── Dates ──
+
+// dateCondition compares a date field. A value is a span: a day for
+// today, tomorrow, yesterday and 2006-01-02, or an instant for now, an
+// RFC 3339 time and offsets from now such as 7d, -12h or 2w. field:value
+// matches within the span, or between now and an offset.
+func dateCondition(t Term, field string, env Env) (bson.M, error) {
+	if len(t.Values) > 1 {
+		return nil, errorf(t.ValuePos, "%s takes a single value", t.Field)
+	}
+	start, end, relative, err := parseDate(t.Values[0], env)
+	if err != nil {
+		return nil, errorf(t.ValuePos, "%s: %v", t.Field, err)
+	}
+	instant := start.Equal(end)
+
+	var cmp bson.M
+	switch t.Op {
+	case OpLt:
+		cmp = bson.M{"$lt": start}
+	case OpLte:
+		if instant {
+			cmp = bson.M{"$lte": start}
+		} else {
+			cmp = bson.M{"$lt": end}
+		}
+	case OpGt:
+		if instant {
+			cmp = bson.M{"$gt": start}
+		} else {
+			cmp = bson.M{"$gte": end}
+		}
+	case OpGte:
+		cmp = bson.M{"$gte": start}
+	default:
+		switch {
+		case relative:
+			from, to := env.Now, start
+			if to.Before(from) {
+				from, to = to, from
+			}
+			cmp = bson.M{"$gte": from, "$lte": to}
+		case instant:
+			cmp = bson.M{"$eq": start}
+		default:
+			cmp = bson.M{"$gte": start, "$lt": end}
+		}
+	}
+	return bson.M{field: cmp}, nil
+}
+
+// parseDate returns the span a date value covers. end equals start for an
+// instant.
+func parseDate(v string, env Env) (start, end time.Time, relative bool, err error) {
+	now := env.Now.In(env.Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, env.Location)
+	day := func(d time.Time) (time.Time, time.Time, bool, error) {
+		return d, d.AddDate(0, 0, 1), false, nil
+	}
+
+	switch strings.ToLower(v) {
+	case "now":
+		return env.Now, env.Now, false, nil
+	case "today":
+		return day(today)
+	case "tomorrow":
+		return day(today.AddDate(0, 0, 1))
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1))
+	}
+
+	if d, err := time.ParseInLocation("2006-01-02", v, env.Location); err == nil {
+		return day(d)
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, t, false, nil
+	}
+	if offset, ok := parseOffset(v); ok {
+		t := env.Now.Add(offset)
+		return t, t, true, nil
+	}
+	return time.Time{}, time.Time{}, false, fmt.Errorf("invalid date %q (expected today, tomorrow, yesterday, now, 2006-01-02, an RFC 3339 time or an offset like 7d)", v)
+}
+
+// parseOffset reads [+|-]N followed by h, d or w.
+func parseOffset(v string) (time.Duration, bool) {
+	if len(v) < 2 {
+		return 0, false
+	}
+	var unit time.Duration
+	switch v[len(v)-1] {
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, false
+	}
+	n, err := strconv.Atoi(v[:len(v)-1])
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
@@ -0,0 +1,177 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCompile(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+	today := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	env := Env{Now: now, Viewer: "u1"}
+
+	tests := []struct {
+		name    string
+		in      string
+		cond    []bson.M
+		text    string
+		lookups []Lookup
+	}{
+		{name: "empty", in: ""},
+		{
+			name: "status",
+			in:   "status:Pending",
+			cond: []bson.M{{"status": "pending"}},
+		},
+		{
+			name: "status alternatives",
+			in:   "status:pending,snoozed",
+			cond: []bson.M{{"status": bson.M{"$in": []string{"pending", "snoozed"}}}},
+		},
+		{
+			name: "priority at least high",
+			in:   "priority:>=high",
+			cond: []bson.M{{"priority": bson.M{"$in": []string{"high", "urgent"}}}},
+		},
+		{
+			name: "priority below medium",
+			in:   "priority:<medium",
+			cond: []bson.M{{"priority": "low"}},
+		},
+		{
+			name: "negated type",
+			in:   "-type:task",
+			cond: []bson.M{{"$nor": []bson.M{{"type": "task"}}}},
+		},
+		{
+			name: "due today",
+			in:   "due:today",
+			cond: []bson.M{{"remind_at": bson.M{"$gte": today, "$lt": today.AddDate(0, 0, 1)}}},
+		},
+		{
+			name: "due within a week",
+			in:   "due:7d",
+			cond: []bson.M{{"remind_at": bson.M{"$gte": now, "$lte": now.Add(7 * 24 * time.Hour)}}},
+		},
+		{
+			name: "due in the last day",
+			in:   "due:-1d",
+			cond: []bson.M{{"remind_at": bson.M{"$gte": now.Add(-24 * time.Hour), "$lte": now}}},
+		},
+		{
+			name: "due before a week from now",
+			in:   "due:<1w",
+			cond: []bson.M{{"remind_at": bson.M{"$lt": now.Add(7 * 24 * time.Hour)}}},
+		},
+		{
+			name: "created after a day",
+			in:   "created:>2025-03-01",
+			cond: []bson.M{{"created_at": bson.M{"$gte": time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)}}},
+		},
+		{
+			name: "created up to a day",
+			in:   "created:<=2025-03-01",
+			cond: []bson.M{{"created_at": bson.M{"$lt": time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)}}},
+		},
+		{
+			name: "due at an instant",
+			in:   "due:2025-03-10T12:00:00Z",
+			cond: []bson.M{{"remind_at": bson.M{"$eq": time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)}}},
+		},
+		{
+			name: "free text and phrases",
+			in:   `launch "release notes" -draft`,
+			text: `launch "release notes" -draft`,
+		},
+		{
+			name:    "lookups",
+			in:      "tag:a,b -assignee:me",
+			lookups: []Lookup{{Kind: LookupTag, Values: []string{"a", "b"}}, {Kind: LookupAssignee, Values: []string{"u1"}, Negated: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Compile(tt.in, env)
+			if err != nil {
+				t.Fatalf("Compile(%q): %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(q.Conditions, tt.cond) {
+				t.Errorf("Compile(%q).Conditions = %v, want %v", tt.in, q.Conditions, tt.cond)
+			}
+			if q.Text != tt.text {
+				t.Errorf("Compile(%q).Text = %q, want %q", tt.in, q.Text, tt.text)
+			}
+			if !reflect.DeepEqual(q.Lookups, tt.lookups) {
+				t.Errorf("Compile(%q).Lookups = %+v, want %+v", tt.in, q.Lookups, tt.lookups)
+			}
+		})
+	}
+}
+
+func TestCompileLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone unavailable: %v", err)
+	}
+	// 20:00 UTC on the 10th is already the 11th in Tokyo.
+	env := Env{Now: time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC), Location: tokyo}
+	q, err := Compile("due:today", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 3, 11, 0, 0, 0, 0, tokyo)
+	want := bson.M{"remind_at": bson.M{"$gte": start, "$lt": start.AddDate(0, 0, 1)}}
+	if !reflect.DeepEqual(q.Filter(), want) {
+		t.Errorf("Filter() = %v, want %v", q.Filter(), want)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"unknown field", "owner:bob"},
+		{"unknown status", "status:done"},
+		{"unordered comparison", "status:>pending"},
+		{"comparison with alternatives", "priority:>low,high"},
+		{"lookup comparison", "tag:>a"},
+		{"bad date", "due:someday"},
+		{"date alternatives", "due:today,tomorrow"},
+		{"syntax", `"open`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.in, Env{Now: time.Now()})
+			var qerr *Error
+			if !errors.As(err, &qerr) {
+				t.Errorf("Compile(%q) error = %v, want *Error", tt.in, err)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	a, b := bson.M{"a": 1}, bson.M{"b": 2}
+	tests := []struct {
+		name string
+		cond []bson.M
+		want bson.M
+	}{
+		{"none", nil, bson.M{}},
+		{"one", []bson.M{a}, a},
+		{"several", []bson.M{a, b}, bson.M{"$and": []bson.M{a, b}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &Query{Conditions: tt.cond}
+			if got := q.Filter(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+// Package recurrence parses and expands RFC 5545 recurrence rules.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule is wrapped by every parse and validation error so callers
+// can tell bad input apart from infrastructure failures.
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+type Frequency int
+
+const (
+	Yearly Frequency = iota
+	Monthly
+	Weekly
+	Daily
+	Hourly
+)
+
+var frequencyNames = map[Frequency]string{
+	Yearly:  "YEARLY",
+	Monthly: "MONTHLY",
+	Weekly:  "WEEKLY",
+	Daily:   "DAILY",
+	Hourly:  "HOURLY",
+}
+
+func (f Frequency) String() string {
+	return frequencyNames[f]
+}
+
+var weekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Weekday is a BYDAY entry. N selects the nth weekday of the month or year
+// (negative counts from the end); zero means every such weekday.
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+func (w Weekday) String() string {
+	if w.N == 0 {
+		return weekdayCodes[w.Day]
+	}
+	return strconv.Itoa(w.N) + weekdayCodes[w.Day]
+}
+
+// Rule is a parsed RRULE. The zero values of Count and Until mean the
+// series does not end.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	Count    int
+	Until    time.Time
+	// UntilLocal is set when UNTIL had no zone; it is then read as wall-clock
+	// time in the zone of the schedule's start.
+	UntilLocal bool
+
+	ByDay      []Weekday
+	ByMonthDay []int
+	ByMonth    []int
+	ByHour     []int
+	ByMinute   []int
+	BySecond   []int
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+// Parse reads an RRULE value such as "FREQ=MONTHLY;BYDAY=-1FR". A leading
+// "RRULE:" is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	if name, value, ok := strings.Cut(s, ":"); ok && strings.EqualFold(name, "RRULE") {
+		s = value
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	hasFreq := false
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			r.Freq, err = parseFrequency(value)
+			hasFreq = err == nil
+		case "INTERVAL":
+			r.Interval, err = parseInt(name, value, 1, 1<<16)
+		case "COUNT":
+			r.Count, err = parseInt(name, value, 1, 1<<20)
+		case "UNTIL":
+			r.Until, r.UntilLocal, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseWeekdays(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseIntList(name, value, -31, 31)
+		case "BYMONTH":
+			r.ByMonth, err = parseIntList(name, value, 1, 12)
+		case "BYHOUR":
+			r.ByHour, err = parseIntList(name, value, 0, 23)
+		case "BYMINUTE":
+			r.ByMinute, err = parseIntList(name, value, 0, 59)
+		case "BYSECOND":
+			r.BySecond, err = parseIntList(name, value, 0, 59)
+		case "BYSETPOS":
+			r.BySetPos, err = parseIntList(name, value, -366, 366)
+		case "WKST":
+			var wd Weekday
+			wd, err = parseWeekday(value)
+			r.WeekStart = wd.Day
+		default:
+			err = fmt.Errorf("%w: unsupported part %q", ErrInvalidRule, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !hasFreq {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRule)
+	}
+	return r, nil
+}
+
+// FromPattern builds a rule from the legacy pattern/interval fields. Days of
+// the week use time.Weekday numbering (0 is Sunday).
+func FromPattern(pattern string, interval int, daysOfWeek, daysOfMonth, months []int) (*Rule, error) {
+	r := &Rule{Interval: interval, WeekStart: time.Monday}
+	if r.Interval < 1 {
+		r.Interval = 1
+	}
+
+	switch strings.ToLower(pattern) {
+	case "yearly":
+		r.Freq = Yearly
+	case "monthly":
+		r.Freq = Monthly
+	case "weekly":
+		r.Freq = Weekly
+	case "hourly":
+		r.Freq = Hourly
+	default:
+		r.Freq = Daily
+	}
+
+	for _, d := range daysOfWeek {
+		if d < 0 || d > 6 {
+			return nil, fmt.Errorf("%w: day of week %d out of range", ErrInvalidRule, d)
+		}
+		r.ByDay = append(r.ByDay, Weekday{Day: time.Weekday(d)})
+	}
+	for _, d := range daysOfMonth {
+		if d == 0 || d < -31 || d > 31 {
+			return nil, fmt.Errorf("%w: day of month %d out of range", ErrInvalidRule, d)
+		}
+		r.ByMonthDay = append(r.ByMonthDay, d)
+	}
+	for _, m := range months {
+		if m < 1 || m > 12 {
+			return nil, fmt.Errorf("%w: month %d out of range", ErrInvalidRule, m)
+		}
+		r.ByMonth = append(r.ByMonth, m)
+	}
+	return r, nil
+}
+
+// LoadLocation resolves an IANA zone name. An empty name means UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidRule, name)
+	}
+	return loc, nil
+}
+
+// String renders the rule in canonical RRULE form, without the "RRULE:"
+// prefix.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq.String()}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.UntilLocal {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = d.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByHour) > 0 {
+		parts = append(parts, "BYHOUR="+joinInts(r.ByHour))
+	}
+	if len(r.ByMinute) > 0 {
+		parts = append(parts, "BYMINUTE="+joinInts(r.ByMinute))
+	}
+	if len(r.BySecond) > 0 {
+		parts = append(parts, "BYSECOND="+joinInts(r.BySecond))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCodes[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+func parseFrequency(value string) (Frequency, error) {
+	for f, name := range frequencyNames {
+		if strings.EqualFold(value, name) {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRule, value)
+}
+
+func parseUntil(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse("20060102T150405", value); err == nil {
+		return t, true, nil
+	}
+	// A date-only UNTIL includes the whole day.
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%w: malformed UNTIL %q", ErrInvalidRule, value)
+}
+
+func parseWeekdays(value string) ([]Weekday, error) {
+	var days []Weekday
+	for _, item := range strings.Split(value, ",") {
+		wd, err := parseWeekday(item)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, wd)
+	}
+	return days, nil
+}
+
+func parseWeekday(value string) (Weekday, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return Weekday{}, fmt.Errorf("%w: malformed weekday %q", ErrInvalidRule, value)
+	}
+
+	code := value[len(value)-2:]
+	wd := Weekday{Day: -1}
+	for i, c := range weekdayCodes {
+		if c == code {
+			wd.Day = time.Weekday(i)
+		}
+	}
+	if wd.Day < 0 {
+		return Weekday{}, fmt.Errorf("%w: malformed weekday %q", ErrInvalidRule, value)
+	}
+
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return Weekday{}, fmt.Errorf("%w: malformed weekday %q", ErrInvalidRule, value)
+		}
+		wd.N = n
+	}
+	return wd, nil
+}
+
+func parseInt(name, value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%w: %s value %q out of range", ErrInvalidRule, strings.ToUpper(name), value)
+	}
+	return n, nil
+}
+
+// parseIntList parses a comma-separated list. Zero is never a valid entry
+// for parts that allow negative values.
+func parseIntList(name, value string, min, max int) ([]int, error) {
+	var out []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := parseInt(name, item, min, max)
+		if err != nil {
+			return nil, err
+		}
+		if min < 0 && n == 0 {
+			return nil, fmt.Errorf("%w: %s cannot be 0", ErrInvalidRule, strings.ToUpper(name))
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func joinInts(values []int) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, ",")
+}
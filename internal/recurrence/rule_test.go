@@ -0,0 +1,117 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		want  string
+		local bool
+	}{
+		{"daily", "FREQ=DAILY", "FREQ=DAILY", false},
+		{"prefix and case", "rrule:freq=weekly;byday=mo,we", "FREQ=WEEKLY;BYDAY=MO,WE", false},
+		{"interval one dropped", "FREQ=DAILY;INTERVAL=1", "FREQ=DAILY", false},
+		{"ordinal weekday", "FREQ=MONTHLY;BYDAY=-1FR", "FREQ=MONTHLY;BYDAY=-1FR", false},
+		{"count", "FREQ=WEEKLY;INTERVAL=2;COUNT=10", "FREQ=WEEKLY;INTERVAL=2;COUNT=10", false},
+		{"utc until", "FREQ=DAILY;UNTIL=20250301T090000Z", "FREQ=DAILY;UNTIL=20250301T090000Z", false},
+		{"local until", "FREQ=DAILY;UNTIL=20250301T090000", "FREQ=DAILY;UNTIL=20250301T090000", true},
+		{"date until covers the day", "FREQ=DAILY;UNTIL=20250301", "FREQ=DAILY;UNTIL=20250301T235959", true},
+		{"week start", "FREQ=WEEKLY;WKST=SU", "FREQ=WEEKLY;WKST=SU", false},
+		{"set position", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.in, err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+			}
+			if r.UntilLocal != tt.local {
+				t.Errorf("Parse(%q).UntilLocal = %v, want %v", tt.in, r.UntilLocal, tt.local)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"empty", ""},
+		{"no freq", "INTERVAL=2"},
+		{"unknown freq", "FREQ=SECONDLY"},
+		{"malformed part", "FREQ=DAILY;COUNT"},
+		{"unsupported part", "FREQ=DAILY;BYWEEKNO=1"},
+		{"zero interval", "FREQ=DAILY;INTERVAL=0"},
+		{"count and until", "FREQ=DAILY;COUNT=2;UNTIL=20250301T090000Z"},
+		{"bad until", "FREQ=DAILY;UNTIL=tomorrow"},
+		{"bad weekday", "FREQ=WEEKLY;BYDAY=XX"},
+		{"zero ordinal", "FREQ=MONTHLY;BYDAY=0MO"},
+		{"zero month day", "FREQ=MONTHLY;BYMONTHDAY=0"},
+		{"month out of range", "FREQ=YEARLY;BYMONTH=13"},
+		{"hour out of range", "FREQ=DAILY;BYHOUR=24"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.in); !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidRule", tt.in, err)
+			}
+		})
+	}
+}
+
+func TestFromPattern(t *testing.T) {
+	tests := []struct {
+		name        string
+		pattern     string
+		interval    int
+		daysOfWeek  []int
+		daysOfMonth []int
+		months      []int
+		want        string
+		wantErr     bool
+	}{
+		{name: "daily default", pattern: "", want: "FREQ=DAILY"},
+		{name: "weekly days", pattern: "weekly", interval: 2, daysOfWeek: []int{1, 3}, want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{name: "monthly last day", pattern: "Monthly", daysOfMonth: []int{-1}, want: "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{name: "yearly months", pattern: "yearly", months: []int{1, 7}, want: "FREQ=YEARLY;BYMONTH=1,7"},
+		{name: "negative interval", pattern: "hourly", interval: -3, want: "FREQ=HOURLY"},
+		{name: "bad weekday", pattern: "weekly", daysOfWeek: []int{7}, wantErr: true},
+		{name: "bad month day", pattern: "monthly", daysOfMonth: []int{0}, wantErr: true},
+		{name: "bad month", pattern: "yearly", months: []int{13}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := FromPattern(tt.pattern, tt.interval, tt.daysOfWeek, tt.daysOfMonth, tt.months)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRule) {
+					t.Fatalf("FromPattern error = %v, want ErrInvalidRule", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromPattern: %v", err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("FromPattern().String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	if loc, err := LoadLocation(""); err != nil || loc != time.UTC {
+		t.Errorf(`LoadLocation("") = %v, %v, want UTC`, loc, err)
+	}
+	if _, err := LoadLocation("Mars/Olympus_Mons"); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("LoadLocation(unknown) error = %v, want ErrInvalidRule", err)
+	}
+}
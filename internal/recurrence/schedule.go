@@ -0,0 +1,407 @@
+package recurrence
+
+import (
+	"sort"
+	"time"
+)
+
+// maxEmptyPeriods bounds the search for rules that can never match, such as
+// the 30th of February.
+const maxEmptyPeriods = 5000
+
+// Schedule expands a rule from a start time (DTSTART). Occurrences are built
+// from wall-clock fields in the start's location, so a 09:00 series stays at
+// 09:00 across DST changes.
+type Schedule struct {
+	rule    *Rule
+	start   time.Time
+	until   time.Time
+	exdates map[int64]struct{}
+
+	byMonth    []int
+	byMonthDay []int
+	byDay      []Weekday
+	hours      []int
+	minutes    []int
+	seconds    []int
+}
+
+// NewSchedule returns the expansion of rule starting at start. The start
+// itself is always the first occurrence, as in RFC 5545.
+func NewSchedule(rule *Rule, start time.Time, exdates []time.Time) *Schedule {
+	start = start.Truncate(time.Second)
+	loc := start.Location()
+
+	s := &Schedule{
+		rule:       rule,
+		start:      start,
+		until:      rule.Until,
+		exdates:    make(map[int64]struct{}, len(exdates)),
+		byMonth:    rule.ByMonth,
+		byMonthDay: rule.ByMonthDay,
+		byDay:      rule.ByDay,
+		hours:      rule.ByHour,
+		minutes:    rule.ByMinute,
+		seconds:    rule.BySecond,
+	}
+	if rule.UntilLocal && !s.until.IsZero() {
+		u := s.until
+		s.until = time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, loc)
+	}
+	for _, t := range exdates {
+		s.exdates[t.Unix()] = struct{}{}
+	}
+
+	// Parts the rule leaves out are taken from the start.
+	switch rule.Freq {
+	case Yearly:
+		if len(s.byMonth) == 0 && len(s.byMonthDay) == 0 && len(s.byDay) == 0 {
+			s.byMonth = []int{int(start.Month())}
+		}
+		if len(s.byMonthDay) == 0 && len(s.byDay) == 0 {
+			s.byMonthDay = []int{start.Day()}
+		}
+	case Monthly:
+		if len(s.byMonthDay) == 0 && len(s.byDay) == 0 {
+			s.byMonthDay = []int{start.Day()}
+		}
+	case Weekly:
+		if len(s.byDay) == 0 {
+			s.byDay = []Weekday{{Day: start.Weekday()}}
+		}
+	}
+	if len(s.hours) == 0 && rule.Freq != Hourly {
+		s.hours = []int{start.Hour()}
+	}
+	if len(s.minutes) == 0 {
+		s.minutes = []int{start.Minute()}
+	}
+	if len(s.seconds) == 0 {
+		s.seconds = []int{start.Second()}
+	}
+	return s
+}
+
+// Start returns the first occurrence of the series.
+func (s *Schedule) Start() time.Time {
+	return s.start
+}
+
+// Next returns the first occurrence strictly after t. The second result is
+// false once the series has ended.
+func (s *Schedule) Next(t time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	s.iterate(t, func(occ time.Time) bool {
+		if occ.After(t) {
+			next, found = occ, true
+			return false
+		}
+		return true
+	})
+	return next, found
+}
+
+// Matches reports whether t is produced by the rule itself. Unlike Next it
+// does not treat the start as an occurrence unless the rule generates it.
+func (s *Schedule) Matches(t time.Time) bool {
+	if _, excluded := s.exdates[t.Unix()]; excluded {
+		return false
+	}
+	if !s.until.IsZero() && t.After(s.until) {
+		return false
+	}
+	for k := s.periodIndex(t); ; k++ {
+		period := s.period(k)
+		if period.After(t) {
+			return false
+		}
+		for _, occ := range s.expand(period) {
+			if occ.Equal(t) {
+				return true
+			}
+		}
+	}
+}
+
+// Between returns up to limit occurrences in [from, to).
+func (s *Schedule) Between(from, to time.Time, limit int) []time.Time {
+	var out []time.Time
+	s.iterate(from, func(occ time.Time) bool {
+		if !occ.Before(to) {
+			return false
+		}
+		if !occ.Before(from) {
+			out = append(out, occ)
+		}
+		return limit <= 0 || len(out) < limit
+	})
+	return out
+}
+
+// iterate yields occurrences in order until yield returns false or the
+// series ends. Rules without COUNT skip straight to the period around from;
+// COUNT has to be tallied from the start.
+func (s *Schedule) iterate(from time.Time, yield func(time.Time) bool) {
+	k := 0
+	if s.rule.Count == 0 {
+		k = s.periodIndex(from)
+	}
+
+	emitted := 0
+	emit := func(t time.Time) (stop bool) {
+		if !s.until.IsZero() && t.After(s.until) {
+			return true
+		}
+		if s.rule.Count > 0 {
+			if emitted >= s.rule.Count {
+				return true
+			}
+			emitted++
+		}
+		if _, excluded := s.exdates[t.Unix()]; excluded {
+			return false
+		}
+		return !yield(t)
+	}
+
+	if k == 0 && emit(s.start) {
+		return
+	}
+
+	for empty := 0; empty < maxEmptyPeriods; k++ {
+		period := s.period(k)
+		if !s.until.IsZero() && period.After(s.until) {
+			return
+		}
+
+		candidates := s.expand(period)
+		if len(candidates) == 0 {
+			empty++
+			continue
+		}
+		empty = 0
+
+		for _, t := range candidates {
+			if !t.After(s.start) {
+				continue
+			}
+			if emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// period returns the start of the k-th interval of the series.
+func (s *Schedule) period(k int) time.Time {
+	st := s.start
+	loc := st.Location()
+	n := k * s.rule.Interval
+
+	switch s.rule.Freq {
+	case Yearly:
+		return time.Date(st.Year()+n, time.January, 1, 0, 0, 0, 0, loc)
+	case Monthly:
+		return time.Date(st.Year(), st.Month()+time.Month(n), 1, 0, 0, 0, 0, loc)
+	case Weekly:
+		offset := (int(st.Weekday()) - int(s.rule.WeekStart) + 7) % 7
+		return time.Date(st.Year(), st.Month(), st.Day()-offset+7*n, 0, 0, 0, 0, loc)
+	case Daily:
+		return time.Date(st.Year(), st.Month(), st.Day()+n, 0, 0, 0, 0, loc)
+	default:
+		hour := time.Date(st.Year(), st.Month(), st.Day(), st.Hour(), 0, 0, 0, loc)
+		return hour.Add(time.Duration(n) * time.Hour)
+	}
+}
+
+// periodIndex returns a period index at or just before the one containing t.
+func (s *Schedule) periodIndex(t time.Time) int {
+	if !t.After(s.start) {
+		return 0
+	}
+	st := s.start
+	t = t.In(st.Location())
+
+	var units int
+	switch s.rule.Freq {
+	case Yearly:
+		units = t.Year() - st.Year()
+	case Monthly:
+		units = (t.Year()-st.Year())*12 + int(t.Month()-st.Month())
+	case Weekly:
+		units = civilDays(st, t) / 7
+	case Daily:
+		units = civilDays(st, t)
+	default:
+		units = int(t.Sub(st) / time.Hour)
+	}
+
+	k := units/s.rule.Interval - 1
+	if k < 0 {
+		return 0
+	}
+	return k
+}
+
+// expand returns the sorted occurrences that fall in the period starting at p.
+func (s *Schedule) expand(p time.Time) []time.Time {
+	loc := s.start.Location()
+
+	var days []time.Time
+	switch s.rule.Freq {
+	case Yearly:
+		days = daysFrom(p, daysIn(p.Year(), 0))
+	case Monthly:
+		days = daysFrom(p, daysIn(p.Year(), p.Month()))
+	case Weekly:
+		days = daysFrom(p, 7)
+	default:
+		days = daysFrom(p, 1)
+	}
+
+	var out []time.Time
+	for _, d := range days {
+		if !s.matchDay(d) {
+			continue
+		}
+		if s.rule.Freq == Hourly {
+			if len(s.hours) > 0 && !containsInt(s.hours, p.Hour()) {
+				continue
+			}
+			for _, m := range s.minutes {
+				for _, sec := range s.seconds {
+					out = append(out, time.Date(d.Year(), d.Month(), d.Day(), p.Hour(), m, sec, 0, loc))
+				}
+			}
+			continue
+		}
+		for _, h := range s.hours {
+			for _, m := range s.minutes {
+				for _, sec := range s.seconds {
+					out = append(out, time.Date(d.Year(), d.Month(), d.Day(), h, m, sec, 0, loc))
+				}
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	out = dedupe(out)
+	if len(s.rule.BySetPos) > 0 {
+		out = selectPositions(out, s.rule.BySetPos)
+	}
+	return out
+}
+
+// matchDay applies BYMONTH, BYMONTHDAY and BYDAY to a civil date.
+func (s *Schedule) matchDay(d time.Time) bool {
+	if len(s.byMonth) > 0 && !containsInt(s.byMonth, int(d.Month())) {
+		return false
+	}
+
+	monthLen := daysIn(d.Year(), d.Month())
+	if len(s.byMonthDay) > 0 {
+		ok := false
+		for _, md := range s.byMonthDay {
+			if md == d.Day() || (md < 0 && monthLen+md+1 == d.Day()) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if len(s.byDay) == 0 {
+		return true
+	}
+
+	// Ordinals count within the month for MONTHLY (or YEARLY with BYMONTH)
+	// and within the year for plain YEARLY. Other frequencies ignore them.
+	var pos, fromEnd int
+	switch {
+	case s.rule.Freq == Monthly || (s.rule.Freq == Yearly && len(s.rule.ByMonth) > 0):
+		pos = (d.Day()-1)/7 + 1
+		fromEnd = (monthLen-d.Day())/7 + 1
+	case s.rule.Freq == Yearly:
+		yearLen := daysIn(d.Year(), 0)
+		pos = (d.YearDay()-1)/7 + 1
+		fromEnd = (yearLen-d.YearDay())/7 + 1
+	}
+
+	for _, wd := range s.byDay {
+		if wd.Day != d.Weekday() {
+			continue
+		}
+		if wd.N == 0 || pos == 0 {
+			return true
+		}
+		if (wd.N > 0 && wd.N == pos) || (wd.N < 0 && -wd.N == fromEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+// daysFrom returns n consecutive civil dates starting at p, as UTC midnights.
+func daysFrom(p time.Time, n int) []time.Time {
+	days := make([]time.Time, n)
+	for i := range days {
+		days[i] = time.Date(p.Year(), p.Month(), p.Day()+i, 0, 0, 0, 0, time.UTC)
+	}
+	return days
+}
+
+// daysIn returns the length of a month, or of the year when month is 0.
+func daysIn(year int, month time.Month) int {
+	if month == 0 {
+		return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	}
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func civilDays(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func selectPositions(set []time.Time, positions []int) []time.Time {
+	var out []time.Time
+	for _, pos := range positions {
+		i := pos - 1
+		if pos < 0 {
+			i = len(set) + pos
+		}
+		if i >= 0 && i < len(set) {
+			out = append(out, set[i])
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return dedupe(out)
+}
+
+// dedupe drops repeats from a sorted slice. They appear when a DST gap
+// normalizes two wall-clock times to the same instant.
+func dedupe(sorted []time.Time) []time.Time {
+	if len(sorted) < 2 {
+		return sorted
+	}
+	out := sorted[:1]
+	for _, t := range sorted[1:] {
+		if !t.Equal(out[len(out)-1]) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+func formatTimes(ts []time.Time) []string {
+	out := make([]string, len(ts))
+	for i, ts := range ts {
+		out[i] = ts.Format(time.RFC3339)
+	}
+	return out
+}
+
+func TestScheduleBetween(t *testing.T) {
+	utc := time.UTC
+	tests := []struct {
+		name    string
+		rule    string
+		start   time.Time
+		exdates []time.Time
+		from    time.Time
+		to      time.Time
+		limit   int
+		want    []string
+	}{
+		{
+			name:  "daily",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			from:  time.Date(2025, 1, 1, 0, 0, 0, 0, utc),
+			to:    time.Date(2025, 1, 4, 0, 0, 0, 0, utc),
+			want:  []string{"2025-01-01T09:00:00Z", "2025-01-02T09:00:00Z", "2025-01-03T09:00:00Z"},
+		},
+		{
+			name:  "weekly by day with interval",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+			start: time.Date(2025, 1, 6, 8, 30, 0, 0, utc), // Monday
+			from:  time.Date(2025, 1, 1, 0, 0, 0, 0, utc),
+			to:    time.Date(2025, 1, 25, 0, 0, 0, 0, utc),
+			want:  []string{"2025-01-06T08:30:00Z", "2025-01-10T08:30:00Z", "2025-01-20T08:30:00Z", "2025-01-24T08:30:00Z"},
+		},
+		{
+			name:  "last friday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: time.Date(2025, 1, 31, 17, 0, 0, 0, utc),
+			from:  time.Date(2025, 1, 1, 0, 0, 0, 0, utc),
+			to:    time.Date(2025, 4, 1, 0, 0, 0, 0, utc),
+			want:  []string{"2025-01-31T17:00:00Z", "2025-02-28T17:00:00Z", "2025-03-28T17:00:00Z"},
+		},
+		{
+			name:  "last weekday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			start: time.Date(2025, 5, 30, 9, 0, 0, 0, utc),
+			from:  time.Date(2025, 5, 1, 0, 0, 0, 0, utc),
+			to:    time.Date(2025, 9, 1, 0, 0, 0, 0, utc),
+			want:  []string{"2025-05-30T09:00:00Z", "2025-06-30T09:00:00Z", "2025-07-31T09:00:00Z", "2025-08-29T09:00:00Z"},
+		},
+		{
+			name:  "31st skips short months",
+			rule:  "FREQ=MONTHLY",
+			start: time.Date(2025, 1, 31, 9, 0, 0, 0, utc),
+			from:  time.Date(2025, 1, 1, 0, 0, 0, 0, utc),
+			to:    time.Date(2025, 6, 1, 0, 0, 0, 0, utc),
+			want:  []string{"2025-01-31T09:00:00Z", "2025-03-31T09:00:00Z", "2025-05-31T09:00:00Z"},
+		},
+		{
+			name:  "leap day yearly",
+			rule:  "FREQ=YEARLY",
+			start: time.Date(2024, 2, 29, 9, 0, 0, 0, utc),
+			from:  time.Date(2024, 1, 1, 0, 0, 0, 0, utc),
+			to:    time.Date(2033, 1, 1, 0, 0, 0, 0, utc),
+			want:  []string{"2024-02-29T09:00:00Z", "2028-02-29T09:00:00Z", "2032-02-29T09:00:00Z"},
+		},
+		{
+			name:  "count counts from the start",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			from:  time.Date(2025, 1, 2, 0, 0, 0, 0, utc),
+			to:    time.Date(2025, 2, 1, 0, 0, 0, 0, utc),
+			want:  []string{"2025-01-02T09:00:00Z", "2025-01-03T09:00:00Z"},
+		},
+		{
+			name:  "until is inclusive",
+			rule:  "FREQ=DAILY;UNTIL=20250103T090000Z",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			from:  time.Date(2025, 1, 1, 0, 0, 0, 0, utc),
+			to:    time.Date(2025, 2, 1, 0, 0, 0, 0, utc),
+			want:  []string{"2025-01-01T09:00:00Z", "2025-01-02T09:00:00Z", "2025-01-03T09:00:00Z"},
+		},
+		{
+			name:    "exdates are skipped",
+			rule:    "FREQ=DAILY;COUNT=4",
+			start:   time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			exdates: []time.Time{time.Date(2025, 1, 2, 9, 0, 0, 0, utc)},
+			from:    time.Date(2025, 1, 1, 0, 0, 0, 0, utc),
+			to:      time.Date(2025, 2, 1, 0, 0, 0, 0, utc),
+			want:    []string{"2025-01-01T09:00:00Z", "2025-01-03T09:00:00Z", "2025-01-04T09:00:00Z"},
+		},
+		{
+			name:  "hourly by minute",
+			rule:  "FREQ=HOURLY;INTERVAL=2;BYMINUTE=0,30",
+			start: time.Date(2025, 1, 1, 8, 0, 0, 0, utc),
+			from:  time.Date(2025, 1, 1, 0, 0, 0, 0, utc),
+			to:    time.Date(2025, 1, 1, 11, 0, 0, 0, utc),
+			want:  []string{"2025-01-01T08:00:00Z", "2025-01-01T08:30:00Z", "2025-01-01T10:00:00Z", "2025-01-01T10:30:00Z"},
+		},
+		{
+			name:  "limit",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			from:  time.Date(2025, 1, 1, 0, 0, 0, 0, utc),
+			to:    time.Date(2026, 1, 1, 0, 0, 0, 0, utc),
+			limit: 2,
+			want:  []string{"2025-01-01T09:00:00Z", "2025-01-02T09:00:00Z"},
+		},
+		{
+			name:  "impossible date ends",
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			start: time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			from:  time.Date(2025, 1, 2, 0, 0, 0, 0, utc),
+			to:    time.Date(2100, 1, 1, 0, 0, 0, 0, utc),
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+			got := formatTimes(NewSchedule(rule, tt.start, tt.exdates).Between(tt.from, tt.to, tt.limit))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Between = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Between = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleKeepsWallClockAcrossDST(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	rule, err := Parse("FREQ=DAILY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSchedule(rule, time.Date(2025, 3, 8, 9, 0, 0, 0, ny), nil)
+
+	tests := []struct {
+		after time.Time
+		want  string
+	}{
+		{time.Date(2025, 3, 8, 9, 0, 0, 0, ny), "2025-03-09T09:00:00-04:00"},
+		{time.Date(2025, 11, 1, 9, 0, 0, 0, ny), "2025-11-02T09:00:00-05:00"},
+	}
+	for _, tt := range tests {
+		next, ok := s.Next(tt.after)
+		if !ok {
+			t.Fatalf("Next(%v) ended the series", tt.after)
+		}
+		if got := next.Format(time.RFC3339); got != tt.want {
+			t.Errorf("Next(%v) = %s, want %s", tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	utc := time.UTC
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, utc)
+	tests := []struct {
+		name   string
+		rule   string
+		after  time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{"before start", "FREQ=DAILY", start.Add(-time.Hour), start, true},
+		{"at an occurrence", "FREQ=DAILY", start, start.AddDate(0, 0, 1), true},
+		{"far ahead", "FREQ=WEEKLY", start.AddDate(1, 0, 0), time.Date(2026, 1, 7, 9, 0, 0, 0, utc), true},
+		{"after count", "FREQ=DAILY;COUNT=2", start.AddDate(0, 0, 1), time.Time{}, false},
+		{"after until", "FREQ=DAILY;UNTIL=20250102T090000Z", start.AddDate(0, 0, 1), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+			got, ok := NewSchedule(rule, start, nil).Next(tt.after)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, %v, want %v, %v", tt.after, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestScheduleMatches(t *testing.T) {
+	utc := time.UTC
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=TU,TH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The start is a Wednesday, which the rule itself never produces.
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, utc)
+	excluded := time.Date(2025, 1, 9, 9, 0, 0, 0, utc)
+	s := NewSchedule(rule, start, []time.Time{excluded})
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"thursday", time.Date(2025, 1, 2, 9, 0, 0, 0, utc), true},
+		{"tuesday", time.Date(2025, 1, 7, 9, 0, 0, 0, utc), true},
+		{"start off the rule", start, false},
+		{"wrong time", time.Date(2025, 1, 7, 10, 0, 0, 0, utc), false},
+		{"wrong day", time.Date(2025, 1, 8, 9, 0, 0, 0, utc), false},
+		{"excluded", excluded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Matches(tt.t); got != tt.want {
+				t.Errorf("Matches(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
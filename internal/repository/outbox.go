@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// outboxRetention is how long published events are kept for inspection.
+const outboxRetention = 7 * 24 * time.Hour
+
+// OutboxStore is the relay's view of the outbox collection.
+type OutboxStore interface {
+	PendingEvents(ctx context.Context, limit int64) ([]*models.OutboxEvent, error)
+	MarkEventPublished(ctx context.Context, id primitive.ObjectID) error
+	MarkEventFailed(ctx context.Context, id primitive.ObjectID, attempts int, nextAttempt time.Time, reason string) error
+	MarkEventDead(ctx context.Context, id primitive.ObjectID, attempts int, reason string) error
+	AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+}
+
+func supportsTransactions(ctx context.Context, client *mongo.Client) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid"
+}
+
+// WithTransaction runs fn inside a MongoDB transaction. Calls made while a
+// transaction is already open on ctx join it instead of starting a new one.
+func (r *MongoRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !r.txSupported || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := r.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// EnqueueEvent records an event in the outbox. It must be called with the
+// transaction context of the write it describes.
+func (r *MongoRepository) EnqueueEvent(ctx context.Context, event *models.OutboxEvent) error {
+	now := time.Now()
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if event.DedupeKey == "" {
+		event.DedupeKey = fmt.Sprintf("%s:%s", event.Topic, event.ID.Hex())
+	}
+	event.Status = models.OutboxPending
+	event.NextAttemptAt = now
+	event.CreatedAt = now
+
+	_, err := r.outbox.InsertOne(ctx, event)
+	return err
+}
+
+// PendingEvents returns the unpublished events that are due, in insertion
+// order. Events whose key has an event still backing off are left out, so
+// they are not sent ahead of it.
+func (r *MongoRepository) PendingEvents(ctx context.Context, limit int64) ([]*models.OutboxEvent, error) {
+	now := time.Now()
+	backingOff, err := r.outbox.Distinct(ctx, "key", bson.M{
+		"status":          models.OutboxPending,
+		"next_attempt_at": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"status":          models.OutboxPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	if len(backingOff) > 0 {
+		filter["key"] = bson.M{"$nin": backingOff}
+	}
+	cursor, err := r.outbox.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []*models.OutboxEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *MongoRepository) MarkEventPublished(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.outbox.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"status": models.OutboxPublished, "published_at": time.Now()},
+		"$unset": bson.M{"last_error": ""},
+	})
+	return err
+}
+
+func (r *MongoRepository) MarkEventFailed(ctx context.Context, id primitive.ObjectID, attempts int, nextAttempt time.Time, reason string) error {
+	_, err := r.outbox.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"attempts": attempts, "next_attempt_at": nextAttempt, "last_error": reason},
+	})
+	return err
+}
+
+// MarkEventDead gives up on an event. Later events for its key are sent
+// without it.
+func (r *MongoRepository) MarkEventDead(ctx context.Context, id primitive.ObjectID, attempts int, reason string) error {
+	_, err := r.outbox.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"status": models.OutboxFailed, "attempts": attempts, "last_error": reason},
+	})
+	return err
+}
+
+// AcquireLock takes or renews a named lease in scheduler_locks. It returns
+// false while another owner holds an unexpired lease.
+func (r *MongoRepository) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": []bson.M{
+			{"owner": owner},
+			{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(ttl)}}
+
+	_, err := r.locks.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"log"
 	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,25 +17,36 @@ import (
 
 type Repository interface {
 	Create(ctx context.Context, reminder *models.Reminder) error
+	CreateMany(ctx context.Context, reminders []*models.Reminder) ([]error, error)
 	GetByID(ctx context.Context, id string) (*models.Reminder, error)
 	GetByUserID(ctx context.Context, userID string, status *models.ReminderStatus) ([]*models.Reminder, error)
-	GetByUserIDPaginated(ctx context.Context, userID string, status *models.ReminderStatus, skip, limit int64) ([]*models.Reminder, int64, error)
-	GetByChannelID(ctx context.Context, channelID string, skip, limit int64) ([]*models.Reminder, int64, error)
-	GetByWorkspaceID(ctx context.Context, workspaceID string, status *models.ReminderStatus, skip, limit int64) ([]*models.Reminder, int64, error)
+	GetByUserIDPaginated(ctx context.Context, userID string, status *models.ReminderStatus, page *models.PaginationParams) ([]*models.Reminder, *models.PageInfo, error)
+	GetByChannelID(ctx context.Context, workspaceID, channelID string, page *models.PaginationParams) ([]*models.Reminder, *models.PageInfo, error)
+	GetByWorkspaceID(ctx context.Context, workspaceID string, status *models.ReminderStatus, page *models.PaginationParams) ([]*models.Reminder, *models.PageInfo, error)
 	GetPendingReminders(ctx context.Context, before time.Time) ([]*models.Reminder, error)
+	ClaimDueReminder(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.Reminder, error)
+	MarkTriggered(ctx context.Context, id, owner string, from models.ReminderStatus, now time.Time) (bool, error)
+	DeferNotification(ctx context.Context, id, owner string, from, to models.ReminderStatus, until time.Time) (bool, error)
+	SetRenotify(ctx context.Context, id string, at *time.Time, count int) error
+	ClaimRenotify(ctx context.Context, now time.Time) (*models.Reminder, error)
+	Acknowledge(ctx context.Context, id string, at time.Time) (bool, error)
 	GetStats(ctx context.Context, userID string) (*models.ReminderStats, error)
 	Update(ctx context.Context, id string, update *models.UpdateReminderRequest) error
-	UpdateStatus(ctx context.Context, id string, status models.ReminderStatus) error
-	BulkUpdateStatus(ctx context.Context, ids []string, status models.ReminderStatus) (int64, error)
+	UpdateStatus(ctx context.Context, id string, from, to models.ReminderStatus) (bool, error)
 	Delete(ctx context.Context, id string) error
 	BulkDelete(ctx context.Context, ids []string) (int64, error)
 	Count(ctx context.Context, filter bson.M) (int64, error)
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	EnqueueEvent(ctx context.Context, event *models.OutboxEvent) error
 	Close() error
 }
 
 type MongoRepository struct {
-	client     *mongo.Client
-	collection *mongo.Collection
+	client      *mongo.Client
+	collection  *audit.Collection
+	outbox      *mongo.Collection
+	locks       *mongo.Collection
+	txSupported bool
 }
 
 func NewMongoRepository(url, dbName string) (*MongoRepository, error) {
@@ -52,18 +66,64 @@ func NewMongoRepository(url, dbName string) (*MongoRepository, error) {
 
 	// Create indexes
 	indexes := []mongo.IndexModel{
-		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "remind_at", Value: 1}, {Key: "_id", Value: 1}}},
 		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "remind_at", Value: 1}}},
-		{Keys: bson.D{{Key: "workspace_id", Value: 1}}},
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "remind_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "remind_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "lease_expires_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "notify_at", Value: 1}}},
+		{Keys: bson.D{{Key: "renotify_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "external_uid", Value: 1}, {Key: "remind_at", Value: -1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "title", Value: "text"},
+				{Key: "description", Value: "text"},
+				{Key: "search_notes", Value: "text"},
+				{Key: "search_comments", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("reminder_text").
+				SetWeights(bson.D{
+					{Key: "title", Value: 10},
+					{Key: "description", Value: 5},
+					{Key: "search_notes", Value: 2},
+					{Key: "search_comments", Value: 1},
+				}),
+		},
 	}
 	_, _ = collection.Indexes().CreateMany(ctx, indexes)
 
+	outbox := client.Database(dbName).Collection("reminder_outbox")
+	_, _ = outbox.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "dedupe_key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "published_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(outboxRetention.Seconds()))},
+	})
+
+	txSupported := supportsTransactions(ctx, client)
+	if !txSupported {
+		log.Println("Warning: MongoDB is not a replica set; outbox writes will not be transactional")
+	}
+
 	return &MongoRepository{
-		client:     client,
-		collection: collection,
+		client:      client,
+		collection:  audit.Wrap(collection, "reminder", "_id", nil),
+		outbox:      outbox,
+		locks:       client.Database(dbName).Collection("scheduler_locks"),
+		txSupported: txSupported,
 	}, nil
 }
 
+// Audit records the changes made to reminders in the activity log through
+// sink. It must be called before the repository is used.
+func (r *MongoRepository) Audit(sink audit.Sink) {
+	r.collection = audit.Wrap(r.collection.Collection, "reminder", "_id", sink)
+}
+
 func (r *MongoRepository) Create(ctx context.Context, reminder *models.Reminder) error {
 	reminder.CreatedAt = time.Now()
 	reminder.UpdatedAt = time.Now()
@@ -78,6 +138,38 @@ func (r *MongoRepository) Create(ctx context.Context, reminder *models.Reminder)
 	return nil
 }
 
+// CreateMany inserts reminders with a single unordered write. The first
+// result holds one entry per reminder, nil for those that were inserted;
+// the second is set when the write as a whole failed.
+func (r *MongoRepository) CreateMany(ctx context.Context, reminders []*models.Reminder) ([]error, error) {
+	if len(reminders) == 0 {
+		return nil, nil
+	}
+	now := time.Now()
+	docs := make([]interface{}, len(reminders))
+	for i, reminder := range reminders {
+		reminder.ID = primitive.NewObjectID()
+		reminder.CreatedAt = now
+		reminder.UpdatedAt = now
+		reminder.Status = models.StatusPending
+		docs[i] = reminder
+	}
+
+	itemErrs := make([]error, len(reminders))
+	_, err := r.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, we := range bulkErr.WriteErrors {
+			itemErrs[we.Index] = we
+		}
+		return itemErrs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return itemErrs, nil
+}
+
 func (r *MongoRepository) GetByID(ctx context.Context, id string) (*models.Reminder, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -115,7 +207,7 @@ func (r *MongoRepository) GetByUserID(ctx context.Context, userID string, status
 
 func (r *MongoRepository) GetPendingReminders(ctx context.Context, before time.Time) ([]*models.Reminder, error) {
 	filter := bson.M{
-		"status":    models.StatusPending,
+		"status":    bson.M{"$in": []models.ReminderStatus{models.StatusPending, models.StatusSnoozed}},
 		"remind_at": bson.M{"$lte": before},
 	}
 
@@ -133,6 +225,168 @@ func (r *MongoRepository) GetPendingReminders(ctx context.Context, before time.T
 	return reminders, nil
 }
 
+// ClaimDueReminder atomically leases the oldest due reminder to owner. Reminders
+// whose lease has expired (the holder crashed mid-trigger) are reclaimed too.
+// The status before the claim is kept in claimed_from. It returns nil when
+// nothing is due.
+func (r *MongoRepository) ClaimDueReminder(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.Reminder, error) {
+	// Reminders blocked by a dependency wait until it is released, and
+	// geofenced ones until the user crosses their location. Snoozed
+	// reminders are due again at their new time.
+	waiting := bson.M{"$in": []models.ReminderStatus{models.StatusPending, models.StatusSnoozed}}
+	unblocked := bson.M{"$exists": false}
+	timed := bson.M{"$ne": true}
+	filter := bson.M{
+		"$or": []bson.M{
+			{"status": waiting, "notify_at": bson.M{"$lte": now}, "blocked_by.0": unblocked, "geofenced": timed},
+			{"status": waiting, "notify_at": nil, "remind_at": bson.M{"$lte": now}, "blocked_by.0": unblocked, "geofenced": timed},
+			{"status": models.StatusProcessing, "lease_expires_at": bson.M{"$lte": now}},
+		},
+	}
+	// A reclaimed reminder keeps the status it had before the first claim.
+	claimedFrom := bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{"$status", models.StatusProcessing}}, "$claimed_from", "$status",
+	}}
+	update := bson.A{bson.M{
+		"$set": bson.M{
+			"claimed_from":     claimedFrom,
+			"status":           models.StatusProcessing,
+			"lease_owner":      bson.M{"$literal": owner},
+			"lease_expires_at": now.Add(lease),
+			"updated_at":       now,
+		},
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "remind_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var reminder models.Reminder
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&reminder)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// MarkTriggered moves a reminder from status from to triggered only if it
+// still has that status and, for leased callers, the caller still holds its
+// lease. The boolean reports whether this caller won the transition. An
+// earlier acknowledgement and re-notification state are reset, and
+// triggered_at is set to now.
+func (r *MongoRepository) MarkTriggered(ctx context.Context, id, owner string, from models.ReminderStatus, now time.Time) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	filter := bson.M{"_id": objID, "status": from}
+	if owner != "" {
+		filter["lease_owner"] = owner
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{"status": models.StatusTriggered, "triggered_at": now, "updated_at": now},
+		"$unset": bson.M{
+			"lease_owner": "", "lease_expires_at": "", "claimed_from": "",
+			"acknowledged_at": "", "renotify_at": "", "renotify_count": "",
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// DeferNotification moves a reminder from status from to status to with a
+// later notify_at and drops the lease. Like MarkTriggered, it only does so
+// while the reminder has status from and the lease is the caller's.
+func (r *MongoRepository) DeferNotification(ctx context.Context, id, owner string, from, to models.ReminderStatus, until time.Time) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	filter := bson.M{"_id": objID, "status": from}
+	if owner != "" {
+		filter["lease_owner"] = owner
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$set":   bson.M{"status": to, "notify_at": until, "updated_at": time.Now()},
+		"$unset": bson.M{"lease_owner": "", "lease_expires_at": "", "claimed_from": ""},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// SetRenotify schedules the next re-notification of a triggered reminder
+// and records how many were sent. A nil at stops re-notifying.
+func (r *MongoRepository) SetRenotify(ctx context.Context, id string, at *time.Time, count int) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"renotify_count": count, "updated_at": time.Now()}}
+	if at != nil {
+		update["$set"].(bson.M)["renotify_at"] = at
+	} else {
+		update["$unset"] = bson.M{"renotify_at": ""}
+	}
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	return err
+}
+
+// ClaimRenotify takes the triggered, unacknowledged reminder whose
+// re-notification has been due longest and clears its renotify_at, so no
+// other caller takes it. It returns nil when nothing is due.
+func (r *MongoRepository) ClaimRenotify(ctx context.Context, now time.Time) (*models.Reminder, error) {
+	filter := bson.M{
+		"status":          models.StatusTriggered,
+		"acknowledged_at": nil,
+		"renotify_at":     bson.M{"$lte": now},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "renotify_at", Value: 1}})
+
+	var reminder models.Reminder
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$unset": bson.M{"renotify_at": ""}}, opts).Decode(&reminder)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// Acknowledge records when the user saw a triggered reminder and stops its
+// re-notifications. Only the first acknowledgement of a trigger counts, and
+// none from before it; the boolean reports whether this one did.
+func (r *MongoRepository) Acknowledge(ctx context.Context, id string, at time.Time) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":             objID,
+		"acknowledged_at": nil,
+		"triggered_at":    bson.M{"$lte": at},
+	}, bson.M{
+		"$set":   bson.M{"acknowledged_at": at, "updated_at": time.Now()},
+		"$unset": bson.M{"renotify_at": ""},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
 func (r *MongoRepository) Update(ctx context.Context, id string, update *models.UpdateReminderRequest) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -150,10 +404,21 @@ func (r *MongoRepository) Update(ctx context.Context, id string, update *models.
 	}
 	if update.RemindAt != nil {
 		setDoc["remind_at"] = update.RemindAt
+		if update.NotifyAt != nil {
+			setDoc["notify_at"] = update.NotifyAt
+		} else {
+			updateDoc["$unset"] = bson.M{"notify_at": ""}
+		}
 	}
 	if update.Status != "" {
 		setDoc["status"] = update.Status
 	}
+	if update.Priority != "" {
+		setDoc["priority"] = update.Priority
+	}
+	if update.CategoryID != "" {
+		setDoc["category_id"] = update.CategoryID
+	}
 	if update.Recurrence != nil {
 		setDoc["recurrence"] = update.Recurrence
 	}
@@ -165,26 +430,31 @@ func (r *MongoRepository) Update(ctx context.Context, id string, update *models.
 	return err
 }
 
-func (r *MongoRepository) UpdateStatus(ctx context.Context, id string, status models.ReminderStatus) error {
+// UpdateStatus moves a reminder from one status to another. The boolean
+// reports whether the reminder still had status from.
+func (r *MongoRepository) UpdateStatus(ctx context.Context, id string, from, to models.ReminderStatus) (bool, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	update := bson.M{
 		"$set": bson.M{
-			"status":     status,
+			"status":     to,
 			"updated_at": time.Now(),
 		},
 	}
 
-	if status == models.StatusTriggered {
+	if to == models.StatusTriggered {
 		now := time.Now()
 		update["$set"].(bson.M)["triggered_at"] = now
 	}
 
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
-	return err
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID, "status": from}, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
 }
 
 func (r *MongoRepository) Delete(ctx context.Context, id string) error {
@@ -197,91 +467,85 @@ func (r *MongoRepository) Delete(ctx context.Context, id string) error {
 	return err
 }
 
-func (r *MongoRepository) GetByUserIDPaginated(ctx context.Context, userID string, status *models.ReminderStatus, skip, limit int64) ([]*models.Reminder, int64, error) {
+func (r *MongoRepository) GetByUserIDPaginated(ctx context.Context, userID string, status *models.ReminderStatus, page *models.PaginationParams) ([]*models.Reminder, *models.PageInfo, error) {
 	filter := bson.M{"user_id": userID}
 	if status != nil {
 		filter["status"] = *status
 	}
-
-	total, err := r.collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	opts := options.Find().
-		SetSort(bson.D{{Key: "remind_at", Value: 1}}).
-		SetSkip(skip).
-		SetLimit(limit)
-
-	cursor, err := r.collection.Find(ctx, filter, opts)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer cursor.Close(ctx)
-
-	var reminders []*models.Reminder
-	if err := cursor.All(ctx, &reminders); err != nil {
-		return nil, 0, err
-	}
-
-	return reminders, total, nil
+	return r.findPage(ctx, filter, page)
 }
 
-func (r *MongoRepository) GetByChannelID(ctx context.Context, channelID string, skip, limit int64) ([]*models.Reminder, int64, error) {
-	filter := bson.M{"channel_id": channelID}
-
-	total, err := r.collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	opts := options.Find().
-		SetSort(bson.D{{Key: "remind_at", Value: 1}}).
-		SetSkip(skip).
-		SetLimit(limit)
-
-	cursor, err := r.collection.Find(ctx, filter, opts)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer cursor.Close(ctx)
-
-	var reminders []*models.Reminder
-	if err := cursor.All(ctx, &reminders); err != nil {
-		return nil, 0, err
-	}
-
-	return reminders, total, nil
+func (r *MongoRepository) GetByChannelID(ctx context.Context, workspaceID, channelID string, page *models.PaginationParams) ([]*models.Reminder, *models.PageInfo, error) {
+	return r.findPage(ctx, bson.M{"workspace_id": workspaceID, "channel_id": channelID}, page)
 }
 
-func (r *MongoRepository) GetByWorkspaceID(ctx context.Context, workspaceID string, status *models.ReminderStatus, skip, limit int64) ([]*models.Reminder, int64, error) {
+func (r *MongoRepository) GetByWorkspaceID(ctx context.Context, workspaceID string, status *models.ReminderStatus, page *models.PaginationParams) ([]*models.Reminder, *models.PageInfo, error) {
 	filter := bson.M{"workspace_id": workspaceID}
 	if status != nil {
 		filter["status"] = *status
 	}
+	return r.findPage(ctx, filter, page)
+}
 
-	total, err := r.collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, 0, err
+// PageOrder is the order of every paged reminder listing. _id breaks ties
+// so that a cursor names exactly one position.
+var PageOrder = bson.D{{Key: "remind_at", Value: 1}, {Key: "_id", Value: 1}}
+
+// AfterCursor matches the reminders that come after c in PageOrder.
+func AfterCursor(c *models.PageCursor) bson.M {
+	return bson.M{"$or": []bson.M{
+		{"remind_at": bson.M{"$gt": c.RemindAt}},
+		{"remind_at": c.RemindAt, "_id": bson.M{"$gt": c.ID}},
+	}}
+}
+
+// findPage returns the page of reminders matching filter in PageOrder. One
+// extra reminder is read to tell whether another page follows.
+func (r *MongoRepository) findPage(ctx context.Context, filter bson.M, page *models.PaginationParams) ([]*models.Reminder, *models.PageInfo, error) {
+	info := &models.PageInfo{}
+	if page.WantsTotal() {
+		total, err := r.collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		info.Total = &total
 	}
 
 	opts := options.Find().
-		SetSort(bson.D{{Key: "remind_at", Value: 1}}).
-		SetSkip(skip).
-		SetLimit(limit)
+		SetSort(PageOrder).
+		SetLimit(page.Limit() + 1)
+
+	if page.Cursor == "" {
+		opts.SetSkip(page.Skip())
+	} else {
+		cursor, err := models.DecodeCursor(page.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cursor.IsOffset() {
+			opts.SetSkip(cursor.Offset)
+		} else {
+			filter = bson.M{"$and": []bson.M{filter, AfterCursor(cursor)}}
+		}
+	}
 
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 	defer cursor.Close(ctx)
 
 	var reminders []*models.Reminder
 	if err := cursor.All(ctx, &reminders); err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 
-	return reminders, total, nil
+	if int64(len(reminders)) > page.Limit() {
+		reminders = reminders[:page.Limit()]
+		last := reminders[len(reminders)-1]
+		info.NextCursor = models.PageCursor{RemindAt: last.RemindAt, ID: last.ID}.Encode()
+	}
+	return reminders, info, nil
 }
 
 func (r *MongoRepository) GetStats(ctx context.Context, userID string) (*models.ReminderStats, error) {
@@ -300,7 +564,7 @@ func (r *MongoRepository) GetStats(ctx context.Context, userID string) (*models.
 	stats.Total = total
 
 	// Count by status
-	for _, status := range []models.ReminderStatus{models.StatusPending, models.StatusTriggered, models.StatusCompleted, models.StatusCancelled, models.StatusSnoozed} {
+	for _, status := range []models.ReminderStatus{models.StatusPending, models.StatusTriggered, models.StatusAcknowledged, models.StatusCompleted, models.StatusCancelled, models.StatusSnoozed, models.StatusArchived} {
 		f := bson.M{"user_id": userID, "status": status}
 		count, _ := r.collection.CountDocuments(ctx, f)
 		stats.ByStatus[string(status)] = count
@@ -313,18 +577,18 @@ func (r *MongoRepository) GetStats(ctx context.Context, userID string) (*models.
 		stats.ByType[string(rtype)] = count
 	}
 
-	// Upcoming (pending, remind_at > now)
+	// Upcoming (pending or snoozed, remind_at > now)
 	upcoming, _ := r.collection.CountDocuments(ctx, bson.M{
 		"user_id": userID,
-		"status":  models.StatusPending,
+		"status":  bson.M{"$in": []models.ReminderStatus{models.StatusPending, models.StatusSnoozed}},
 		"remind_at": bson.M{"$gt": time.Now()},
 	})
 	stats.Upcoming = upcoming
 
-	// Overdue (pending, remind_at < now)
+	// Overdue (pending or snoozed, remind_at < now)
 	overdue, _ := r.collection.CountDocuments(ctx, bson.M{
 		"user_id": userID,
-		"status":  models.StatusPending,
+		"status":  bson.M{"$in": []models.ReminderStatus{models.StatusPending, models.StatusSnoozed}},
 		"remind_at": bson.M{"$lt": time.Now()},
 	})
 	stats.Overdue = overdue
@@ -332,36 +596,6 @@ func (r *MongoRepository) GetStats(ctx context.Context, userID string) (*models.
 	return stats, nil
 }
 
-func (r *MongoRepository) BulkUpdateStatus(ctx context.Context, ids []string, status models.ReminderStatus) (int64, error) {
-	var objectIDs []primitive.ObjectID
-	for _, id := range ids {
-		objID, err := primitive.ObjectIDFromHex(id)
-		if err != nil {
-			continue
-		}
-		objectIDs = append(objectIDs, objID)
-	}
-
-	if len(objectIDs) == 0 {
-		return 0, nil
-	}
-
-	filter := bson.M{"_id": bson.M{"$in": objectIDs}}
-	update := bson.M{
-		"$set": bson.M{
-			"status":     status,
-			"updated_at": time.Now(),
-		},
-	}
-
-	result, err := r.collection.UpdateMany(ctx, filter, update)
-	if err != nil {
-		return 0, err
-	}
-
-	return result.ModifiedCount, nil
-}
-
 func (r *MongoRepository) BulkDelete(ctx context.Context, ids []string) (int64, error) {
 	var objectIDs []primitive.ObjectID
 	for _, id := range ids {
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAfterCursor(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+
+	got := AfterCursor(&models.PageCursor{RemindAt: at, ID: id})
+	want := bson.M{"$or": []bson.M{
+		{"remind_at": bson.M{"$gt": at}},
+		{"remind_at": at, "_id": bson.M{"$gt": id}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AfterCursor = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"reminder-service/internal/audit"
+	"reminder-service/internal/models"
+	"reminder-service/internal/service"
+)
+
+// maxEscalationAttempts is how often an event is retried before it is
+// recorded as failed.
+const maxEscalationAttempts = 5
+
+// EscalationWorker executes escalation events once their delay has passed.
+type EscalationWorker struct {
+	escalations *service.EscalationService
+	reminders   *service.ReminderService
+	owner       string
+	lease       time.Duration
+	batchSize   int
+	ticker      *time.Ticker
+	done        chan bool
+}
+
+func NewEscalationWorker(escalations *service.EscalationService, reminders *service.ReminderService, owner string, lease time.Duration, batchSize int) *EscalationWorker {
+	return &EscalationWorker{
+		escalations: escalations,
+		reminders:   reminders,
+		owner:       owner,
+		lease:       lease,
+		batchSize:   batchSize,
+		done:        make(chan bool),
+	}
+}
+
+func (w *EscalationWorker) Start() {
+	w.ticker = time.NewTicker(15 * time.Second)
+	log.Printf("Escalation worker started (owner %s)", w.owner)
+
+	for {
+		select {
+		case <-w.ticker.C:
+			w.runDueEscalations()
+		case <-w.done:
+			w.ticker.Stop()
+			return
+		}
+	}
+}
+
+func (w *EscalationWorker) Stop() {
+	w.done <- true
+}
+
+func (w *EscalationWorker) runDueEscalations() {
+	ctx, cancel := context.WithTimeout(audit.WithSource(context.Background(), audit.SourceScheduler), 30*time.Second)
+	defer cancel()
+
+	for i := 0; i < w.batchSize; i++ {
+		event, err := w.escalations.ClaimDueEvent(ctx, w.owner, time.Now(), w.lease)
+		if err != nil {
+			log.Printf("Error claiming escalation event: %v", err)
+			return
+		}
+		if event == nil {
+			return
+		}
+
+		err = w.reminders.ExecuteEscalation(ctx, event)
+		switch {
+		case err == nil:
+			log.Printf("Escalation %s (%s) for reminder %s: %s", event.ID.Hex(), event.Action.Type, event.ReminderID, event.Status)
+		case errors.Is(err, service.ErrEscalationLeaseLost):
+			log.Printf("Lease lost for escalation %s, skipping", event.ID.Hex())
+		case event.Attempts >= maxEscalationAttempts:
+			log.Printf("Giving up on escalation %s after %d attempts: %v", event.ID.Hex(), event.Attempts, err)
+			if err := w.escalations.FinishEvent(ctx, event, models.EscalationFailed, err.Error()); err != nil {
+				log.Printf("Error recording failed escalation %s: %v", event.ID.Hex(), err)
+			}
+		default:
+			// Leave the lease in place: the event is retried once it expires.
+			log.Printf("Error executing escalation %s: %v", event.ID.Hex(), err)
+		}
+	}
+}
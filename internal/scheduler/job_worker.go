@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"reminder-service/internal/audit"
+	"reminder-service/internal/service"
+)
+
+// jobPurgeInterval is how often finished jobs past their retention are
+// deleted.
+const jobPurgeInterval = time.Hour
+
+// JobWorker runs queued export and import jobs one at a time. Jobs keep
+// their lease alive while they report progress, so a long job is not taken
+// over by another replica.
+type JobWorker struct {
+	jobs      *service.JobService
+	owner     string
+	lease     time.Duration
+	lastPurge time.Time
+	ticker    *time.Ticker
+	done      chan bool
+}
+
+func NewJobWorker(jobs *service.JobService, owner string, lease time.Duration) *JobWorker {
+	return &JobWorker{
+		jobs:  jobs,
+		owner: owner,
+		lease: lease,
+		done:  make(chan bool),
+	}
+}
+
+func (w *JobWorker) Start() {
+	w.ticker = time.NewTicker(5 * time.Second)
+	log.Printf("Job worker started (owner %s)", w.owner)
+
+	for {
+		select {
+		case <-w.ticker.C:
+			w.runQueuedJobs()
+			w.purgeExpiredJobs()
+		case <-w.done:
+			w.ticker.Stop()
+			return
+		}
+	}
+}
+
+func (w *JobWorker) Stop() {
+	w.done <- true
+}
+
+func (w *JobWorker) runQueuedJobs() {
+	ctx := audit.WithSource(context.Background(), audit.SourceScheduler)
+
+	for {
+		claimCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		job, err := w.jobs.ClaimNext(claimCtx, w.owner, w.lease)
+		cancel()
+		if err != nil {
+			log.Printf("Error claiming job: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		// Imports are recorded as changes by the user who submitted them.
+		err = w.jobs.Run(audit.WithActor(ctx, job.UserID), job, w.lease)
+		switch {
+		case err == nil:
+			log.Printf("Job %s (%s) finished", job.ID.Hex(), job.Kind)
+		case errors.Is(err, service.ErrJobLeaseLost):
+			log.Printf("Lease lost for job %s, skipping", job.ID.Hex())
+		default:
+			// Leave the lease in place: the job is retried once it expires.
+			log.Printf("Error running job %s: %v", job.ID.Hex(), err)
+		}
+	}
+}
+
+func (w *JobWorker) purgeExpiredJobs() {
+	if time.Since(w.lastPurge) < jobPurgeInterval {
+		return
+	}
+	w.lastPurge = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	purged, err := w.jobs.PurgeExpired(ctx)
+	if err != nil {
+		log.Printf("Error purging expired jobs: %v", err)
+		return
+	}
+	if purged > 0 {
+		log.Printf("Purged %d expired jobs", purged)
+	}
+}
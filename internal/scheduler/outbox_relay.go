@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"reminder-service/internal/interfaces"
+	"reminder-service/internal/repository"
+)
+
+const (
+	outboxLockName   = "outbox-relay"
+	outboxLockTTL    = 30 * time.Second
+	outboxMaxBackoff = 5 * time.Minute
+	// outboxMaxAttempts is how often an event is sent before it is marked
+	// failed, about an hour with the backoff above.
+	outboxMaxAttempts = 20
+)
+
+// OutboxRelay drains the transactional outbox to Kafka. Only the replica
+// holding the relay lock publishes, events sharing a key are sent in
+// insertion order, and failed sends are retried with exponential backoff
+// until outboxMaxAttempts.
+type OutboxRelay struct {
+	store     repository.OutboxStore
+	publisher interfaces.MessagePublisher
+	owner     string
+	batchSize int64
+	ticker    *time.Ticker
+	done      chan bool
+}
+
+func NewOutboxRelay(store repository.OutboxStore, publisher interfaces.MessagePublisher, owner string) *OutboxRelay {
+	return &OutboxRelay{
+		store:     store,
+		publisher: publisher,
+		owner:     owner,
+		batchSize: 200,
+		done:      make(chan bool),
+	}
+}
+
+func (r *OutboxRelay) Start() {
+	r.ticker = time.NewTicker(time.Second)
+	log.Println("Outbox relay started")
+
+	for {
+		select {
+		case <-r.ticker.C:
+			r.drain()
+		case <-r.done:
+			r.ticker.Stop()
+			return
+		}
+	}
+}
+
+func (r *OutboxRelay) Stop() {
+	r.done <- true
+}
+
+func (r *OutboxRelay) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), outboxLockTTL)
+	defer cancel()
+
+	leader, err := r.store.AcquireLock(ctx, outboxLockName, r.owner, outboxLockTTL)
+	if err != nil {
+		log.Printf("Error acquiring outbox lock: %v", err)
+		return
+	}
+	if !leader {
+		return
+	}
+
+	events, err := r.store.PendingEvents(ctx, r.batchSize)
+	if err != nil {
+		log.Printf("Error fetching outbox events: %v", err)
+		return
+	}
+
+	// Once an event for a key is held back, every later event for that key
+	// must wait too, otherwise consumers would see them out of order.
+	blocked := make(map[string]bool)
+	now := time.Now()
+	for _, event := range events {
+		if blocked[event.Key] {
+			continue
+		}
+
+		headers := map[string]string{
+			"event_id":   event.ID.Hex(),
+			"dedupe_key": event.DedupeKey,
+		}
+		if err := r.publisher.PublishMessage(event.Topic, event.Key, []byte(event.Payload), headers); err != nil {
+			blocked[event.Key] = true
+			attempts := event.Attempts + 1
+			if attempts >= outboxMaxAttempts {
+				log.Printf("Giving up on outbox event %s after %d attempts: %v", event.ID.Hex(), attempts, err)
+				if err := r.store.MarkEventDead(ctx, event.ID, attempts, err.Error()); err != nil {
+					log.Printf("Error recording outbox failure for %s: %v", event.ID.Hex(), err)
+				}
+				continue
+			}
+			if err := r.store.MarkEventFailed(ctx, event.ID, attempts, now.Add(outboxBackoff(attempts)), err.Error()); err != nil {
+				log.Printf("Error recording outbox failure for %s: %v", event.ID.Hex(), err)
+			}
+			continue
+		}
+
+		if err := r.store.MarkEventPublished(ctx, event.ID); err != nil {
+			// The event will be sent again; consumers drop it by dedupe_key.
+			log.Printf("Error marking outbox event %s published: %v", event.ID.Hex(), err)
+			blocked[event.Key] = true
+		}
+	}
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	backoff := time.Second
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
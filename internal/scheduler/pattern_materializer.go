@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"reminder-service/internal/audit"
+	"reminder-service/internal/models"
+	"reminder-service/internal/service"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PatternMaterializer turns due recurring patterns into reminders. Patterns
+// are leased like reminders, so each occurrence is created by one replica.
+type PatternMaterializer struct {
+	patterns  *service.RecurringService
+	reminders *service.ReminderService
+	owner     string
+	lease     time.Duration
+	batchSize int
+	ticker    *time.Ticker
+	done      chan bool
+}
+
+func NewPatternMaterializer(patterns *service.RecurringService, reminders *service.ReminderService, owner string, lease time.Duration, batchSize int) *PatternMaterializer {
+	return &PatternMaterializer{
+		patterns:  patterns,
+		reminders: reminders,
+		owner:     owner,
+		lease:     lease,
+		batchSize: batchSize,
+		done:      make(chan bool),
+	}
+}
+
+func (m *PatternMaterializer) Start() {
+	m.ticker = time.NewTicker(30 * time.Second)
+	log.Printf("Pattern materializer started (owner %s)", m.owner)
+
+	for {
+		select {
+		case <-m.ticker.C:
+			m.materializeDuePatterns()
+		case <-m.done:
+			m.ticker.Stop()
+			return
+		}
+	}
+}
+
+func (m *PatternMaterializer) Stop() {
+	m.done <- true
+}
+
+func (m *PatternMaterializer) materializeDuePatterns() {
+	ctx, cancel := context.WithTimeout(audit.WithSource(context.Background(), audit.SourceScheduler), m.lease)
+	defer cancel()
+
+	for i := 0; i < m.batchSize; i++ {
+		pattern, err := m.patterns.ClaimDuePattern(ctx, m.owner, time.Now(), m.lease)
+		if err != nil {
+			log.Printf("Error claiming due pattern: %v", err)
+			return
+		}
+		if pattern == nil {
+			return
+		}
+
+		if err := m.materialize(ctx, pattern); err != nil {
+			// Leave the lease in place: the pattern is retried once it expires.
+			log.Printf("Error materializing pattern %s: %v", pattern.ID.Hex(), err)
+		}
+	}
+}
+
+// materialize creates the due occurrences of a claimed pattern. Each
+// occurrence is created, recorded and advanced past in one transaction, so a
+// crash midway resumes from the first occurrence not yet created.
+func (m *PatternMaterializer) materialize(ctx context.Context, pattern *models.RecurringPattern) error {
+	due, next, err := m.patterns.PlanOccurrences(pattern, time.Now())
+	if err != nil {
+		log.Printf("Stopping pattern %s: %v", pattern.ID.Hex(), err)
+		return m.patterns.AdvancePattern(ctx, pattern.ID, m.owner, nil, true)
+	}
+
+	count := pattern.OccurrenceCount
+	for i, at := range due {
+		following := next
+		if i+1 < len(due) {
+			following = &due[i+1]
+		}
+
+		err := m.reminders.WithTransaction(ctx, func(ctx context.Context) error {
+			reminder, err := m.reminders.Create(ctx, &models.CreateReminderRequest{
+				UserID:      pattern.UserID,
+				WorkspaceID: pattern.WorkspaceID,
+				Type:        pattern.ReminderType,
+				Title:       pattern.ReminderTitle,
+				Description: pattern.ReminderDesc,
+				RemindAt:    at,
+				Metadata: map[string]any{
+					"recurring_pattern_id": pattern.ID.Hex(),
+					"occurrence":           count + 1,
+				},
+			})
+			if err != nil {
+				return err
+			}
+			if err := m.patterns.RecordOccurrence(ctx, pattern.ID.Hex(), reminder.ID.Hex(), at, count+1); err != nil {
+				return err
+			}
+			return m.patterns.AdvancePattern(ctx, pattern.ID, m.owner, following, false)
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			log.Printf("Occurrence %s of pattern %s already exists, skipping", at.Format(time.RFC3339), pattern.ID.Hex())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		count++
+	}
+
+	return m.patterns.AdvancePattern(ctx, pattern.ID, m.owner, next, true)
+}
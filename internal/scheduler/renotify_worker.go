@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"reminder-service/internal/audit"
+	"reminder-service/internal/service"
+)
+
+// RenotifyWorker re-sends triggered reminders nobody has acknowledged, as
+// the users' notification preferences ask.
+type RenotifyWorker struct {
+	reminders *service.ReminderService
+	batchSize int
+	ticker    *time.Ticker
+	done      chan bool
+}
+
+func NewRenotifyWorker(reminders *service.ReminderService, batchSize int) *RenotifyWorker {
+	return &RenotifyWorker{
+		reminders: reminders,
+		batchSize: batchSize,
+		done:      make(chan bool),
+	}
+}
+
+func (w *RenotifyWorker) Start() {
+	w.ticker = time.NewTicker(30 * time.Second)
+	log.Println("Re-notification worker started")
+
+	for {
+		select {
+		case <-w.ticker.C:
+			w.runDueRenotifications()
+		case <-w.done:
+			w.ticker.Stop()
+			return
+		}
+	}
+}
+
+func (w *RenotifyWorker) Stop() {
+	w.done <- true
+}
+
+func (w *RenotifyWorker) runDueRenotifications() {
+	ctx, cancel := context.WithTimeout(audit.WithSource(context.Background(), audit.SourceScheduler), 30*time.Second)
+	defer cancel()
+
+	for i := 0; i < w.batchSize; i++ {
+		reminder, err := w.reminders.RenotifyDue(ctx, time.Now())
+		if err != nil {
+			log.Printf("Error re-notifying reminder: %v", err)
+			return
+		}
+		if reminder == nil {
+			return
+		}
+		log.Printf("Re-notification %d for reminder %s handled", reminder.RenotifyCount, reminder.ID.Hex())
+	}
+}
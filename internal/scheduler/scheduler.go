@@ -2,31 +2,38 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
-	"reminder-service/internal/kafka"
+	"reminder-service/internal/audit"
 	"reminder-service/internal/service"
 )
 
 type Scheduler struct {
-	service  *service.ReminderService
-	producer *kafka.Producer
-	ticker   *time.Ticker
-	done     chan bool
+	service   *service.ReminderService
+	owner     string
+	lease     time.Duration
+	batchSize int
+	ticker    *time.Ticker
+	done      chan bool
 }
 
-func NewScheduler(svc *service.ReminderService, producer *kafka.Producer) *Scheduler {
+// NewScheduler creates a scheduler that claims due reminders under the given
+// owner ID. Every replica must use a distinct owner so leases can be fenced.
+func NewScheduler(svc *service.ReminderService, owner string, lease time.Duration, batchSize int) *Scheduler {
 	return &Scheduler{
-		service:  svc,
-		producer: producer,
-		done:     make(chan bool),
+		service:   svc,
+		owner:     owner,
+		lease:     lease,
+		batchSize: batchSize,
+		done:      make(chan bool),
 	}
 }
 
 func (s *Scheduler) Start() {
 	s.ticker = time.NewTicker(30 * time.Second) // Check every 30 seconds
-	log.Println("Reminder scheduler started")
+	log.Printf("Reminder scheduler started (owner %s)", s.owner)
 
 	for {
 		select {
@@ -43,18 +50,33 @@ func (s *Scheduler) Stop() {
 	s.done <- true
 }
 
+// checkPendingReminders claims due reminders one at a time until none are
+// left or the batch limit is hit. Claiming is atomic, so replicas running the
+// same loop never trigger the same reminder twice.
 func (s *Scheduler) checkPendingReminders() {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(audit.WithSource(context.Background(), audit.SourceScheduler), 30*time.Second)
 	defer cancel()
 
-	reminders, err := s.service.GetPendingReminders(ctx, time.Now())
-	if err != nil {
-		log.Printf("Error fetching pending reminders: %v", err)
-		return
-	}
+	for i := 0; i < s.batchSize; i++ {
+		reminder, err := s.service.ClaimDueReminder(ctx, s.owner, s.lease)
+		if err != nil {
+			log.Printf("Error claiming due reminder: %v", err)
+			return
+		}
+		if reminder == nil {
+			return
+		}
 
-	for _, reminder := range reminders {
 		if err := s.service.TriggerReminder(ctx, reminder); err != nil {
+			if errors.Is(err, service.ErrLeaseLost) {
+				log.Printf("Lease lost for reminder %s, skipping", reminder.ID.Hex())
+				continue
+			}
+			if errors.Is(err, service.ErrNotificationDeferred) {
+				log.Printf("Deferred reminder %s until the end of quiet hours", reminder.ID.Hex())
+				continue
+			}
+			// Leave the lease in place: the reminder is retried once it expires.
 			log.Printf("Error triggering reminder %s: %v", reminder.ID.Hex(), err)
 			continue
 		}
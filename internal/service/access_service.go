@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"reminder-service/internal/models"
+	"reminder-service/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrResourceNotFound = errors.New("resource not found")
+
+// Access is what a caller may do with a reminder. Levels are ordered, so a
+// check for AccessView also passes with AccessEdit.
+type Access int
+
+const (
+	AccessNone Access = iota
+	AccessView
+	AccessEdit
+	AccessOwner
+)
+
+// ResourceKind names a record that belongs to a user or hangs off a reminder.
+type ResourceKind string
+
+const (
+	ResourceTag            ResourceKind = "tag"
+	ResourceTemplate       ResourceKind = "template"
+	ResourceHabit          ResourceKind = "habit"
+	ResourcePattern        ResourceKind = "recurring_pattern"
+	ResourceEscalationRule ResourceKind = "escalation_rule"
+	ResourceCategory       ResourceKind = "category"
+	ResourceNote           ResourceKind = "note"
+	ResourceComment        ResourceKind = "comment"
+	ResourceSubtask        ResourceKind = "subtask"
+	ResourceAttachment     ResourceKind = "attachment"
+	ResourceDependency     ResourceKind = "dependency"
+	ResourceQuickAction    ResourceKind = "quick_action"
+	ResourceDelegation     ResourceKind = "delegation"
+	ResourceJob            ResourceKind = "job"
+	ResourceView           ResourceKind = "view"
+)
+
+// resourceSpec says where a resource lives and which fields hold its owner
+// and the reminder it belongs to. Either field may be empty.
+type resourceSpec struct {
+	collection    string
+	ownerField    string
+	reminderField string
+}
+
+var resourceSpecs = map[ResourceKind]resourceSpec{
+	ResourceTag:            {"reminder_tags", "user_id", ""},
+	ResourceTemplate:       {"reminder_templates", "user_id", ""},
+	ResourceHabit:          {"habits", "user_id", ""},
+	ResourcePattern:        {"recurring_patterns", "user_id", ""},
+	ResourceEscalationRule: {"reminder_escalation_rules", "user_id", ""},
+	ResourceCategory:       {"reminder_categories", "user_id", ""},
+	ResourceNote:           {"reminder_notes", "user_id", "reminder_id"},
+	ResourceComment:        {"reminder_comments", "user_id", "reminder_id"},
+	ResourceSubtask:        {"reminder_subtasks", "", "reminder_id"},
+	ResourceAttachment:     {"reminder_attachments", "uploaded_by", "reminder_id"},
+	ResourceDependency:     {"reminder_dependencies", "", "reminder_id"},
+	ResourceQuickAction:    {"reminder_quick_actions", "user_id", ""},
+	ResourceDelegation:     {"reminder_delegations", "delegated_to", "reminder_id"},
+	ResourceJob:            {"reminder_jobs", "user_id", ""},
+	ResourceView:           {"reminder_views", "user_id", ""},
+}
+
+// ResourceRef is the ownership information of a single resource.
+type ResourceRef struct {
+	Owner      string
+	ReminderID string
+	Shared     bool
+}
+
+// AccessService answers authorization questions for the HTTP API: who owns
+// a resource, and what a user may do with a reminder through ownership,
+// shares and accepted delegations.
+type AccessService struct {
+	repo        repository.Repository
+	db          *mongo.Database
+	sharing     *SharingService
+	delegations *DelegationService
+}
+
+func NewAccessService(repo repository.Repository, db *mongo.Database, sharing *SharingService, delegations *DelegationService) *AccessService {
+	return &AccessService{
+		repo:        repo,
+		db:          db,
+		sharing:     sharing,
+		delegations: delegations,
+	}
+}
+
+// ReminderAccess returns the access userID has to a reminder. The owner has
+// full access, an "edit" share or an accepted delegation allows changes, and
+// a "view" share allows reading.
+func (s *AccessService) ReminderAccess(ctx context.Context, reminderID, userID string) (Access, error) {
+	reminder, err := s.repo.GetByID(ctx, reminderID)
+	if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, primitive.ErrInvalidHex) {
+		return AccessNone, ErrResourceNotFound
+	}
+	if err != nil {
+		return AccessNone, err
+	}
+	if reminder.UserID == userID {
+		return AccessOwner, nil
+	}
+
+	access := AccessNone
+	shared, permission, err := s.sharing.HasAccess(ctx, reminderID, userID)
+	if err != nil {
+		return AccessNone, err
+	}
+	if shared {
+		access = AccessView
+		if permission == models.SharePermissionEdit {
+			return AccessEdit, nil
+		}
+	}
+
+	delegated, err := s.delegations.IsAccepted(ctx, reminderID, userID)
+	if err != nil {
+		return AccessNone, err
+	}
+	if delegated {
+		return AccessEdit, nil
+	}
+	return access, nil
+}
+
+// VisibleReminders filters reminderIDs down to those userID may view.
+func (s *AccessService) VisibleReminders(ctx context.Context, reminderIDs []string, userID string) ([]string, error) {
+	var visible []string
+	for _, id := range reminderIDs {
+		access, err := s.ReminderAccess(ctx, id, userID)
+		if errors.Is(err, ErrResourceNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if access >= AccessView {
+			visible = append(visible, id)
+		}
+	}
+	return visible, nil
+}
+
+// ViewAccess returns the access userID has to a saved view: full for its
+// owner, otherwise whatever a view share grants.
+func (s *AccessService) ViewAccess(ctx context.Context, viewID, userID string) (Access, error) {
+	ref, err := s.Resource(ctx, ResourceView, viewID)
+	if err != nil {
+		return AccessNone, err
+	}
+	if ref.Owner == userID {
+		return AccessOwner, nil
+	}
+
+	var share models.ViewShare
+	err = s.db.Collection("reminder_view_shares").FindOne(ctx, bson.M{"view_id": viewID, "shared_with": userID}).Decode(&share)
+	if err == mongo.ErrNoDocuments {
+		return AccessNone, nil
+	}
+	if err != nil {
+		return AccessNone, err
+	}
+	if share.Permission == models.SharePermissionEdit {
+		return AccessEdit, nil
+	}
+	return AccessView, nil
+}
+
+// Resource looks up the owner of a resource and the reminder it belongs to.
+func (s *AccessService) Resource(ctx context.Context, kind ResourceKind, id string) (*ResourceRef, error) {
+	spec, ok := resourceSpecs[kind]
+	if !ok {
+		return nil, errors.New("unknown resource kind " + string(kind))
+	}
+	objID, err := objectIDFromHex(id)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+
+	var doc bson.M
+	projection := bson.M{"is_shared": 1}
+	if spec.ownerField != "" {
+		projection[spec.ownerField] = 1
+	}
+	if spec.reminderField != "" {
+		projection[spec.reminderField] = 1
+	}
+	err = s.db.Collection(spec.collection).FindOne(ctx, bson.M{"_id": objID},
+		options.FindOne().SetProjection(projection)).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ref := &ResourceRef{}
+	ref.Owner, _ = doc[spec.ownerField].(string)
+	ref.ReminderID, _ = doc[spec.reminderField].(string)
+	ref.Shared, _ = doc["is_shared"].(bool)
+	return ref, nil
+}
@@ -2,35 +2,71 @@ package service
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
 	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ActivityService keeps the activity log. Services do not log changes
+// themselves: the collections they write are wrapped with audit.Wrap, which
+// hands every change to Record. Entries expire after the retention period.
 type ActivityService struct {
 	collection *mongo.Collection
+	retention  time.Duration
 }
 
-func NewActivityService(db *mongo.Database) *ActivityService {
-	return &ActivityService{collection: db.Collection("reminder_activity")}
+func NewActivityService(db *mongo.Database, retention time.Duration) *ActivityService {
+	// Changes hold nested documents; read them as maps so they are served
+	// as objects.
+	registry := bson.NewRegistry()
+	registry.RegisterTypeMapEntry(bsontype.EmbeddedDocument, reflect.TypeOf(bson.M{}))
+
+	collection := db.Collection("reminder_activity", options.Collection().SetRegistry(registry))
+	_, _ = collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "reminder_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return &ActivityService{collection: collection, retention: retention}
+}
+
+// Record stores entries, stamping each with its expiry. It implements
+// audit.Sink.
+func (s *ActivityService) Record(ctx context.Context, entries ...*models.ReminderActivity) error {
+	docs := make([]any, len(entries))
+	for i, e := range entries {
+		if s.retention > 0 {
+			expiresAt := e.CreatedAt.Add(s.retention)
+			e.ExpiresAt = &expiresAt
+		}
+		docs[i] = e
+	}
+	_, err := s.collection.InsertMany(ctx, docs)
+	return err
 }
 
 func (s *ActivityService) Log(ctx context.Context, reminderID, userID, action string, details map[string]any) error {
-	activity := &models.ReminderActivity{
+	return s.Record(ctx, &models.ReminderActivity{
 		ReminderID: reminderID,
 		UserID:     userID,
 		Action:     action,
+		Source:     audit.Source(ctx),
 		Details:    details,
 		CreatedAt:  time.Now(),
-	}
-
-	_, err := s.collection.InsertOne(ctx, activity)
-	return err
+	})
 }
 
 func (s *ActivityService) GetByReminder(ctx context.Context, reminderID string, limit int64) ([]models.ReminderActivity, error) {
@@ -91,4 +127,129 @@ func (s *ActivityService) GetRecent(ctx context.Context, workspaceID string, lim
 	return activities, nil
 }
 
+// ── Export ──
+
+// activityColumns are the CSV columns of an activity export.
+var activityColumns = []string{
+	"created_at", "source", "user_id", "action", "entity", "entity_id", "reminder_id", "changes", "details",
+}
+
+// Export writes the entries req selects to w, oldest first, as NDJSON (the
+// default) or CSV. Request errors are returned before anything is written.
+// If w has a Flush method it is called after every batch.
+func (s *ActivityService) Export(ctx context.Context, req *models.ActivityExportRequest, w io.Writer) error {
+	filter := bson.M{}
+	for field, value := range map[string]string{
+		"user_id":     req.UserID,
+		"reminder_id": req.ReminderID,
+		"entity":      req.Entity,
+		"source":      req.Source,
+	} {
+		if value != "" {
+			filter[field] = value
+		}
+	}
+	created := bson.M{}
+	if req.DateFrom != "" {
+		from, err := time.Parse("2006-01-02", req.DateFrom)
+		if err != nil {
+			return fmt.Errorf("%w: date_from must be YYYY-MM-DD", ErrInvalidExport)
+		}
+		created["$gte"] = from
+	}
+	if req.DateTo != "" {
+		to, err := time.Parse("2006-01-02", req.DateTo)
+		if err != nil {
+			return fmt.Errorf("%w: date_to must be YYYY-MM-DD", ErrInvalidExport)
+		}
+		created["$lt"] = to.AddDate(0, 0, 1)
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+
+	var write func(a *models.ReminderActivity) error
+	var flush func() error
+	switch req.Format {
+	case models.ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(activityColumns); err != nil {
+			return err
+		}
+		write = func(a *models.ReminderActivity) error {
+			changes, err := jsonCell(a.Changes)
+			if err != nil {
+				return err
+			}
+			details, err := jsonCell(a.Details)
+			if err != nil {
+				return err
+			}
+			record := []string{
+				a.CreatedAt.UTC().Format(time.RFC3339), a.Source, a.UserID, a.Action,
+				a.Entity, a.EntityID, a.ReminderID, changes, details,
+			}
+			for i, cell := range record {
+				record[i] = csvSafe(cell)
+			}
+			return cw.Write(record)
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case models.ExportNDJSON:
+		enc := json.NewEncoder(w)
+		write = func(a *models.ReminderActivity) error { return enc.Encode(a) }
+		flush = func() error { return nil }
+	default:
+		return fmt.Errorf("%w: unsupported format %q", ErrInvalidExport, req.Format)
+	}
+
+	cursor, err := s.collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetBatchSize(exportBatchSize))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	flushBatch := func() error {
+		if err := flush(); err != nil {
+			return err
+		}
+		if f, ok := w.(interface{ Flush() }); ok {
+			f.Flush()
+		}
+		return nil
+	}
+	for n := 1; cursor.Next(ctx); n++ {
+		var activity models.ReminderActivity
+		if err := cursor.Decode(&activity); err != nil {
+			return err
+		}
+		if err := write(&activity); err != nil {
+			return err
+		}
+		if n%exportBatchSize == 0 {
+			if err := flushBatch(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	return flushBatch()
+}
+
+// jsonCell renders a map for a CSV cell, leaving it blank when empty.
+func jsonCell(m any) (string, error) {
+	if reflect.ValueOf(m).Len() == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(m)
+	return string(b), err
+}
+
 var _ = primitive.ObjectID{}
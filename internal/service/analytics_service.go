@@ -15,12 +15,14 @@ import (
 type AnalyticsService struct {
 	repo       repository.Repository
 	collection *mongo.Collection // reminders collection for aggregation
+	deliveries *mongo.Collection
 }
 
 func NewAnalyticsService(repo repository.Repository, db *mongo.Database) *AnalyticsService {
 	return &AnalyticsService{
 		repo:       repo,
 		collection: db.Collection("reminders"),
+		deliveries: db.Collection("reminder_deliveries"),
 	}
 }
 
@@ -53,7 +55,7 @@ func (s *AnalyticsService) GetWorkspaceAnalytics(ctx context.Context, workspaceI
 	}
 
 	// By status
-	for _, status := range []string{"pending", "triggered", "completed", "cancelled", "snoozed"} {
+	for _, status := range []string{"pending", "triggered", "acknowledged", "completed", "cancelled", "snoozed", "archived"} {
 		count, _ := s.collection.CountDocuments(ctx, bson.M{"workspace_id": workspaceID, "status": status})
 		analytics.ByStatus[status] = count
 	}
@@ -98,6 +100,85 @@ func (s *AnalyticsService) GetWorkspaceAnalytics(ctx context.Context, workspaceI
 	return analytics, nil
 }
 
+// GetAcknowledgementAnalytics reports how the workspace's reminders
+// triggered since since were acknowledged, counting those left
+// unacknowledged for more than afterMins minutes, and how each channel's
+// notifications were received.
+func (s *AnalyticsService) GetAcknowledgementAnalytics(ctx context.Context, workspaceID string, since time.Time, afterMins int) (*models.AcknowledgementAnalytics, error) {
+	analytics := &models.AcknowledgementAnalytics{
+		Since:     since,
+		AfterMins: afterMins,
+		ByChannel: []models.ChannelDeliveryStats{},
+	}
+
+	after := time.Duration(afterMins) * time.Minute
+	acked := bson.M{"$ifNull": bson.A{"$acknowledged_at", false}}
+	ackMillis := bson.M{"$subtract": bson.A{"$acknowledged_at", "$triggered_at"}}
+	late := bson.M{"$and": bson.A{
+		bson.M{"$lte": bson.A{"$triggered_at", time.Now().Add(-after)}},
+		bson.M{"$or": bson.A{
+			bson.M{"$not": bson.A{acked}},
+			bson.M{"$gt": bson.A{ackMillis, after.Milliseconds()}},
+		}},
+	}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"workspace_id": workspaceID, "triggered_at": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                  nil,
+			"triggered":            bson.M{"$sum": 1},
+			"acknowledged":         bson.M{"$sum": bson.M{"$cond": bson.A{acked, 1, 0}}},
+			"unacknowledged_after": bson.M{"$sum": bson.M{"$cond": bson.A{late, 1, 0}}},
+			"avg_ack_millis":       bson.M{"$avg": bson.M{"$cond": bson.A{acked, ackMillis, nil}}},
+		}}},
+	}
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if cursor.Next(ctx) {
+		var result struct {
+			Triggered           int64   `bson:"triggered"`
+			Acknowledged        int64   `bson:"acknowledged"`
+			UnacknowledgedAfter int64   `bson:"unacknowledged_after"`
+			AvgAckMillis        float64 `bson:"avg_ack_millis"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+		analytics.Triggered = result.Triggered
+		analytics.Acknowledged = result.Acknowledged
+		analytics.UnacknowledgedAfter = result.UnacknowledgedAfter
+		analytics.AvgAckSeconds = result.AvgAckMillis / 1000
+		if result.Triggered > 0 {
+			analytics.AckRate = float64(result.Acknowledged) / float64(result.Triggered) * 100
+		}
+	}
+
+	counted := func(field string) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{field, false}}, 1, 0}}}
+	}
+	channels, err := s.deliveries.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"workspace_id": workspaceID, "sent_at": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$channel",
+			"sent":      bson.M{"$sum": 1},
+			"delivered": counted("$delivered_at"),
+			"read":      counted("$read_at"),
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer channels.Close(ctx)
+	if err := channels.All(ctx, &analytics.ByChannel); err != nil {
+		return nil, err
+	}
+
+	return analytics, nil
+}
+
 func (s *AnalyticsService) GetUpcoming(ctx context.Context, userID string, days, limit int) ([]*models.Reminder, error) {
 	if days <= 0 {
 		days = 7
@@ -4,10 +4,14 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
+	"reminder-service/internal/ical"
 	"reminder-service/internal/models"
+	"reminder-service/internal/recurrence"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,58 +22,76 @@ import (
 type CalendarService struct {
 	syncCollection *mongo.Collection
 	remCollection  *mongo.Collection
+	views          *ViewService
 }
 
-func NewCalendarService(db *mongo.Database) *CalendarService {
+func NewCalendarService(db *mongo.Database, views *ViewService) *CalendarService {
+	syncCollection := db.Collection("reminder_calendar_syncs")
+	_, _ = syncCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "feed_token", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "provider", Value: 1}, {Key: "view_id", Value: 1}}},
+	})
+
 	return &CalendarService{
-		syncCollection: db.Collection("reminder_calendar_syncs"),
+		syncCollection: syncCollection,
 		remCollection:  db.Collection("reminders"),
+		views:          views,
 	}
 }
 
 func (s *CalendarService) ExportICal(ctx context.Context, userID string) (string, error) {
-	cursor, err := s.remCollection.Find(ctx, bson.M{"user_id": userID, "status": "pending"},
-		options.Find().SetSort(bson.D{{Key: "remind_at", Value: 1}}))
+	cal, err := s.buildCalendar(ctx, userID, "")
 	if err != nil {
 		return "", err
 	}
-	defer cursor.Close(ctx)
+	return string(cal), nil
+}
 
-	var reminders []*models.Reminder
-	if err := cursor.All(ctx, &reminders); err != nil {
-		return "", err
-	}
+// ── Feeds ──
 
-	ical := "BEGIN:VCALENDAR\nVERSION:2.0\nPRODID:-//QuckApp//Reminder//EN\n"
-	for _, r := range reminders {
-		ical += fmt.Sprintf("BEGIN:VEVENT\nUID:%s\nDTSTART:%s\nSUMMARY:%s\nDESCRIPTION:%s\nEND:VEVENT\n",
-			r.ID.Hex(), r.RemindAt.Format("20060102T150405Z"), r.Title, r.Description)
+// A user has one feed per provider, plus one per provider for each saved
+// view they subscribe to.
+func feedKey(userID, provider, viewID string) bson.M {
+	key := bson.M{"user_id": userID, "provider": provider, "view_id": nil}
+	if viewID != "" {
+		key["view_id"] = viewID
 	}
-	ical += "END:VCALENDAR"
-	return ical, nil
+	return key
 }
 
 func (s *CalendarService) GetFeedURL(ctx context.Context, userID, workspaceID string, req *models.CalendarFeedRequest) (*models.CalendarSync, error) {
+	if req.ViewID != "" {
+		if _, err := s.views.Filter(ctx, req.ViewID, userID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if feed already exists
 	var existing models.CalendarSync
-	err := s.syncCollection.FindOne(ctx, bson.M{"user_id": userID, "provider": req.Provider}).Decode(&existing)
+	err := s.syncCollection.FindOne(ctx, feedKey(userID, req.Provider, req.ViewID)).Decode(&existing)
 	if err == nil {
-		return &existing, nil
+		if existing.FeedToken != "" {
+			return &existing, nil
+		}
+		// A revoked feed is re-enabled with a fresh token.
+		return s.RotateFeedToken(ctx, userID, req.Provider, req.ViewID)
 	}
 
-	// Generate feed token
-	tokenBytes := make([]byte, 32)
-	if _, err := rand.Read(tokenBytes); err != nil {
+	token, err := newFeedToken()
+	if err != nil {
 		return nil, err
 	}
-	token := hex.EncodeToString(tokenBytes)
 
 	sync := &models.CalendarSync{
 		UserID:      userID,
 		WorkspaceID: workspaceID,
 		Provider:    req.Provider,
 		FeedToken:   token,
-		FeedURL:     fmt.Sprintf("/api/v1/calendar/feed/%s", token),
+		FeedURL:     feedURL(token),
+		ViewID:      req.ViewID,
 		SyncEnabled: true,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -83,6 +105,231 @@ func (s *CalendarService) GetFeedURL(ctx context.Context, userID, workspaceID st
 	return sync, nil
 }
 
+// RotateFeedToken replaces the feed token, so the old feed URL stops
+// working immediately.
+func (s *CalendarService) RotateFeedToken(ctx context.Context, userID, provider, viewID string) (*models.CalendarSync, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var sync models.CalendarSync
+	err = s.syncCollection.FindOneAndUpdate(ctx,
+		feedKey(userID, provider, viewID),
+		bson.M{
+			"$set": bson.M{
+				"feed_token":   token,
+				"feed_url":     feedURL(token),
+				"sync_enabled": true,
+				"updated_at":   time.Now(),
+			},
+			"$unset": bson.M{"revoked_at": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&sync)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sync, nil
+}
+
+// RevokeFeed disables the feed and drops its token.
+func (s *CalendarService) RevokeFeed(ctx context.Context, userID, provider, viewID string) error {
+	now := time.Now()
+	result, err := s.syncCollection.UpdateOne(ctx,
+		feedKey(userID, provider, viewID),
+		bson.M{
+			"$set":   bson.M{"sync_enabled": false, "revoked_at": now, "updated_at": now},
+			"$unset": bson.M{"feed_token": "", "feed_url": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrResourceNotFound
+	}
+	return nil
+}
+
+// Feed renders the calendar behind a feed token. Unknown and revoked tokens
+// return ErrResourceNotFound, as do feeds of views that were deleted or are
+// no longer open to the subscriber.
+func (s *CalendarService) Feed(ctx context.Context, token string) ([]byte, error) {
+	var sync models.CalendarSync
+	err := s.syncCollection.FindOne(ctx, bson.M{"feed_token": token, "sync_enabled": true}).Decode(&sync)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, _ = s.syncCollection.UpdateOne(ctx, bson.M{"_id": sync.ID},
+		bson.M{"$set": bson.M{"last_synced_at": now}})
+
+	cal, err := s.buildCalendar(ctx, sync.UserID, sync.ViewID)
+	if errors.Is(err, ErrViewForbidden) {
+		return nil, ErrResourceNotFound
+	}
+	return cal, err
+}
+
+func newFeedToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+func feedURL(token string) string {
+	return fmt.Sprintf("/api/v1/calendar/feed/%s", token)
+}
+
+// ── ICS Rendering ──
+
+// feedHistory is how far back finished reminders stay in a feed.
+const feedHistory = 90 * 24 * time.Hour
+
+// buildCalendar renders a user's open reminders plus recent history, or
+// those of a saved view when viewID is set. Cancelled reminders stay in the
+// feed with STATUS:CANCELLED so subscribed clients remove them.
+func (s *CalendarService) buildCalendar(ctx context.Context, userID, viewID string) ([]byte, error) {
+	scope, err := s.scope(ctx, userID, viewID)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"$and": []bson.M{scope, {
+			"$or": []bson.M{
+				{"status": bson.M{"$in": []models.ReminderStatus{models.StatusPending, models.StatusSnoozed, models.StatusProcessing}}},
+				{"remind_at": bson.M{"$gte": time.Now().Add(-feedHistory)}},
+			},
+		}},
+	}
+	cursor, err := s.remCollection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "remind_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reminders []*models.Reminder
+	if err := cursor.All(ctx, &reminders); err != nil {
+		return nil, err
+	}
+
+	cal := &ical.Calendar{
+		ProdID: "-//QuckApp//Reminder//EN",
+		Name:   "Reminders",
+		Events: make([]ical.Event, 0, len(reminders)),
+	}
+	for _, r := range reminders {
+		cal.Events = append(cal.Events, reminderEvent(r))
+	}
+	return ical.Marshal(cal), nil
+}
+
+func reminderEvent(r *models.Reminder) ical.Event {
+	stamp := r.UpdatedAt
+	if stamp.IsZero() {
+		stamp = r.CreatedAt
+	}
+
+	e := ical.Event{
+		UID:          r.ID.Hex() + "@reminder-service",
+		Stamp:        stamp,
+		LastModified: stamp,
+		Start:        r.RemindAt,
+		Duration:     30 * time.Minute,
+		Summary:      r.Title,
+		Description:  r.Description,
+		Status:       eventStatus(r.Status),
+		Priority:     eventPriority(r.Priority),
+		Alarms:       []ical.Alarm{{Description: r.Title}},
+	}
+	if r.NotifyAt != nil && r.NotifyAt.Before(r.RemindAt) {
+		e.Alarms[0].Before = r.RemindAt.Sub(*r.NotifyAt)
+	}
+
+	// Only the open occurrence of a series carries the rule; earlier
+	// occurrences are separate reminders and appear as single events.
+	if r.Recurrence != nil && isOpen(r.Status) {
+		if rule, loc, err := feedRule(r.Recurrence, r.RemindAt); err == nil && rule != nil {
+			e.RRule = rule.String()
+			e.Location = loc
+			e.ExDates = r.Recurrence.ExDates
+		} else if err != nil {
+			log.Printf("Omitting recurrence of reminder %s from feed: %v", r.ID.Hex(), err)
+		}
+	}
+	return e
+}
+
+// feedRule returns the series rule restated to start at from. A COUNT is
+// converted to the UNTIL of the last remaining occurrence, since earlier
+// occurrences are not part of this event. It returns nil when nothing
+// remains.
+func feedRule(rec *models.Recurrence, from time.Time) (*recurrence.Rule, *time.Location, error) {
+	rule, loc, err := seriesRule(rec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rule.UntilLocal {
+		u := rule.Until
+		rule.Until = time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, loc).UTC()
+		rule.UntilLocal = false
+	}
+	if rule.Count > 0 {
+		start := from
+		if rec.Start != nil {
+			start = *rec.Start
+		}
+		remaining := recurrence.NewSchedule(rule, start.In(loc), rec.ExDates).Between(from, from.AddDate(100, 0, 0), 0)
+		if len(remaining) == 0 {
+			return nil, nil, nil
+		}
+		rule.Count = 0
+		rule.Until = remaining[len(remaining)-1].UTC()
+	}
+	return rule, loc, nil
+}
+
+func isOpen(status models.ReminderStatus) bool {
+	switch status {
+	case models.StatusPending, models.StatusSnoozed, models.StatusProcessing:
+		return true
+	}
+	return false
+}
+
+func eventStatus(status models.ReminderStatus) string {
+	if status == models.StatusCancelled {
+		return ical.StatusCancelled
+	}
+	return ical.StatusConfirmed
+}
+
+func eventPriority(p models.ReminderPriority) int {
+	switch p {
+	case models.PriorityUrgent:
+		return 1
+	case models.PriorityHigh:
+		return 3
+	case models.PriorityMedium:
+		return 5
+	case models.PriorityLow:
+		return 9
+	}
+	return 0
+}
+
 func (s *CalendarService) SyncCalendar(ctx context.Context, userID string) error {
 	now := time.Now()
 	_, err := s.syncCollection.UpdateMany(ctx,
@@ -92,13 +339,26 @@ func (s *CalendarService) SyncCalendar(ctx context.Context, userID string) error
 	return err
 }
 
-func (s *CalendarService) GetCalendarView(ctx context.Context, userID string, start, end time.Time) ([]models.CalendarEvent, error) {
+// scope selects the user's own reminders, or those of a saved view.
+func (s *CalendarService) scope(ctx context.Context, userID, viewID string) (bson.M, error) {
+	if viewID == "" {
+		return bson.M{"user_id": userID}, nil
+	}
+	return s.views.Filter(ctx, viewID, userID)
+}
+
+func (s *CalendarService) GetCalendarView(ctx context.Context, userID, viewID string, start, end time.Time) ([]models.CalendarEvent, error) {
+	scope, err := s.scope(ctx, userID, viewID)
+	if err != nil {
+		return nil, err
+	}
 	filter := bson.M{
-		"user_id": userID,
-		"remind_at": bson.M{
-			"$gte": start,
-			"$lte": end,
-		},
+		"$and": []bson.M{scope, {
+			"remind_at": bson.M{
+				"$gte": start,
+				"$lte": end,
+			},
+		}},
 	}
 
 	cursor, err := s.remCollection.Find(ctx, filter,
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// commandLogRetention bounds how long an idempotency key is honoured.
+const commandLogRetention = 7 * 24 * time.Hour
+
+// commandLease is how long a reservation keeps other consumers off a
+// command. It outlasts the consumer's 30-second handling timeout.
+const commandLease = 2 * time.Minute
+
+// ErrCommandInProgress is returned by Reserve while another consumer holds
+// the command's idempotency key.
+var ErrCommandInProgress = errors.New("command is already being processed")
+
+// errCommandAbandoned answers a command whose reservation outlived its lease.
+const errCommandAbandoned = "an earlier attempt stopped before finishing; the command may or may not have been applied"
+
+// CommandLogService stores the reply for every processed Kafka command so a
+// redelivered command is answered from the log instead of being re-applied.
+type CommandLogService struct {
+	collection *mongo.Collection
+}
+
+func NewCommandLogService(db *mongo.Database) *CommandLogService {
+	collection := db.Collection("reminder_command_log")
+	_, _ = collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "idempotency_key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "processed_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(commandLogRetention.Seconds()))},
+	})
+	return &CommandLogService{collection: collection}
+}
+
+// Lookup returns the stored reply for key, or nil if the key is new.
+func (s *CommandLogService) Lookup(ctx context.Context, key string) (*models.CommandReply, error) {
+	var reply models.CommandReply
+	err := s.collection.FindOne(ctx, bson.M{"idempotency_key": key}).Decode(&reply)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// Reserve claims the idempotency key of reply before its command runs, by
+// inserting an in-progress entry the unique index lets only one consumer
+// create. It returns nil once the key is reserved, the stored reply if the
+// command already finished, and ErrCommandInProgress while another
+// consumer holds the key. A reservation older than commandLease was left
+// by a consumer that stopped mid-command; since the command may already
+// have been applied it is not run again but finished with an error.
+func (s *CommandLogService) Reserve(ctx context.Context, reply *models.CommandReply) (*models.CommandReply, error) {
+	now := time.Now()
+	reservation := *reply
+	reservation.Status = models.CommandInProgress
+	reservation.ReservedAt = now
+	reservation.ProcessedAt = now
+
+	_, err := s.collection.InsertOne(ctx, &reservation)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	prior, err := s.Lookup(ctx, reply.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case prior == nil:
+		// Released since the insert failed; the retry reserves it.
+		return nil, ErrCommandInProgress
+	case prior.Status != models.CommandInProgress:
+		return prior, nil
+	case now.Sub(prior.ReservedAt) < commandLease:
+		return nil, ErrCommandInProgress
+	}
+
+	var abandoned models.CommandReply
+	err = s.collection.FindOneAndUpdate(ctx,
+		bson.M{"idempotency_key": reply.IdempotencyKey, "status": models.CommandInProgress, "reserved_at": prior.ReservedAt},
+		bson.M{"$set": bson.M{"status": "error", "error": errCommandAbandoned, "processed_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&abandoned)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCommandInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &abandoned, nil
+}
+
+// Finish replaces the reservation for reply's key with the reply.
+func (s *CommandLogService) Finish(ctx context.Context, reply *models.CommandReply) error {
+	_, err := s.collection.UpdateOne(ctx,
+		bson.M{"idempotency_key": reply.IdempotencyKey, "status": models.CommandInProgress},
+		bson.M{"$set": reply, "$unset": bson.M{"reserved_at": ""}},
+	)
+	return err
+}
+
+// Release drops the reservation for key so a retry can run the command.
+func (s *CommandLogService) Release(ctx context.Context, key string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"idempotency_key": key, "status": models.CommandInProgress})
+	return err
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"reminder-service/internal/interfaces"
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DeadLetterService struct {
+	collection *mongo.Collection
+	publisher  interfaces.MessagePublisher
+}
+
+func NewDeadLetterService(db *mongo.Database, publisher interfaces.MessagePublisher) *DeadLetterService {
+	return &DeadLetterService{
+		collection: db.Collection("reminder_command_dlq"),
+		publisher:  publisher,
+	}
+}
+
+func (s *DeadLetterService) Record(ctx context.Context, dl *models.DeadLetter) error {
+	result, err := s.collection.InsertOne(ctx, dl)
+	if err != nil {
+		return err
+	}
+	dl.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (s *DeadLetterService) List(ctx context.Context, includeReplayed bool, limit int64) ([]models.DeadLetter, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	filter := bson.M{}
+	if !includeReplayed {
+		filter["replayed_at"] = bson.M{"$exists": false}
+	}
+
+	cursor, err := s.collection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "failed_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.DeadLetter
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Get returns a dead letter, or ErrResourceNotFound if there is none with id.
+func (s *DeadLetterService) Get(ctx context.Context, id string) (*models.DeadLetter, error) {
+	objID, err := objectIDFromHex(id)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+
+	var dl models.DeadLetter
+	err = s.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&dl)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dl, nil
+}
+
+// Replay republishes the original message to the topic it failed on. The
+// x-replay-of header links the new delivery back to the DLQ entry.
+func (s *DeadLetterService) Replay(ctx context.Context, id string) (*models.DeadLetter, error) {
+	dl, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(dl.Headers)+1)
+	for k, v := range dl.Headers {
+		headers[k] = v
+	}
+	headers["x-replay-of"] = dl.ID.Hex()
+
+	if err := s.publisher.PublishMessage(dl.Topic, dl.Key, []byte(dl.Value), headers); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = s.collection.UpdateOne(ctx, bson.M{"_id": dl.ID}, bson.M{
+		"$set": bson.M{"replayed_at": now},
+		"$inc": bson.M{"replay_count": 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dl.ReplayedAt = &now
+	dl.ReplayCount++
+	return dl, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,11 +14,15 @@ import (
 )
 
 type DelegationService struct {
-	collection *mongo.Collection
+	collection *audit.Collection
 }
 
-func NewDelegationService(db *mongo.Database) *DelegationService {
-	return &DelegationService{collection: db.Collection("reminder_delegations")}
+func NewDelegationService(db *mongo.Database, sink audit.Sink) *DelegationService {
+	collection := db.Collection("reminder_delegations")
+	_, _ = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "reminder_id", Value: 1}, {Key: "delegated_to", Value: 1}},
+	})
+	return &DelegationService{collection: audit.Wrap(collection, "delegation", "reminder_id", sink)}
 }
 
 func (s *DelegationService) Delegate(ctx context.Context, reminderID, delegatedBy string, req *models.DelegateRequest) (*models.ReminderDelegation, error) {
@@ -80,3 +85,16 @@ func (s *DelegationService) GetDelegatedReminders(ctx context.Context, userID st
 	}
 	return delegations, nil
 }
+
+// IsAccepted reports whether userID has accepted a delegation of the reminder.
+func (s *DelegationService) IsAccepted(ctx context.Context, reminderID, userID string) (bool, error) {
+	count, err := s.collection.CountDocuments(ctx, bson.M{
+		"reminder_id":  reminderID,
+		"delegated_to": userID,
+		"status":       models.DelegationAccepted,
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
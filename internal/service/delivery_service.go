@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DeliveryService struct {
+	collection *mongo.Collection
+}
+
+func NewDeliveryService(db *mongo.Database) *DeliveryService {
+	collection := db.Collection("reminder_deliveries")
+	_, _ = collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "reminder_id", Value: 1}, {Key: "channel", Value: 1}, {Key: "sent_at", Value: -1}}},
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "sent_at", Value: -1}}},
+	})
+	return &DeliveryService{collection: collection}
+}
+
+// Record stores a notification of reminder on channel. ReminderService
+// calls it in the transaction that queues the notification, so every
+// notifications.send has its attempt.
+func (s *DeliveryService) Record(ctx context.Context, reminder *models.Reminder, channel string, attempt int, sentAt time.Time) (*models.DeliveryAttempt, error) {
+	delivery := &models.DeliveryAttempt{
+		ReminderID:  reminder.ID.Hex(),
+		UserID:      reminder.UserID,
+		WorkspaceID: reminder.WorkspaceID,
+		Channel:     channel,
+		Attempt:     attempt,
+		Status:      models.DeliverySent,
+		SentAt:      sentAt,
+	}
+	result, err := s.collection.InsertOne(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+	delivery.ID = result.InsertedID.(primitive.ObjectID)
+	return delivery, nil
+}
+
+func (s *DeliveryService) List(ctx context.Context, reminderID string) ([]models.DeliveryAttempt, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"reminder_id": reminderID},
+		options.Find().SetSort(bson.D{{Key: "sent_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []models.DeliveryAttempt
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Mark applies a delivered or read receipt to its attempt. Receipts only
+// move an attempt forward, so duplicates and late delivered receipts are
+// no-ops; a read attempt counts as delivered too.
+func (s *DeliveryService) Mark(ctx context.Context, status string, r *models.DeliveryReceipt) error {
+	filter := bson.M{"reminder_id": r.ReminderID}
+	if r.DeliveryID != "" {
+		objID, err := objectIDFromHex(r.DeliveryID)
+		if err != nil {
+			return err
+		}
+		filter["_id"] = objID
+	} else if r.Channel != "" {
+		filter["channel"] = r.Channel
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "sent_at", Value: -1}})
+
+	var update any
+	switch status {
+	case models.DeliveryDelivered:
+		filter["status"] = models.DeliverySent
+		update = bson.M{"$set": bson.M{"status": models.DeliveryDelivered, "delivered_at": r.At}}
+	case models.DeliveryRead:
+		filter["status"] = bson.M{"$ne": models.DeliveryRead}
+		update = mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"status":       models.DeliveryRead,
+			"read_at":      r.At,
+			"delivered_at": bson.M{"$ifNull": bson.A{"$delivered_at", r.At}},
+		}}}}
+	default:
+		return nil
+	}
+
+	err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Err()
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,517 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"reminder-service/internal/audit"
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrInvalidDependency = errors.New("invalid dependency")
+	ErrDependencyCycle   = errors.New("dependency would create a cycle")
+	// ErrReminderBlocked is returned when completing a reminder whose
+	// completion prerequisites are still open.
+	ErrReminderBlocked = errors.New("reminder is blocked by incomplete prerequisites")
+)
+
+// Dependency types. A trigger dependency holds the reminder back from
+// triggering until the prerequisite is completed; a completion dependency
+// keeps it from being completed first.
+const (
+	DependencyTrigger    = "trigger"
+	DependencyCompletion = "completion"
+)
+
+// maxGraphNodes bounds how much of a dependency graph is loaded.
+const maxGraphNodes = 500
+
+// ReminderDependency makes ReminderID wait for DependsOnID. With a Lag,
+// completing the prerequisite also moves ReminderID to no earlier than the
+// completion time plus the lag.
+type ReminderDependency struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ReminderID  string             `bson:"reminder_id" json:"reminder_id"`
+	DependsOnID string             `bson:"depends_on_id" json:"depends_on_id"`
+	Type        string             `bson:"type" json:"type"`
+	Lag         string             `bson:"lag,omitempty" json:"lag,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// DependencyGraph is the connected dependency DAG around a reminder. Edges
+// point from a dependent to its prerequisite.
+type DependencyGraph struct {
+	Nodes []DependencyNode     `json:"nodes"`
+	Edges []ReminderDependency `json:"edges"`
+	// CriticalPath lists the open reminders, prerequisites first, whose
+	// chain decides when the last reminder in the graph can come due.
+	CriticalPath []string   `json:"critical_path"`
+	FinishAt     *time.Time `json:"finish_at,omitempty"`
+	Truncated    bool       `json:"truncated,omitempty"`
+}
+
+// DependencyNode is a reminder in a DependencyGraph. EarliestAt is the
+// soonest it can come due given its open prerequisites and their lags.
+type DependencyNode struct {
+	ReminderID string                `json:"reminder_id"`
+	Title      string                `json:"title,omitempty"`
+	Status     models.ReminderStatus `json:"status"`
+	RemindAt   time.Time             `json:"remind_at"`
+	EarliestAt *time.Time            `json:"earliest_at,omitempty"`
+	Blocked    bool                  `json:"blocked"`
+	Critical   bool                  `json:"critical"`
+}
+
+type DependencyService struct {
+	collection *audit.Collection
+	reminders  *audit.Collection
+}
+
+func NewDependencyService(db *mongo.Database, sink audit.Sink) *DependencyService {
+	collection := db.Collection("reminder_dependencies")
+	_, _ = collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "reminder_id", Value: 1}, {Key: "depends_on_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "depends_on_id", Value: 1}}},
+	})
+
+	return &DependencyService{
+		collection: audit.Wrap(collection, "dependency", "reminder_id", sink),
+		reminders:  audit.Wrap(db.Collection("reminders"), "reminder", "_id", sink),
+	}
+}
+
+// Add records d after checking that it keeps the graph acyclic, and blocks
+// the dependent when a trigger prerequisite is still open. The check runs
+// again after the insert, which is undone if a cycle appeared meanwhile.
+func (s *DependencyService) Add(ctx context.Context, d *ReminderDependency) error {
+	if d.Type == "" {
+		d.Type = DependencyTrigger
+	}
+	if d.Type != DependencyTrigger && d.Type != DependencyCompletion {
+		return fmt.Errorf("%w: type must be %s or %s", ErrInvalidDependency, DependencyTrigger, DependencyCompletion)
+	}
+	if d.Lag != "" {
+		if lag, err := time.ParseDuration(d.Lag); err != nil || lag < 0 {
+			return fmt.Errorf("%w: lag must be a non-negative duration such as 2h", ErrInvalidDependency)
+		}
+	}
+	if d.ReminderID == d.DependsOnID {
+		return fmt.Errorf("%w: a reminder cannot depend on itself", ErrInvalidDependency)
+	}
+
+	prerequisite, err := s.reminder(ctx, d.DependsOnID)
+	if err != nil {
+		return err
+	}
+	cycle, err := s.reaches(ctx, d.DependsOnID, d.ReminderID)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return ErrDependencyCycle
+	}
+
+	d.CreatedAt = time.Now()
+	result, err := s.collection.InsertOne(ctx, d)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: already depends on %s", ErrInvalidDependency, d.DependsOnID)
+	}
+	if err != nil {
+		return err
+	}
+	d.ID = result.InsertedID.(primitive.ObjectID)
+
+	// A concurrent Add may have closed a cycle the check above could not
+	// see yet. Checking again now that this edge is stored means at least
+	// one of two racing edges sees the other and is taken back.
+	cycle, err = s.reaches(ctx, d.DependsOnID, d.ReminderID)
+	if err == nil && cycle {
+		err = ErrDependencyCycle
+	}
+	if err != nil {
+		if _, delErr := s.collection.DeleteOne(ctx, bson.M{"_id": d.ID}); delErr != nil {
+			return delErr
+		}
+		return err
+	}
+
+	if d.Type == DependencyTrigger && !settled(prerequisite.Status) {
+		return s.block(ctx, d.ReminderID, d.DependsOnID)
+	}
+	return nil
+}
+
+// Remove deletes dependency id of reminderID. A dependency of another
+// reminder is reported as not found.
+func (s *DependencyService) Remove(ctx context.Context, reminderID, id string) error {
+	objID, err := objectIDFromHex(id)
+	if err != nil {
+		return ErrResourceNotFound
+	}
+
+	var d ReminderDependency
+	err = s.collection.FindOneAndDelete(ctx, bson.M{"_id": objID, "reminder_id": reminderID}).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrResourceNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.unblock(ctx, []string{d.ReminderID}, d.DependsOnID)
+}
+
+func (s *DependencyService) List(ctx context.Context, reminderID string) ([]ReminderDependency, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"reminder_id": reminderID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var deps []ReminderDependency
+	if err := cursor.All(ctx, &deps); err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
+
+// CheckCompletion returns ErrReminderBlocked, naming the open prerequisites,
+// when reminderID may not be completed yet.
+func (s *DependencyService) CheckCompletion(ctx context.Context, reminderID string) error {
+	cursor, err := s.collection.Find(ctx, bson.M{"reminder_id": reminderID, "type": DependencyCompletion})
+	if err != nil {
+		return err
+	}
+	var deps []ReminderDependency
+	if err := cursor.All(ctx, &deps); err != nil {
+		return err
+	}
+	if len(deps) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(deps))
+	for _, d := range deps {
+		if objID, err := objectIDFromHex(d.DependsOnID); err == nil {
+			ids = append(ids, objID)
+		}
+	}
+	cursor, err = s.reminders.Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "status": bson.M{"$nin": []models.ReminderStatus{models.StatusCompleted, models.StatusArchived}}},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	var open []models.Reminder
+	if err := cursor.All(ctx, &open); err != nil {
+		return err
+	}
+	if len(open) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(open))
+	for i, r := range open {
+		names[i] = r.ID.Hex()
+	}
+	return fmt.Errorf("%w: waiting on %s", ErrReminderBlocked, strings.Join(names, ", "))
+}
+
+// Release unblocks the reminders waiting on a prerequisite that was just
+// completed and returns their dependencies, so the caller can apply lags.
+func (s *DependencyService) Release(ctx context.Context, prerequisiteID string) ([]ReminderDependency, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"depends_on_id": prerequisiteID})
+	if err != nil {
+		return nil, err
+	}
+	var deps []ReminderDependency
+	if err := cursor.All(ctx, &deps); err != nil {
+		return nil, err
+	}
+
+	dependents := make([]string, 0, len(deps))
+	for _, d := range deps {
+		if d.Type == DependencyTrigger {
+			dependents = append(dependents, d.ReminderID)
+		}
+	}
+	if err := s.unblock(ctx, dependents, prerequisiteID); err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
+
+// Forget drops every dependency on or of deleted reminders. Reminders that
+// waited on them are unblocked.
+func (s *DependencyService) Forget(ctx context.Context, reminderIDs ...string) error {
+	if len(reminderIDs) == 0 {
+		return nil
+	}
+	_, err := s.collection.DeleteMany(ctx, bson.M{"$or": []bson.M{
+		{"reminder_id": bson.M{"$in": reminderIDs}},
+		{"depends_on_id": bson.M{"$in": reminderIDs}},
+	}})
+	if err != nil {
+		return err
+	}
+	_, err = s.reminders.UpdateMany(ctx,
+		bson.M{"blocked_by": bson.M{"$in": reminderIDs}},
+		bson.M{"$pull": bson.M{"blocked_by": bson.M{"$in": reminderIDs}}})
+	return err
+}
+
+func (s *DependencyService) block(ctx context.Context, reminderID, prerequisiteID string) error {
+	objID, err := objectIDFromHex(reminderID)
+	if err != nil {
+		return ErrResourceNotFound
+	}
+	_, err = s.reminders.UpdateOne(ctx, bson.M{"_id": objID},
+		bson.M{"$addToSet": bson.M{"blocked_by": prerequisiteID}})
+	return err
+}
+
+func (s *DependencyService) unblock(ctx context.Context, reminderIDs []string, prerequisiteID string) error {
+	ids := make([]primitive.ObjectID, 0, len(reminderIDs))
+	for _, id := range reminderIDs {
+		if objID, err := objectIDFromHex(id); err == nil {
+			ids = append(ids, objID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := s.reminders.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		bson.M{"$pull": bson.M{"blocked_by": prerequisiteID}})
+	return err
+}
+
+func (s *DependencyService) reminder(ctx context.Context, id string) (*models.Reminder, error) {
+	objID, err := objectIDFromHex(id)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+	var r models.Reminder
+	err = s.reminders.FindOne(ctx, bson.M{"_id": objID}).Decode(&r)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// reaches reports whether target is among the prerequisites of from,
+// directly or transitively.
+func (s *DependencyService) reaches(ctx context.Context, from, target string) (bool, error) {
+	seen := map[string]bool{from: true}
+	frontier := []string{from}
+	for len(frontier) > 0 {
+		cursor, err := s.collection.Find(ctx, bson.M{"reminder_id": bson.M{"$in": frontier}},
+			options.Find().SetProjection(bson.M{"depends_on_id": 1}))
+		if err != nil {
+			return false, err
+		}
+		var deps []ReminderDependency
+		if err := cursor.All(ctx, &deps); err != nil {
+			return false, err
+		}
+
+		frontier = frontier[:0]
+		for _, d := range deps {
+			if d.DependsOnID == target {
+				return true, nil
+			}
+			if !seen[d.DependsOnID] {
+				seen[d.DependsOnID] = true
+				frontier = append(frontier, d.DependsOnID)
+			}
+		}
+	}
+	return false, nil
+}
+
+// ── Graph ──
+
+// Graph loads the dependencies connected to reminderID in either direction
+// and works out when each open reminder can come due. Only completed
+// prerequisites no longer hold anything back.
+func (s *DependencyService) Graph(ctx context.Context, reminderID string) (*DependencyGraph, error) {
+	graph := &DependencyGraph{Nodes: []DependencyNode{}, Edges: []ReminderDependency{}, CriticalPath: []string{}}
+
+	seen := map[string]bool{reminderID: true}
+	edges := map[primitive.ObjectID]bool{}
+	frontier := []string{reminderID}
+	for len(frontier) > 0 {
+		cursor, err := s.collection.Find(ctx, bson.M{"$or": []bson.M{
+			{"reminder_id": bson.M{"$in": frontier}},
+			{"depends_on_id": bson.M{"$in": frontier}},
+		}})
+		if err != nil {
+			return nil, err
+		}
+		var deps []ReminderDependency
+		if err := cursor.All(ctx, &deps); err != nil {
+			return nil, err
+		}
+
+		frontier = nil
+		for _, d := range deps {
+			if edges[d.ID] {
+				continue
+			}
+			for _, id := range []string{d.ReminderID, d.DependsOnID} {
+				if seen[id] {
+					continue
+				}
+				if len(seen) >= maxGraphNodes {
+					graph.Truncated = true
+					continue
+				}
+				seen[id] = true
+				frontier = append(frontier, id)
+			}
+			if seen[d.ReminderID] && seen[d.DependsOnID] {
+				edges[d.ID] = true
+				graph.Edges = append(graph.Edges, d)
+			}
+		}
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(seen))
+	for id := range seen {
+		if objID, err := objectIDFromHex(id); err == nil {
+			ids = append(ids, objID)
+		}
+	}
+	cursor, err := s.reminders.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetSort(bson.D{{Key: "remind_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var reminders []models.Reminder
+	if err := cursor.All(ctx, &reminders); err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(reminders))
+	for _, r := range reminders {
+		index[r.ID.Hex()] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, DependencyNode{
+			ReminderID: r.ID.Hex(),
+			Title:      r.Title,
+			Status:     r.Status,
+			RemindAt:   r.RemindAt,
+		})
+	}
+
+	// Edges to deleted reminders carry no information.
+	kept := graph.Edges[:0]
+	for _, d := range graph.Edges {
+		_, from := index[d.ReminderID]
+		_, to := index[d.DependsOnID]
+		if from && to {
+			kept = append(kept, d)
+		}
+	}
+	graph.Edges = kept
+
+	graph.schedule(index)
+	return graph, nil
+}
+
+// schedule fills in EarliestAt, Blocked and the critical path. Nodes are
+// visited prerequisites first; a node is due no earlier than its own time
+// and than each open prerequisite plus the lag. The critical path follows,
+// from the latest open node, the prerequisite that comes due last.
+func (g *DependencyGraph) schedule(index map[string]int) {
+	open := func(n *DependencyNode) bool {
+		return !settled(n.Status)
+	}
+
+	prereqs := make([][]ReminderDependency, len(g.Nodes))
+	waiting := make([]int, len(g.Nodes))
+	for _, d := range g.Edges {
+		i := index[d.ReminderID]
+		prereqs[i] = append(prereqs[i], d)
+		waiting[i]++
+	}
+	dependents := make([][]int, len(g.Nodes))
+	for _, d := range g.Edges {
+		j := index[d.DependsOnID]
+		dependents[j] = append(dependents[j], index[d.ReminderID])
+	}
+
+	var order, ready []int
+	for i := range g.Nodes {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		order = append(order, i)
+		for _, j := range dependents[i] {
+			if waiting[j]--; waiting[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	via := make([]int, len(g.Nodes))
+	last := -1
+	for _, i := range order {
+		n := &g.Nodes[i]
+		via[i] = -1
+		if !open(n) {
+			continue
+		}
+		earliest := n.RemindAt
+		var latest time.Time
+		for _, d := range prereqs[i] {
+			j := index[d.DependsOnID]
+			p := &g.Nodes[j]
+			if !open(p) || p.EarliestAt == nil {
+				continue
+			}
+			n.Blocked = true
+			lag, _ := time.ParseDuration(d.Lag)
+			at := p.EarliestAt.Add(lag)
+			if via[i] < 0 || at.After(latest) {
+				via[i], latest = j, at
+			}
+			if at.After(earliest) {
+				earliest = at
+			}
+		}
+		n.EarliestAt = &earliest
+		if last < 0 || earliest.After(*g.Nodes[last].EarliestAt) {
+			last = i
+		}
+	}
+	if last < 0 {
+		return
+	}
+
+	g.FinishAt = g.Nodes[last].EarliestAt
+	for i := last; i >= 0; i = via[i] {
+		g.Nodes[i].Critical = true
+		g.CriticalPath = append(g.CriticalPath, g.Nodes[i].ReminderID)
+	}
+	for a, b := 0, len(g.CriticalPath)-1; a < b; a, b = a+1, b-1 {
+		g.CriticalPath[a], g.CriticalPath[b] = g.CriticalPath[b], g.CriticalPath[a]
+	}
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,28 +15,77 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrEscalationLeaseLost is returned when another worker took over an
+// escalation event.
+var ErrEscalationLeaseLost = errors.New("escalation event claimed by another worker")
+
+// ErrInvalidEscalationAction is returned for a rule with an action the
+// escalation worker cannot run.
+var ErrInvalidEscalationAction = errors.New("invalid escalation action")
+
+var escalationTypes = map[string]bool{
+	models.EscalationNotify:       true,
+	models.EscalationRenotify:     true,
+	models.EscalationPostChannel:  true,
+	models.EscalationBumpPriority: true,
+}
+
+// validateActions checks each action's type and condition, and that it is
+// not scheduled before the trigger.
+func validateActions(actions []models.EscalationAction) error {
+	for i, action := range actions {
+		if !escalationTypes[action.Type] {
+			return fmt.Errorf("%w: action %d has unknown type %q", ErrInvalidEscalationAction, i, action.Type)
+		}
+		switch action.Condition {
+		case "", models.EscalationWhenUnacknowledged, models.EscalationWhenOpen:
+		default:
+			return fmt.Errorf("%w: action %d has unknown condition %q", ErrInvalidEscalationAction, i, action.Condition)
+		}
+		if action.DelayMins < 0 {
+			return fmt.Errorf("%w: action %d has a negative delay", ErrInvalidEscalationAction, i)
+		}
+	}
+	return nil
+}
+
 type EscalationService struct {
-	ruleCollection  *mongo.Collection
+	ruleCollection  *audit.Collection
 	eventCollection *mongo.Collection
 }
 
-func NewEscalationService(db *mongo.Database) *EscalationService {
+func NewEscalationService(db *mongo.Database, sink audit.Sink) *EscalationService {
+	ruleCollection := db.Collection("reminder_escalation_rules")
+	eventCollection := db.Collection("reminder_escalation_events")
+	_, _ = ruleCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "enabled", Value: 1}},
+	})
+	_, _ = eventCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "scheduled_at", Value: 1}}},
+		{Keys: bson.D{{Key: "reminder_id", Value: 1}, {Key: "status", Value: 1}}},
+	})
+
 	return &EscalationService{
-		ruleCollection:  db.Collection("reminder_escalation_rules"),
-		eventCollection: db.Collection("reminder_escalation_events"),
+		ruleCollection:  audit.Wrap(ruleCollection, "escalation_rule", "", sink),
+		eventCollection: eventCollection,
 	}
 }
 
 func (s *EscalationService) CreateRule(ctx context.Context, userID, workspaceID string, req *models.CreateEscalationRequest) (*models.EscalationRule, error) {
+	if err := validateActions(req.Actions); err != nil {
+		return nil, err
+	}
+
 	rule := &models.EscalationRule{
-		UserID:      userID,
-		WorkspaceID: workspaceID,
-		Name:        req.Name,
-		Priority:    req.Priority,
-		Enabled:     req.Enabled,
-		Actions:     req.Actions,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		UserID:        userID,
+		WorkspaceID:   workspaceID,
+		WorkspaceWide: req.WorkspaceWide,
+		Name:          req.Name,
+		Priority:      req.Priority,
+		Enabled:       req.Enabled,
+		Actions:       req.Actions,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 
 	result, err := s.ruleCollection.InsertOne(ctx, rule)
@@ -81,6 +133,9 @@ func (s *EscalationService) UpdateRule(ctx context.Context, ruleID string, req *
 		update["enabled"] = *req.Enabled
 	}
 	if req.Actions != nil {
+		if err := validateActions(req.Actions); err != nil {
+			return nil, err
+		}
 		update["actions"] = req.Actions
 	}
 
@@ -107,7 +162,7 @@ func (s *EscalationService) DeleteRule(ctx context.Context, ruleID string) error
 
 func (s *EscalationService) GetHistory(ctx context.Context, reminderID string) ([]models.EscalationEvent, error) {
 	cursor, err := s.eventCollection.Find(ctx, bson.M{"reminder_id": reminderID},
-		options.Find().SetSort(bson.D{{Key: "executed_at", Value: -1}}))
+		options.Find().SetSort(bson.D{{Key: "scheduled_at", Value: -1}}))
 	if err != nil {
 		return nil, err
 	}
@@ -119,3 +174,137 @@ func (s *EscalationService) GetHistory(ctx context.Context, reminderID string) (
 	}
 	return events, nil
 }
+
+// ── Execution ──
+
+// Schedule queues every action of the enabled rules matching the reminder's
+// workspace and priority. Rules without a priority match any reminder, and
+// rules that are not workspace-wide only match their creator's reminders.
+func (s *EscalationService) Schedule(ctx context.Context, reminder *models.Reminder, triggeredAt time.Time) error {
+	filter := bson.M{
+		"workspace_id": reminder.WorkspaceID,
+		"enabled":      true,
+		"priority":     bson.M{"$in": []any{nil, "", reminder.Priority}},
+		"$or": []bson.M{
+			{"workspace_wide": true},
+			{"user_id": reminder.UserID},
+		},
+	}
+	cursor, err := s.ruleCollection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []models.EscalationRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return err
+	}
+
+	var events []any
+	for _, rule := range rules {
+		for i, action := range rule.Actions {
+			events = append(events, &models.EscalationEvent{
+				ReminderID:       reminder.ID.Hex(),
+				EscalationRuleID: rule.ID.Hex(),
+				ActionIndex:      i,
+				Action:           action,
+				Status:           models.EscalationScheduled,
+				ScheduledAt:      triggeredAt.Add(time.Duration(action.DelayMins) * time.Minute),
+			})
+		}
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	_, err = s.eventCollection.InsertMany(ctx, events)
+	return err
+}
+
+// CancelPending stops the escalation chains of the given reminders.
+func (s *EscalationService) CancelPending(ctx context.Context, reminderIDs ...string) error {
+	now := time.Now()
+	_, err := s.eventCollection.UpdateMany(ctx, bson.M{
+		"reminder_id": bson.M{"$in": reminderIDs},
+		"status":      models.EscalationScheduled,
+	}, bson.M{
+		"$set":   bson.M{"status": models.EscalationCancelled, "executed_at": now},
+		"$unset": bson.M{"lease_owner": "", "lease_expires_at": ""},
+	})
+	return err
+}
+
+// CancelUnacknowledged stops the escalations of an acknowledged reminder
+// that only run while it is unacknowledged. "open" actions stay scheduled.
+func (s *EscalationService) CancelUnacknowledged(ctx context.Context, reminderID string) error {
+	now := time.Now()
+	_, err := s.eventCollection.UpdateMany(ctx, bson.M{
+		"reminder_id":      reminderID,
+		"status":           models.EscalationScheduled,
+		"action.condition": bson.M{"$ne": models.EscalationWhenOpen},
+	}, bson.M{
+		"$set":   bson.M{"status": models.EscalationCancelled, "reason": "reminder acknowledged", "executed_at": now},
+		"$unset": bson.M{"lease_owner": "", "lease_expires_at": ""},
+	})
+	return err
+}
+
+// ClaimDueEvent leases the oldest scheduled event that is due. It returns nil
+// when nothing is due.
+func (s *EscalationService) ClaimDueEvent(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.EscalationEvent, error) {
+	filter := bson.M{
+		"status":       models.EscalationScheduled,
+		"scheduled_at": bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"lease_expires_at": nil},
+			{"lease_expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"lease_owner": owner, "lease_expires_at": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "scheduled_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var event models.EscalationEvent
+	err := s.eventCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// FinishEvent records the outcome of a claimed event. It fails with
+// ErrEscalationLeaseLost if the caller no longer holds the lease.
+func (s *EscalationService) FinishEvent(ctx context.Context, event *models.EscalationEvent, status, reason string) error {
+	now := time.Now()
+	set := bson.M{"status": status, "executed_at": now}
+	if reason != "" {
+		set["reason"] = reason
+	}
+
+	result, err := s.eventCollection.UpdateOne(ctx, bson.M{
+		"_id":         event.ID,
+		"status":      models.EscalationScheduled,
+		"lease_owner": event.LeaseOwner,
+	}, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"lease_owner": "", "lease_expires_at": ""},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrEscalationLeaseLost
+	}
+
+	event.Status = status
+	event.Reason = reason
+	event.ExecutedAt = &now
+	return nil
+}
@@ -1,34 +1,56 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"reminder-service/internal/audit"
+	"reminder-service/internal/ical"
 	"reminder-service/internal/models"
+	"reminder-service/internal/recurrence"
 	"reminder-service/internal/repository"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ExportService struct {
 	repo       repository.Repository
-	collection *mongo.Collection
+	reminders  *ReminderService
+	collection *audit.Collection
 }
 
-func NewExportService(repo repository.Repository, db *mongo.Database) *ExportService {
+func NewExportService(repo repository.Repository, db *mongo.Database, reminders *ReminderService, sink audit.Sink) *ExportService {
 	return &ExportService{
 		repo:       repo,
-		collection: db.Collection("reminders"),
+		reminders:  reminders,
+		collection: audit.Wrap(db.Collection("reminders"), "reminder", "_id", sink),
 	}
 }
 
+var ErrInvalidExport = errors.New("invalid export request")
+
+// Progress receives the work done since its last call: items processed, how
+// many of them failed and their error messages. Returning an error stops the
+// export or import, which then returns that error.
+type Progress func(processed, failed int, errs []string) error
+
 func (s *ExportService) Export(ctx context.Context, req *models.ExportRequest) (*models.ExportResponse, error) {
-	filter := bson.M{"user_id": req.UserID}
-	if req.Status != "" {
-		filter["status"] = req.Status
+	filter, err := s.exportFilter(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
 	cursor, err := s.collection.Find(ctx, filter,
@@ -51,30 +73,839 @@ func (s *ExportService) Export(ctx context.Context, req *models.ExportRequest) (
 	}, nil
 }
 
+// exportFilter applies the search filters. An export is either one user's
+// reminders or a whole workspace's.
+func (s *ExportService) exportFilter(ctx context.Context, req *models.ExportRequest) (bson.M, error) {
+	if req.UserID == "" && req.WorkspaceID == "" {
+		return nil, fmt.Errorf("%w: export requires a user or workspace", ErrInvalidExport)
+	}
+	return reminderFilter(ctx, s.collection.Database(), &req.ReminderFilter)
+}
+
+// ── Streaming Export ──
+
+// exportBatchSize is how many reminders are held in memory at once while
+// streaming, along with their related records.
+const exportBatchSize = 200
+
+// exportRow is a reminder with the related records the export includes.
+type exportRow struct {
+	reminder *models.Reminder
+	tags     []string
+	notes    []models.ReminderNote
+	subtasks []models.ReminderSubtask
+	comments []ReminderComment
+}
+
+type exportColumn struct {
+	value func(row *exportRow) any
+	// include names the related records the column needs loaded.
+	include string
+}
+
+var exportColumns = map[string]exportColumn{
+	"id":              {value: func(r *exportRow) any { return r.reminder.ID.Hex() }},
+	"user_id":         {value: func(r *exportRow) any { return r.reminder.UserID }},
+	"workspace_id":    {value: func(r *exportRow) any { return r.reminder.WorkspaceID }},
+	"channel_id":      {value: func(r *exportRow) any { return r.reminder.ChannelID }},
+	"message_id":      {value: func(r *exportRow) any { return r.reminder.MessageID }},
+	"type":            {value: func(r *exportRow) any { return r.reminder.Type }},
+	"title":           {value: func(r *exportRow) any { return r.reminder.Title }},
+	"description":     {value: func(r *exportRow) any { return r.reminder.Description }},
+	"status":          {value: func(r *exportRow) any { return r.reminder.Status }},
+	"priority":        {value: func(r *exportRow) any { return r.reminder.Priority }},
+	"remind_at":       {value: func(r *exportRow) any { return r.reminder.RemindAt }},
+	"notify_at":       {value: func(r *exportRow) any { return r.reminder.NotifyAt }},
+	"recurrence":      {value: func(r *exportRow) any { return r.reminder.Recurrence }},
+	"created_at":      {value: func(r *exportRow) any { return r.reminder.CreatedAt }},
+	"updated_at":      {value: func(r *exportRow) any { return r.reminder.UpdatedAt }},
+	"triggered_at":    {value: func(r *exportRow) any { return r.reminder.TriggeredAt }},
+	"acknowledged_at": {value: func(r *exportRow) any { return r.reminder.AcknowledgedAt }},
+	"tags":            {value: func(r *exportRow) any { return r.tags }, include: "tags"},
+	"notes":           {value: func(r *exportRow) any { return r.notes }, include: "notes"},
+	"subtasks":        {value: func(r *exportRow) any { return r.subtasks }, include: "subtasks"},
+	"comments":        {value: func(r *exportRow) any { return r.comments }, include: "comments"},
+}
+
+var defaultExportColumns = []string{
+	"id", "workspace_id", "channel_id", "type", "title", "description",
+	"status", "priority", "remind_at", "recurrence", "created_at", "updated_at",
+}
+
+// Count returns how many reminders an export will contain.
+func (s *ExportService) Count(ctx context.Context, req *models.ExportRequest) (int64, error) {
+	filter, err := s.exportFilter(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	return s.collection.CountDocuments(ctx, filter)
+}
+
+// Stream writes the export to w as CSV or NDJSON, reading reminders from a
+// cursor in batches so memory use does not grow with the export. Request
+// errors are returned before anything is written. If w has a Flush method
+// it is called after every batch, as is progress when it is not nil.
+func (s *ExportService) Stream(ctx context.Context, req *models.ExportRequest, w io.Writer, progress Progress) error {
+	filter, err := s.exportFilter(ctx, req)
+	if err != nil {
+		return err
+	}
+	columns, includes, err := exportLayout(req)
+	if err != nil {
+		return err
+	}
+
+	var enc rowEncoder
+	switch req.Format {
+	case models.ExportCSV:
+		enc = newCSVEncoder(w, columns)
+	case models.ExportNDJSON:
+		enc = &ndjsonEncoder{w: w, columns: columns}
+	default:
+		return fmt.Errorf("%w: unsupported format %q", ErrInvalidExport, req.Format)
+	}
+
+	cursor, err := s.collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "remind_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetBatchSize(exportBatchSize))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	batch := make([]*exportRow, 0, exportBatchSize)
+	flush := func() error {
+		if err := s.loadRelated(ctx, batch, includes); err != nil {
+			return err
+		}
+		for _, row := range batch {
+			if err := enc.write(row); err != nil {
+				return err
+			}
+		}
+		if err := enc.flush(); err != nil {
+			return err
+		}
+		if f, ok := w.(interface{ Flush() }); ok {
+			f.Flush()
+		}
+		if progress != nil && len(batch) > 0 {
+			if err := progress(len(batch), 0, nil); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	if err := enc.header(); err != nil {
+		return err
+	}
+	for cursor.Next(ctx) {
+		var reminder models.Reminder
+		if err := cursor.Decode(&reminder); err != nil {
+			return err
+		}
+		batch = append(batch, &exportRow{reminder: &reminder})
+		if len(batch) == exportBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// exportLayout resolves the requested columns and includes. Included
+// relations are appended as columns unless already listed.
+func exportLayout(req *models.ExportRequest) ([]string, map[string]bool, error) {
+	columns := req.Columns
+	if len(columns) == 0 {
+		columns = defaultExportColumns
+	}
+	columns = append([]string(nil), columns...)
+
+	listed := map[string]bool{}
+	for _, name := range columns {
+		if _, ok := exportColumns[name]; !ok {
+			return nil, nil, fmt.Errorf("%w: unknown column %q", ErrInvalidExport, name)
+		}
+		if listed[name] {
+			return nil, nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidExport, name)
+		}
+		listed[name] = true
+	}
+	for _, name := range req.Include {
+		if col, ok := exportColumns[name]; !ok || col.include == "" {
+			return nil, nil, fmt.Errorf("%w: unknown include %q", ErrInvalidExport, name)
+		}
+		if !listed[name] {
+			columns = append(columns, name)
+			listed[name] = true
+		}
+	}
+
+	includes := map[string]bool{}
+	for _, name := range columns {
+		if inc := exportColumns[name].include; inc != "" {
+			includes[inc] = true
+		}
+	}
+	return columns, includes, nil
+}
+
+// loadRelated fills in the included records for a batch with one query per
+// relation.
+func (s *ExportService) loadRelated(ctx context.Context, rows []*exportRow, includes map[string]bool) error {
+	if len(rows) == 0 || len(includes) == 0 {
+		return nil
+	}
+	byID := make(map[string]*exportRow, len(rows))
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		id := row.reminder.ID.Hex()
+		byID[id] = row
+		ids = append(ids, id)
+	}
+	db := s.collection.Database()
+	inBatch := bson.M{"reminder_id": bson.M{"$in": ids}}
+	byCreated := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	if includes["tags"] {
+		if err := s.loadTags(ctx, byID, ids); err != nil {
+			return err
+		}
+	}
+	if includes["notes"] {
+		var notes []models.ReminderNote
+		if err := findAll(ctx, db.Collection("reminder_notes"), inBatch, byCreated, &notes); err != nil {
+			return err
+		}
+		for _, n := range notes {
+			if row := byID[n.ReminderID]; row != nil {
+				row.notes = append(row.notes, n)
+			}
+		}
+	}
+	if includes["subtasks"] {
+		var subtasks []models.ReminderSubtask
+		bySortOrder := options.Find().SetSort(bson.D{{Key: "sort_order", Value: 1}})
+		if err := findAll(ctx, db.Collection("reminder_subtasks"), inBatch, bySortOrder, &subtasks); err != nil {
+			return err
+		}
+		for _, st := range subtasks {
+			if row := byID[st.ReminderID]; row != nil {
+				row.subtasks = append(row.subtasks, st)
+			}
+		}
+	}
+	if includes["comments"] {
+		var comments []ReminderComment
+		if err := findAll(ctx, db.Collection("reminder_comments"), inBatch, byCreated, &comments); err != nil {
+			return err
+		}
+		for _, cm := range comments {
+			if row := byID[cm.ReminderID]; row != nil {
+				row.comments = append(row.comments, cm)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *ExportService) loadTags(ctx context.Context, byID map[string]*exportRow, ids []string) error {
+	db := s.collection.Database()
+	var mappings []struct {
+		ReminderID string `bson:"reminder_id"`
+		TagID      string `bson:"tag_id"`
+	}
+	if err := findAll(ctx, db.Collection("reminder_tag_mappings"),
+		bson.M{"reminder_id": bson.M{"$in": ids}}, options.Find(), &mappings); err != nil {
+		return err
+	}
+
+	var tagIDs []primitive.ObjectID
+	for _, m := range mappings {
+		if oid, err := primitive.ObjectIDFromHex(m.TagID); err == nil {
+			tagIDs = append(tagIDs, oid)
+		}
+	}
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	var tags []models.ReminderTag
+	if err := findAll(ctx, db.Collection("reminder_tags"),
+		bson.M{"_id": bson.M{"$in": tagIDs}},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}), &tags); err != nil {
+		return err
+	}
+	names := make(map[string]string, len(tags))
+	for _, t := range tags {
+		names[t.ID.Hex()] = t.Name
+	}
+	for _, m := range mappings {
+		if name, ok := names[m.TagID]; ok {
+			row := byID[m.ReminderID]
+			row.tags = append(row.tags, name)
+		}
+	}
+	for _, row := range byID {
+		sort.Strings(row.tags)
+	}
+	return nil
+}
+
+func findAll(ctx context.Context, coll *mongo.Collection, filter bson.M, opts *options.FindOptions, out any) error {
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, out)
+}
+
+// ── Row Encoders ──
+
+type rowEncoder interface {
+	header() error
+	write(row *exportRow) error
+	flush() error
+}
+
+type csvEncoder struct {
+	w       *csv.Writer
+	columns []string
+	record  []string
+}
+
+func newCSVEncoder(w io.Writer, columns []string) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+}
+
+func (e *csvEncoder) header() error {
+	return e.w.Write(e.columns)
+}
+
+func (e *csvEncoder) write(row *exportRow) error {
+	for i, name := range e.columns {
+		cell, err := csvCell(exportColumns[name].value(row))
+		if err != nil {
+			return err
+		}
+		e.record[i] = csvSafe(cell)
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvCell renders a value for a CSV cell: times as RFC 3339, tag names
+// comma-separated, and structured values as JSON.
+func csvCell(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case models.ReminderType:
+		return string(v), nil
+	case models.ReminderStatus:
+		return string(v), nil
+	case models.ReminderPriority:
+		return string(v), nil
+	case time.Time:
+		if v.IsZero() {
+			return "", nil
+		}
+		return v.UTC().Format(time.RFC3339), nil
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		return v.UTC().Format(time.RFC3339), nil
+	case []string:
+		return strings.Join(v, ", "), nil
+	case *models.Recurrence:
+		if v == nil {
+			return "", nil
+		}
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.Len() == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// csvSafe stops spreadsheets from reading a cell as a formula by prefixing
+// cells that start with =, +, -, @, a tab or a carriage return with a quote.
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// ndjsonEncoder writes one JSON object per line with keys in column order.
+type ndjsonEncoder struct {
+	w       io.Writer
+	columns []string
+	buf     bytes.Buffer
+}
+
+func (e *ndjsonEncoder) header() error { return nil }
+
+func (e *ndjsonEncoder) write(row *exportRow) error {
+	e.buf.WriteByte('{')
+	for i, name := range e.columns {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		v := exportColumns[name].value(row)
+		// Included relations are lists even when empty.
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+			v = []any{}
+		}
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		e.buf.Write(key)
+		e.buf.WriteByte(':')
+		e.buf.Write(value)
+	}
+	e.buf.WriteString("}\n")
+	return nil
+}
+
+func (e *ndjsonEncoder) flush() error {
+	_, err := e.w.Write(e.buf.Bytes())
+	e.buf.Reset()
+	return err
+}
+
+// importBatchSize is how many reminders an import writes at once.
+const importBatchSize = 100
+
+var errMissingImportFields = errors.New("title, type, workspace_id and remind_at are required")
+
 func (s *ExportService) Import(ctx context.Context, userID string, req *models.ImportRequest) *models.ImportResponse {
+	// Without a progress callback there is nothing to stop the import early.
+	resp, _ := s.ImportReminders(ctx, req.Reminders, nil)
+	return resp
+}
+
+// ImportReminders creates reminders in batches. Invalid requests and failed
+// writes are counted per reminder; only an error from progress stops the
+// import.
+func (s *ExportService) ImportReminders(ctx context.Context, reqs []models.CreateReminderRequest, progress Progress) (*models.ImportResponse, error) {
 	resp := &models.ImportResponse{}
 
-	for _, reminderReq := range req.Reminders {
-		reminder := &models.Reminder{
-			UserID:      reminderReq.UserID,
-			WorkspaceID: reminderReq.WorkspaceID,
-			ChannelID:   reminderReq.ChannelID,
-			MessageID:   reminderReq.MessageID,
-			Type:        reminderReq.Type,
-			Title:       reminderReq.Title,
-			Description: reminderReq.Description,
-			RemindAt:    reminderReq.RemindAt,
-			Recurrence:  reminderReq.Recurrence,
-			Metadata:    reminderReq.Metadata,
-		}
-
-		if err := s.repo.Create(ctx, reminder); err != nil {
+	for start := 0; start < len(reqs); start += importBatchSize {
+		end := min(start+importBatchSize, len(reqs))
+		var errs []string
+		failed := 0
+		failItem := func(title string, err error) {
+			failed++
+			errs = append(errs, fmt.Sprintf("%s: %s", title, err.Error()))
+		}
+
+		var batch []*models.Reminder
+		for _, reminderReq := range reqs[start:end] {
+			if reminderReq.Title == "" || reminderReq.Type == "" || reminderReq.WorkspaceID == "" || reminderReq.RemindAt.IsZero() {
+				failItem(reminderReq.Title, errMissingImportFields)
+				continue
+			}
+			rec, err := seriesRecurrence(reminderReq.Recurrence, reminderReq.RemindAt)
+			if err != nil {
+				failItem(reminderReq.Title, err)
+				continue
+			}
+			batch = append(batch, &models.Reminder{
+				UserID:      reminderReq.UserID,
+				WorkspaceID: reminderReq.WorkspaceID,
+				ChannelID:   reminderReq.ChannelID,
+				MessageID:   reminderReq.MessageID,
+				Type:        reminderReq.Type,
+				Title:       reminderReq.Title,
+				Description: reminderReq.Description,
+				RemindAt:    reminderReq.RemindAt,
+				CategoryID:  reminderReq.CategoryID,
+				Recurrence:  rec,
+				Metadata:    reminderReq.Metadata,
+			})
+		}
+
+		itemErrs, err := s.repo.CreateMany(ctx, batch)
+		for i, reminder := range batch {
+			switch {
+			case err != nil:
+				failItem(reminder.Title, err)
+			case itemErrs[i] != nil:
+				failItem(reminder.Title, itemErrs[i])
+			default:
+				resp.Imported++
+			}
+		}
+		resp.Failed += failed
+		resp.Errors = append(resp.Errors, errs...)
+
+		if progress != nil {
+			if err := progress(end-start, failed, errs); err != nil {
+				return resp, err
+			}
+		}
+	}
+	return resp, nil
+}
+
+// ── ICS Import ──
+
+// allDayReminderHour is when all-day calendar entries remind, in the
+// import's floating timezone.
+const allDayReminderHour = 9
+
+// icsItem is one VEVENT or VTODO on its way to becoming a reminder.
+type icsItem struct {
+	result    models.ImportItemResult
+	reminder  *models.Reminder
+	cancelled bool
+	// recurrenceID is set on overrides of a single occurrence of a series.
+	recurrenceID *time.Time
+}
+
+// ImportICS creates reminders from the VEVENTs and VTODOs of an iCalendar
+// file. Items are matched to earlier imports by UID, so importing the same
+// file again updates reminders instead of duplicating them. Floating times
+// and all-day entries are read in floating. A malformed file or an error
+// from progress is returned; problems with single items are reported per
+// item.
+func (s *ExportService) ImportICS(ctx context.Context, userID, workspaceID string, floating *time.Location, r io.Reader, progress Progress) (*models.ImportResponse, error) {
+	cal, err := ical.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	resolve := cal.Resolver()
+	now := time.Now()
+
+	var items []*icsItem
+	for _, comp := range cal.Components {
+		if comp.Name != "VEVENT" && comp.Name != "VTODO" {
+			continue
+		}
+		item := icsToReminder(comp, resolve, floating)
+		if item.reminder != nil {
+			item.reminder.UserID = userID
+			item.reminder.WorkspaceID = workspaceID
+		}
+		items = append(items, item)
+	}
+
+	// A RECURRENCE-ID component replaces one occurrence of its series: the
+	// series skips that date and the override becomes a reminder of its own.
+	masters := map[string]*models.Reminder{}
+	for _, item := range items {
+		if item.reminder != nil && item.recurrenceID == nil && item.reminder.Recurrence != nil {
+			masters[item.result.UID] = item.reminder
+		}
+	}
+	for _, item := range items {
+		if item.reminder == nil || item.recurrenceID == nil {
+			continue
+		}
+		if master, ok := masters[item.result.UID]; ok {
+			master.Recurrence.ExDates = append(master.Recurrence.ExDates, *item.recurrenceID)
+		}
+		item.reminder.ExternalUID = item.result.UID + "#" + item.recurrenceID.UTC().Format("20060102T150405Z")
+	}
+
+	resp := &models.ImportResponse{}
+	var processed, failed int
+	var errs []string
+	for i, item := range items {
+		if item.reminder != nil {
+			s.importItem(ctx, item, now)
+		}
+
+		switch item.result.Status {
+		case models.ImportCreated:
+			resp.Imported++
+		case models.ImportUpdated:
+			resp.Updated++
+		case models.ImportSkipped:
+			resp.Skipped++
+		case models.ImportFailed:
+			msg := fmt.Sprintf("%s: %s", item.result.Title, item.result.Message)
 			resp.Failed++
-			resp.Errors = append(resp.Errors, fmt.Sprintf("%s: %s", reminderReq.Title, err.Error()))
+			resp.Errors = append(resp.Errors, msg)
+			failed++
+			errs = append(errs, msg)
+		}
+		resp.Items = append(resp.Items, item.result)
+
+		processed++
+		if progress != nil && (processed == importBatchSize || i == len(items)-1) {
+			if err := progress(processed, failed, errs); err != nil {
+				return resp, err
+			}
+			processed, failed, errs = 0, 0, nil
+		}
+	}
+	return resp, nil
+}
+
+// importItem schedules the reminder for its next occurrence and then
+// creates it, or updates the reminder an earlier import created unless that
+// one is completed or archived. Both go through the reminder service, so
+// they are validated and announced like any other change.
+func (s *ExportService) importItem(ctx context.Context, item *icsItem, now time.Time) {
+	reminder := item.reminder
+	fail := func(err error) {
+		item.result.Status = models.ImportFailed
+		item.result.Message = err.Error()
+	}
+
+	if rec := reminder.Recurrence; rec != nil {
+		schedule, err := reminderSchedule(rec, *rec.Start)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if reminder.RemindAt.Before(now) {
+			next, ok := schedule.Next(now)
+			if !ok {
+				item.result.Status = models.ImportSkipped
+				item.result.Message = "series has ended"
+				return
+			}
+			if reminder.NotifyAt != nil {
+				notifyAt := next.Add(reminder.NotifyAt.Sub(reminder.RemindAt))
+				reminder.NotifyAt = &notifyAt
+			}
+			reminder.RemindAt = next
+		}
+	}
+
+	// Occurrences of an imported series share its UID; re-imports update
+	// the latest one.
+	var existing models.Reminder
+	found := false
+	if reminder.ExternalUID != "" {
+		err := s.collection.FindOne(ctx,
+			bson.M{"user_id": reminder.UserID, "external_uid": reminder.ExternalUID},
+			options.FindOne().SetSort(bson.D{{Key: "remind_at", Value: -1}}),
+		).Decode(&existing)
+		if err != nil && err != mongo.ErrNoDocuments {
+			fail(err)
+			return
+		}
+		found = err == nil
+	}
+
+	switch {
+	case item.cancelled && !found:
+		item.result.Status = models.ImportSkipped
+		item.result.Message = "cancelled in source"
+		return
+	case !found && reminder.RemindAt.Before(now):
+		item.result.Status = models.ImportSkipped
+		item.result.Message = "in the past"
+		return
+	case found && settled(existing.Status):
+		item.result.Status = models.ImportSkipped
+		item.result.Message = fmt.Sprintf("already %s", existing.Status)
+		item.result.ReminderID = existing.ID.Hex()
+		return
+	}
+
+	if !found {
+		if err := s.reminders.Import(ctx, reminder); err != nil {
+			fail(err)
+			return
+		}
+		item.result.Status = models.ImportCreated
+		item.result.ReminderID = reminder.ID.Hex()
+		return
+	}
+
+	req := &models.UpdateReminderRequest{
+		Title:       reminder.Title,
+		Description: reminder.Description,
+		RemindAt:    &reminder.RemindAt,
+		Priority:    reminder.Priority,
+		Recurrence:  reminder.Recurrence,
+		NotifyAt:    reminder.NotifyAt,
+	}
+	// Cancelling in the source cancels the reminder only where the state
+	// machine allows it.
+	if item.cancelled && checkTransition(existing.Status, models.StatusCancelled) == nil {
+		req.Status = models.StatusCancelled
+	}
+	if _, err := s.reminders.Update(ctx, existing.ID.Hex(), req); err != nil {
+		fail(err)
+		return
+	}
+	item.result.Status = models.ImportUpdated
+	item.result.ReminderID = existing.ID.Hex()
+}
+
+// icsToReminder maps a VEVENT or VTODO onto a reminder. Events remind at
+// their start and to-dos at their due time. The earliest VALARM sets
+// NotifyAt. On failure the item has no reminder and a failed or skipped
+// result.
+func icsToReminder(comp *ical.Component, resolve ical.Resolver, floating *time.Location) *icsItem {
+	item := &icsItem{result: models.ImportItemResult{
+		UID:       comp.Text("UID"),
+		Component: comp.Name,
+		Title:     comp.Text("SUMMARY"),
+	}}
+	fail := func(format string, args ...any) *icsItem {
+		item.result.Status = models.ImportFailed
+		item.result.Message = fmt.Sprintf(format, args...)
+		return item
+	}
+	if item.result.Title == "" {
+		item.result.Title = "(untitled)"
+	}
+
+	status := strings.ToUpper(comp.Text("STATUS"))
+	if status == "COMPLETED" {
+		item.result.Status = models.ImportSkipped
+		item.result.Message = "already completed"
+		return item
+	}
+	item.cancelled = status == "CANCELLED"
+
+	reminderType := models.ReminderTypeCustom
+	start := comp.Prop("DTSTART")
+	at := start
+	if comp.Name == "VTODO" {
+		reminderType = models.ReminderTypeTask
+		if due := comp.Prop("DUE"); due != nil {
+			at = due
+		}
+	}
+	if at == nil {
+		return fail("missing DTSTART")
+	}
+
+	remindAt, allDay, err := at.Time(resolve, floating)
+	if err != nil {
+		return fail("%s: %v", at.Name, err)
+	}
+	loc := remindAt.Location()
+	if allDay {
+		remindAt = remindAt.Add(allDayReminderHour * time.Hour)
+	}
+
+	reminder := &models.Reminder{
+		ExternalUID: item.result.UID,
+		Type:        reminderType,
+		Title:       item.result.Title,
+		Description: comp.Text("DESCRIPTION"),
+		RemindAt:    remindAt,
+		Priority:    icsPriority(comp.Text("PRIORITY")),
+	}
+
+	if p := comp.Prop("RECURRENCE-ID"); p != nil {
+		rid, _, err := p.Time(resolve, floating)
+		if err != nil {
+			return fail("RECURRENCE-ID: %v", err)
+		}
+		if allDay {
+			rid = rid.Add(allDayReminderHour * time.Hour)
+		}
+		item.recurrenceID = &rid
+	}
+
+	if p := comp.Prop("RRULE"); p != nil && item.recurrenceID == nil {
+		rule, err := recurrence.Parse(p.Value)
+		if err != nil {
+			return fail("RRULE: %v", err)
+		}
+		rec := &models.Recurrence{
+			RRule:    rule.String(),
+			Timezone: loc.String(),
+			Start:    &remindAt,
+		}
+		for _, ex := range comp.PropsNamed("EXDATE") {
+			dates, err := ex.Times(resolve, floating)
+			if err != nil {
+				return fail("EXDATE: %v", err)
+			}
+			for _, d := range dates {
+				if allDay {
+					d = d.Add(allDayReminderHour * time.Hour)
+				}
+				rec.ExDates = append(rec.ExDates, d)
+			}
+		}
+		reminder.Recurrence = rec
+		if comp.Prop("RDATE") != nil {
+			item.result.Message = "RDATE is not supported and was ignored"
+		}
+	}
+
+	notifyAt, err := icsAlarm(comp, resolve, floating, remindAt)
+	if err != nil {
+		return fail("VALARM: %v", err)
+	}
+	reminder.NotifyAt = notifyAt
+
+	item.reminder = reminder
+	return item
+}
+
+// icsAlarm returns the time of the earliest VALARM, or nil if there is none.
+// Relative triggers are taken from the reminder time.
+func icsAlarm(comp *ical.Component, resolve ical.Resolver, floating *time.Location, remindAt time.Time) (*time.Time, error) {
+	var earliest *time.Time
+	for _, alarm := range comp.Children("VALARM") {
+		trigger := alarm.Prop("TRIGGER")
+		if trigger == nil {
+			continue
+		}
+
+		var at time.Time
+		if trigger.Params["VALUE"] == "DATE-TIME" {
+			t, _, err := trigger.Time(resolve, floating)
+			if err != nil {
+				return nil, err
+			}
+			at = t
 		} else {
-			resp.Imported++
+			d, err := ical.ParseDuration(trigger.Value)
+			if err != nil {
+				return nil, err
+			}
+			at = remindAt.Add(d)
+		}
+		if earliest == nil || at.Before(*earliest) {
+			earliest = &at
 		}
 	}
+	return earliest, nil
+}
 
-	return resp
+// icsPriority maps PRIORITY (1 highest, 9 lowest, 0 undefined) onto ours.
+// Undefined priorities are medium.
+func icsPriority(value string) models.ReminderPriority {
+	p, err := strconv.Atoi(strings.TrimSpace(value))
+	switch {
+	case err != nil || p <= 0:
+		return models.PriorityMedium
+	case p == 1:
+		return models.PriorityUrgent
+	case p <= 4:
+		return models.PriorityHigh
+	case p == 5:
+		return models.PriorityMedium
+	default:
+		return models.PriorityLow
+	}
 }
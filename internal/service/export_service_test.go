@@ -0,0 +1,243 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"reminder-service/internal/ical"
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// decodeItems parses an iCalendar file and maps its events and to-dos as
+// ImportICS does.
+func decodeItems(t *testing.T, data []byte) []*icsItem {
+	t.Helper()
+	cal, err := ical.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	resolve := cal.Resolver()
+	var items []*icsItem
+	for _, comp := range cal.Components {
+		if comp.Name == "VEVENT" || comp.Name == "VTODO" {
+			items = append(items, icsToReminder(comp, resolve, time.UTC))
+		}
+	}
+	return items
+}
+
+func TestICSRoundTrip(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone unavailable: %v", err)
+	}
+	remindAt := time.Date(2025, 3, 28, 9, 0, 0, 0, berlin).UTC()
+	notifyAt := remindAt.Add(-15 * time.Minute)
+	updated := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		reminder  models.Reminder
+		cancelled bool
+		series    bool
+	}{
+		{
+			name: "single",
+			reminder: models.Reminder{
+				Title:       "Pay rent, monthly; on time",
+				Description: "Transfer\nto the landlord",
+				RemindAt:    remindAt,
+				NotifyAt:    &notifyAt,
+				Priority:    models.PriorityHigh,
+				Status:      models.StatusPending,
+			},
+		},
+		{
+			name: "series",
+			reminder: models.Reminder{
+				Title:    "Stand-up",
+				RemindAt: remindAt,
+				Priority: models.PriorityUrgent,
+				Status:   models.StatusPending,
+				Recurrence: &models.Recurrence{
+					RRule:    "FREQ=WEEKLY;BYDAY=FR",
+					Timezone: "Europe/Berlin",
+					ExDates:  []time.Time{remindAt.AddDate(0, 0, 7)},
+				},
+			},
+			series: true,
+		},
+		{
+			name: "cancelled series is a single event",
+			reminder: models.Reminder{
+				Title:      "Retro",
+				RemindAt:   remindAt,
+				Priority:   models.PriorityLow,
+				Status:     models.StatusCancelled,
+				Recurrence: &models.Recurrence{RRule: "FREQ=DAILY", Timezone: "Europe/Berlin"},
+			},
+			cancelled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.reminder
+			r.ID = primitive.NewObjectID()
+			r.UpdatedAt = updated
+
+			data := ical.Marshal(&ical.Calendar{ProdID: "-//test//EN", Events: []ical.Event{reminderEvent(&r)}})
+			items := decodeItems(t, data)
+			if len(items) != 1 {
+				t.Fatalf("got %d items, want 1", len(items))
+			}
+			item := items[0]
+			got := item.reminder
+			if got == nil {
+				t.Fatalf("import failed: %+v", item.result)
+			}
+
+			if got.ExternalUID != r.ID.Hex()+"@reminder-service" {
+				t.Errorf("ExternalUID = %q", got.ExternalUID)
+			}
+			if got.Title != r.Title || got.Description != r.Description {
+				t.Errorf("Title, Description = %q, %q, want %q, %q", got.Title, got.Description, r.Title, r.Description)
+			}
+			if !got.RemindAt.Equal(r.RemindAt) {
+				t.Errorf("RemindAt = %v, want %v", got.RemindAt, r.RemindAt)
+			}
+			if got.Priority != r.Priority {
+				t.Errorf("Priority = %s, want %s", got.Priority, r.Priority)
+			}
+			wantNotify := r.RemindAt
+			if r.NotifyAt != nil {
+				wantNotify = *r.NotifyAt
+			}
+			if got.NotifyAt == nil || !got.NotifyAt.Equal(wantNotify) {
+				t.Errorf("NotifyAt = %v, want %v", got.NotifyAt, wantNotify)
+			}
+			if item.cancelled != tt.cancelled {
+				t.Errorf("cancelled = %v, want %v", item.cancelled, tt.cancelled)
+			}
+
+			if !tt.series {
+				if got.Recurrence != nil {
+					t.Errorf("Recurrence = %+v, want none", got.Recurrence)
+				}
+				return
+			}
+			if got.Recurrence == nil {
+				t.Fatal("Recurrence = nil")
+			}
+			want := r.Recurrence
+			if got.Recurrence.RRule != want.RRule || got.Recurrence.Timezone != want.Timezone {
+				t.Errorf("Recurrence = %s in %s, want %s in %s", got.Recurrence.RRule, got.Recurrence.Timezone, want.RRule, want.Timezone)
+			}
+			if len(got.Recurrence.ExDates) != len(want.ExDates) || !got.Recurrence.ExDates[0].Equal(want.ExDates[0]) {
+				t.Errorf("ExDates = %v, want %v", got.Recurrence.ExDates, want.ExDates)
+			}
+		})
+	}
+}
+
+func TestICSToReminder(t *testing.T) {
+	tests := []struct {
+		name      string
+		component string
+		status    models.ImportItemStatus
+		remindAt  time.Time
+		kind      models.ReminderType
+		notifyAt  *time.Time
+	}{
+		{
+			name:      "all-day event",
+			component: "BEGIN:VEVENT\nUID:1\nSUMMARY:Holiday\nDTSTART;VALUE=DATE:20250310\nEND:VEVENT",
+			remindAt:  time.Date(2025, 3, 10, allDayReminderHour, 0, 0, 0, time.UTC),
+			kind:      models.ReminderTypeCustom,
+		},
+		{
+			name:      "to-do due",
+			component: "BEGIN:VTODO\nUID:2\nSUMMARY:File taxes\nDTSTART:20250301T090000Z\nDUE:20250415T170000Z\nEND:VTODO",
+			remindAt:  time.Date(2025, 4, 15, 17, 0, 0, 0, time.UTC),
+			kind:      models.ReminderTypeTask,
+		},
+		{
+			name: "earliest alarm",
+			component: "BEGIN:VEVENT\nUID:3\nSUMMARY:Call\nDTSTART:20250310T090000Z\n" +
+				"BEGIN:VALARM\nTRIGGER:-PT10M\nEND:VALARM\n" +
+				"BEGIN:VALARM\nTRIGGER;VALUE=DATE-TIME:20250310T080000Z\nEND:VALARM\nEND:VEVENT",
+			remindAt: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+			kind:     models.ReminderTypeCustom,
+			notifyAt: func() *time.Time { t := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC); return &t }(),
+		},
+		{
+			name:      "completed is skipped",
+			component: "BEGIN:VTODO\nUID:4\nSUMMARY:Done\nSTATUS:COMPLETED\nDUE:20250415T170000Z\nEND:VTODO",
+			status:    models.ImportSkipped,
+		},
+		{
+			name:      "missing start",
+			component: "BEGIN:VEVENT\nUID:5\nSUMMARY:Nowhen\nEND:VEVENT",
+			status:    models.ImportFailed,
+		},
+		{
+			name:      "bad rule",
+			component: "BEGIN:VEVENT\nUID:6\nSUMMARY:Bad\nDTSTART:20250310T090000Z\nRRULE:FREQ=SOMETIMES\nEND:VEVENT",
+			status:    models.ImportFailed,
+		},
+		{
+			name:      "unknown zone",
+			component: "BEGIN:VEVENT\nUID:7\nSUMMARY:Where\nDTSTART;TZID=Mars:20250310T090000\nEND:VEVENT",
+			status:    models.ImportFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "BEGIN:VCALENDAR\nVERSION:2.0\n" + tt.component + "\nEND:VCALENDAR\n"
+			items := decodeItems(t, []byte(strings.ReplaceAll(data, "\n", "\r\n")))
+			if len(items) != 1 {
+				t.Fatalf("got %d items, want 1", len(items))
+			}
+			item := items[0]
+			if tt.status != "" {
+				if item.reminder != nil || item.result.Status != tt.status {
+					t.Errorf("result = %+v, want status %s", item.result, tt.status)
+				}
+				return
+			}
+			if item.reminder == nil {
+				t.Fatalf("import failed: %+v", item.result)
+			}
+			r := item.reminder
+			if !r.RemindAt.Equal(tt.remindAt) || r.Type != tt.kind {
+				t.Errorf("RemindAt, Type = %v, %s, want %v, %s", r.RemindAt, r.Type, tt.remindAt, tt.kind)
+			}
+			if (r.NotifyAt == nil) != (tt.notifyAt == nil) || (r.NotifyAt != nil && !r.NotifyAt.Equal(*tt.notifyAt)) {
+				t.Errorf("NotifyAt = %v, want %v", r.NotifyAt, tt.notifyAt)
+			}
+		})
+	}
+}
+
+func TestICSPriority(t *testing.T) {
+	tests := []struct {
+		in   string
+		want models.ReminderPriority
+	}{
+		{"", models.PriorityMedium},
+		{"0", models.PriorityMedium},
+		{"x", models.PriorityMedium},
+		{"1", models.PriorityUrgent},
+		{" 3 ", models.PriorityHigh},
+		{"5", models.PriorityMedium},
+		{"9", models.PriorityLow},
+	}
+	for _, tt := range tests {
+		if got := icsPriority(tt.in); got != tt.want {
+			t.Errorf("icsPriority(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
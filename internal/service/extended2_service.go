@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"log"
 	"time"
 
+	"reminder-service/internal/audit"
+
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -63,34 +66,6 @@ type ReminderFavorite struct {
 	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
 }
 
-type ReminderDependency struct {
-	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ReminderID        string             `bson:"reminder_id" json:"reminder_id"`
-	DependsOnID       string             `bson:"depends_on_id" json:"depends_on_id"`
-	Type              string             `bson:"type" json:"type"`
-	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
-}
-
-type ReminderLocation struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ReminderID string             `bson:"reminder_id" json:"reminder_id"`
-	Name       string             `bson:"name" json:"name"`
-	Latitude   float64            `bson:"latitude" json:"latitude"`
-	Longitude  float64            `bson:"longitude" json:"longitude"`
-	Radius     float64            `bson:"radius" json:"radius"`
-	TriggerOn  string             `bson:"trigger_on" json:"trigger_on"`
-	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
-}
-
-type ReminderSnoozeHistory struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ReminderID string             `bson:"reminder_id" json:"reminder_id"`
-	UserID     string             `bson:"user_id" json:"user_id"`
-	SnoozedAt  time.Time          `bson:"snoozed_at" json:"snoozed_at"`
-	Duration   string             `bson:"duration" json:"duration"`
-	NewTime    time.Time          `bson:"new_time" json:"new_time"`
-}
-
 type ReminderQuickAction struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID      string             `bson:"user_id" json:"user_id"`
@@ -105,15 +80,28 @@ type ReminderQuickAction struct {
 // ── Extended2 Service ──
 
 type Extended2Service struct {
-	db *mongo.Database
+	db   *mongo.Database
+	sink audit.Sink
 }
 
-func NewExtended2Service(db *mongo.Database) *Extended2Service {
-	return &Extended2Service{db: db}
+func NewExtended2Service(db *mongo.Database, sink audit.Sink) *Extended2Service {
+	return &Extended2Service{db: db, sink: sink}
 }
 
-func (s *Extended2Service) col(name string) *mongo.Collection {
-	return s.db.Collection(name)
+// extended2Entities names the records of each collection in the activity
+// log.
+var extended2Entities = map[string]string{
+	"reminder_attachments":   "attachment",
+	"reminder_comments":      "comment",
+	"reminder_reactions":     "reaction",
+	"reminder_watchers":      "watcher",
+	"reminder_labels":        "label",
+	"reminder_favorites":     "favorite",
+	"reminder_quick_actions": "quick_action",
+}
+
+func (s *Extended2Service) col(name string) *audit.Collection {
+	return audit.Wrap(s.db.Collection(name), extended2Entities[name], "reminder_id", s.sink)
 }
 
 // Attachments
@@ -142,7 +130,11 @@ func (s *Extended2Service) AddComment(ctx context.Context, c *ReminderComment) e
 	c.CreatedAt = time.Now()
 	c.UpdatedAt = time.Now()
 	_, err := s.col("reminder_comments").InsertOne(ctx, c)
-	return err
+	if err != nil { return err }
+	if err := refreshSearchText(ctx, s.db, c.ReminderID, "reminder_comments", "search_comments"); err != nil {
+		log.Printf("Error refreshing search text for reminder %s: %v", c.ReminderID, err)
+	}
+	return nil
 }
 
 func (s *Extended2Service) ListComments(ctx context.Context, reminderID string, limit, offset int) ([]ReminderComment, error) {
@@ -156,14 +148,26 @@ func (s *Extended2Service) ListComments(ctx context.Context, reminderID string,
 
 func (s *Extended2Service) UpdateComment(ctx context.Context, id, content string) error {
 	oid, _ := primitive.ObjectIDFromHex(id)
-	_, err := s.col("reminder_comments").UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{"content": content, "updated_at": time.Now()}})
-	return err
+	var c ReminderComment
+	err := s.col("reminder_comments").FindOneAndUpdate(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{"content": content, "updated_at": time.Now()}}).Decode(&c)
+	if err == mongo.ErrNoDocuments { return nil }
+	if err != nil { return err }
+	if err := refreshSearchText(ctx, s.db, c.ReminderID, "reminder_comments", "search_comments"); err != nil {
+		log.Printf("Error refreshing search text for reminder %s: %v", c.ReminderID, err)
+	}
+	return nil
 }
 
 func (s *Extended2Service) DeleteComment(ctx context.Context, id string) error {
 	oid, _ := primitive.ObjectIDFromHex(id)
-	_, err := s.col("reminder_comments").DeleteOne(ctx, bson.M{"_id": oid})
-	return err
+	var c ReminderComment
+	err := s.col("reminder_comments").FindOneAndDelete(ctx, bson.M{"_id": oid}).Decode(&c)
+	if err == mongo.ErrNoDocuments { return nil }
+	if err != nil { return err }
+	if err := refreshSearchText(ctx, s.db, c.ReminderID, "reminder_comments", "search_comments"); err != nil {
+		log.Printf("Error refreshing search text for reminder %s: %v", c.ReminderID, err)
+	}
+	return nil
 }
 
 // Reactions
@@ -261,73 +265,6 @@ func (s *Extended2Service) IsFavorited(ctx context.Context, reminderID, userID s
 	return count > 0, err
 }
 
-// Dependencies
-func (s *Extended2Service) AddDependency(ctx context.Context, d *ReminderDependency) error {
-	d.CreatedAt = time.Now()
-	_, err := s.col("reminder_dependencies").InsertOne(ctx, d)
-	return err
-}
-
-func (s *Extended2Service) RemoveDependency(ctx context.Context, id string) error {
-	oid, _ := primitive.ObjectIDFromHex(id)
-	_, err := s.col("reminder_dependencies").DeleteOne(ctx, bson.M{"_id": oid})
-	return err
-}
-
-func (s *Extended2Service) ListDependencies(ctx context.Context, reminderID string) ([]ReminderDependency, error) {
-	cursor, err := s.col("reminder_dependencies").Find(ctx, bson.M{"reminder_id": reminderID})
-	if err != nil { return nil, err }
-	var results []ReminderDependency
-	err = cursor.All(ctx, &results)
-	return results, err
-}
-
-// Locations
-func (s *Extended2Service) SetLocation(ctx context.Context, loc *ReminderLocation) error {
-	loc.CreatedAt = time.Now()
-	filter := bson.M{"reminder_id": loc.ReminderID}
-	update := bson.M{"$set": loc}
-	opts := options.Update().SetUpsert(true)
-	_, err := s.col("reminder_locations").UpdateOne(ctx, filter, update, opts)
-	return err
-}
-
-func (s *Extended2Service) GetLocation(ctx context.Context, reminderID string) (*ReminderLocation, error) {
-	var loc ReminderLocation
-	err := s.col("reminder_locations").FindOne(ctx, bson.M{"reminder_id": reminderID}).Decode(&loc)
-	if err == mongo.ErrNoDocuments { return nil, nil }
-	return &loc, err
-}
-
-func (s *Extended2Service) RemoveLocation(ctx context.Context, reminderID string) error {
-	_, err := s.col("reminder_locations").DeleteOne(ctx, bson.M{"reminder_id": reminderID})
-	return err
-}
-
-func (s *Extended2Service) ListNearby(ctx context.Context, lat, lon, radius float64) ([]ReminderLocation, error) {
-	cursor, err := s.col("reminder_locations").Find(ctx, bson.M{})
-	if err != nil { return nil, err }
-	var results []ReminderLocation
-	err = cursor.All(ctx, &results)
-	return results, err
-}
-
-// Snooze History
-func (s *Extended2Service) ListSnoozeHistory(ctx context.Context, reminderID string) ([]ReminderSnoozeHistory, error) {
-	opts := options.Find().SetSort(bson.D{{Key: "snoozed_at", Value: -1}})
-	cursor, err := s.col("reminder_snooze_history").Find(ctx, bson.M{"reminder_id": reminderID}, opts)
-	if err != nil { return nil, err }
-	var results []ReminderSnoozeHistory
-	err = cursor.All(ctx, &results)
-	return results, err
-}
-
-func (s *Extended2Service) RecordSnooze(ctx context.Context, h *ReminderSnoozeHistory) error {
-	h.SnoozedAt = time.Now()
-	_, err := s.col("reminder_snooze_history").InsertOne(ctx, h)
-	return err
-}
-
 // Quick Actions
 func (s *Extended2Service) CreateQuickAction(ctx context.Context, qa *ReminderQuickAction) error {
 	qa.CreatedAt = time.Now()
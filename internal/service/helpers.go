@@ -1,6 +1,13 @@
 package service
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidID is returned for an ID that is not a valid ObjectID.
+var ErrInvalidID = errors.New("invalid id")
 
 func objectIDFromHex(id string) (primitive.ObjectID, error) {
 	return primitive.ObjectIDFromHex(id)
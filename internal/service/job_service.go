@@ -0,0 +1,556 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"reminder-service/internal/ical"
+	"reminder-service/internal/models"
+	"reminder-service/internal/recurrence"
+	"reminder-service/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrInvalidJob      = errors.New("invalid job")
+	ErrJobFinished     = errors.New("job has already finished")
+	ErrJobNoResult     = errors.New("job has no result")
+	ErrJobCancelled    = errors.New("job was cancelled")
+	ErrJobLeaseLost    = errors.New("job claimed by another worker")
+	errJobAttemptsUsed = errors.New("job failed on every attempt")
+)
+
+const (
+	// maxJobAttempts is how often a job whose worker died is restarted.
+	maxJobAttempts = 3
+
+	// maxJobErrors caps the sample of item errors kept on a job.
+	maxJobErrors = 20
+
+	jobsCompletedTopic = "jobs.completed"
+)
+
+// JobService runs exports and imports in the background. Jobs are claimed
+// with a lease like reminders, so a job whose worker dies is picked up again
+// once the lease expires. Inputs and results are stored in GridFS.
+type JobService struct {
+	collection *mongo.Collection
+	files      *gridfs.Bucket
+	repo       repository.Repository
+	exports    *ExportService
+	retention  time.Duration
+}
+
+func NewJobService(repo repository.Repository, db *mongo.Database, exports *ExportService, retention time.Duration) (*JobService, error) {
+	files, err := gridfs.NewBucket(db, options.GridFSBucket().SetName("reminder_job_files"))
+	if err != nil {
+		return nil, err
+	}
+
+	collection := db.Collection("reminder_jobs")
+	_, _ = collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "finished_at", Value: 1}}},
+	})
+
+	return &JobService{
+		collection: collection,
+		files:      files,
+		repo:       repo,
+		exports:    exports,
+		retention:  retention,
+	}, nil
+}
+
+// ── Submission ──
+
+// SubmitExport queues an export. The format defaults to NDJSON; JSON is not
+// offered since it cannot be written incrementally.
+func (s *JobService) SubmitExport(ctx context.Context, userID string, req *models.ExportRequest) (*models.Job, error) {
+	if req.Format == "" {
+		req.Format = models.ExportNDJSON
+	}
+	if req.Format != models.ExportCSV && req.Format != models.ExportNDJSON {
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidExport, req.Format)
+	}
+	if _, err := s.exports.exportFilter(ctx, req); err != nil {
+		return nil, err
+	}
+	if _, _, err := exportLayout(req); err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		UserID:      userID,
+		WorkspaceID: req.WorkspaceID,
+		Kind:        models.JobExport,
+		Status:      models.JobQueued,
+		Export:      req,
+		CreatedAt:   time.Now(),
+	}
+	return job, s.insert(ctx, job)
+}
+
+// SubmitImport stores the uploaded file and queues its import into
+// workspaceID. JSON input is an ImportRequest whose reminders must be for
+// that workspace or name none.
+func (s *JobService) SubmitImport(ctx context.Context, userID, workspaceID, format, timezone string, input io.Reader) (*models.Job, error) {
+	if workspaceID == "" {
+		return nil, fmt.Errorf("%w: workspace_id is required for imports", ErrInvalidJob)
+	}
+	switch format {
+	case models.ImportFormatJSON:
+	case models.ImportFormatICS:
+		if _, err := recurrence.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidJob, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported import format %q", ErrInvalidJob, format)
+	}
+
+	fileID, err := s.files.UploadFromStream(fmt.Sprintf("import-%s.%s", userID, format), input)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		UserID:       userID,
+		WorkspaceID:  workspaceID,
+		Kind:         models.JobImport,
+		Status:       models.JobQueued,
+		ImportFormat: format,
+		Timezone:     timezone,
+		InputFileID:  &fileID,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.insert(ctx, job); err != nil {
+		_ = s.files.Delete(fileID)
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *JobService) insert(ctx context.Context, job *models.Job) error {
+	result, err := s.collection.InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+	job.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ── Queries ──
+
+func (s *JobService) Get(ctx context.Context, id string) (*models.Job, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+	var job models.Job
+	err = s.collection.FindOne(ctx, bson.M{"_id": oid}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *JobService) List(ctx context.Context, userID string, limit int64) ([]models.Job, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.Job
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// OpenResult opens the result file of a finished export.
+func (s *JobService) OpenResult(ctx context.Context, job *models.Job) (io.ReadCloser, error) {
+	if job.Status != models.JobSucceeded || job.ResultFileID == nil {
+		return nil, ErrJobNoResult
+	}
+	return s.files.OpenDownloadStream(*job.ResultFileID)
+}
+
+// Cancel stops a job. A queued job is cancelled at once; a running one is
+// flagged and stops at its next progress update.
+func (s *JobService) Cancel(ctx context.Context, id string) (*models.Job, error) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var cancelled models.Job
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		err := s.collection.FindOneAndUpdate(ctx,
+			bson.M{"_id": job.ID, "status": models.JobQueued},
+			bson.M{"$set": bson.M{"status": models.JobCancelled, "finished_at": time.Now()}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&cancelled)
+		if err != nil {
+			return err
+		}
+		return s.emitCompleted(ctx, &cancelled)
+	})
+	if err == nil {
+		s.deleteFile(cancelled.InputFileID)
+		return &cancelled, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	err = s.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": job.ID, "status": models.JobRunning},
+		bson.M{"$set": bson.M{"cancel_requested": true}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&cancelled)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrJobFinished
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cancelled, nil
+}
+
+// ── Execution ──
+
+// ClaimNext leases the oldest queued job, or a running job whose worker's
+// lease has expired.
+func (s *JobService) ClaimNext(ctx context.Context, owner string, lease time.Duration) (*models.Job, error) {
+	now := time.Now()
+	filter := bson.M{"$or": []bson.M{
+		{"status": models.JobQueued},
+		{"status": models.JobRunning, "lease_expires_at": bson.M{"$lte": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":           models.JobRunning,
+			"started_at":       now,
+			"lease_owner":      owner,
+			"lease_expires_at": now.Add(lease),
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.Job
+	err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Run executes a claimed job and records its outcome. Bad input fails the
+// job at once; other errors are returned and leave the lease in place, so
+// the job is retried once it expires.
+func (s *JobService) Run(ctx context.Context, job *models.Job, lease time.Duration) error {
+	if job.Attempts > maxJobAttempts {
+		return s.finish(ctx, job, models.JobFailed, errJobAttemptsUsed.Error(), nil)
+	}
+
+	var resultID *primitive.ObjectID
+	var err error
+	switch job.Kind {
+	case models.JobExport:
+		resultID, err = s.runExport(ctx, job, lease)
+	case models.JobImport:
+		err = s.runImport(ctx, job, lease)
+	default:
+		err = fmt.Errorf("%w: unknown kind %q", ErrInvalidJob, job.Kind)
+	}
+
+	switch {
+	case err == nil:
+		return s.finish(ctx, job, models.JobSucceeded, "", resultID)
+	case errors.Is(err, ErrJobLeaseLost):
+		return err
+	case errors.Is(err, ErrJobCancelled):
+		return s.finish(ctx, job, models.JobCancelled, "", nil)
+	case permanentJobError(err):
+		return s.finish(ctx, job, models.JobFailed, err.Error(), nil)
+	}
+	return err
+}
+
+func permanentJobError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.Is(err, ErrInvalidJob) ||
+		errors.Is(err, ErrInvalidExport) ||
+		errors.Is(err, ErrInvalidFilter) ||
+		errors.Is(err, ical.ErrMalformed) ||
+		errors.Is(err, recurrence.ErrInvalidRule) ||
+		errors.As(err, &syntaxErr) ||
+		errors.As(err, &typeErr)
+}
+
+func (s *JobService) runExport(ctx context.Context, job *models.Job, lease time.Duration) (*primitive.ObjectID, error) {
+	total, err := s.exports.Count(ctx, job.Export)
+	if err != nil {
+		return nil, err
+	}
+	// An export restarted after a crash starts over.
+	if err := s.resetProgress(ctx, job, int(total)); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("reminders-%s.%s", job.ID.Hex(), job.Export.Format)
+	upload, err := s.files.OpenUploadStream(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.exports.Stream(ctx, job.Export, upload, s.progress(job, lease)); err != nil {
+		_ = upload.Abort()
+		return nil, err
+	}
+	if err := upload.Close(); err != nil {
+		return nil, err
+	}
+	fileID := upload.FileID.(primitive.ObjectID)
+	return &fileID, nil
+}
+
+func (s *JobService) runImport(ctx context.Context, job *models.Job, lease time.Duration) error {
+	if job.InputFileID == nil {
+		return fmt.Errorf("%w: import has no input", ErrInvalidJob)
+	}
+	input, err := s.files.OpenDownloadStream(*job.InputFileID)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	switch job.ImportFormat {
+	case models.ImportFormatJSON:
+		var req models.ImportRequest
+		if err := json.NewDecoder(input).Decode(&req); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+		}
+		// The caller's access was checked for the job's workspace only.
+		for i := range req.Reminders {
+			req.Reminders[i].UserID = job.UserID
+			switch req.Reminders[i].WorkspaceID {
+			case "":
+				req.Reminders[i].WorkspaceID = job.WorkspaceID
+			case job.WorkspaceID:
+			default:
+				return fmt.Errorf("%w: reminder %d is for workspace %q, not %q", ErrInvalidJob, i, req.Reminders[i].WorkspaceID, job.WorkspaceID)
+			}
+		}
+		if err := s.setTotal(ctx, job, len(req.Reminders)); err != nil {
+			return err
+		}
+		// Reminders are written in batches, so a restarted import resumes
+		// after the last batch it recorded.
+		done := min(job.Processed, len(req.Reminders))
+		_, err = s.exports.ImportReminders(ctx, req.Reminders[done:], s.progress(job, lease))
+		return err
+
+	case models.ImportFormatICS:
+		floating, err := recurrence.LoadLocation(job.Timezone)
+		if err != nil {
+			return err
+		}
+		// Calendar items are matched by UID, so starting over is harmless.
+		if err := s.resetProgress(ctx, job, 0); err != nil {
+			return err
+		}
+		resp, err := s.exports.ImportICS(ctx, job.UserID, job.WorkspaceID, floating, input, s.progress(job, lease))
+		if err != nil {
+			return err
+		}
+		return s.setTotal(ctx, job, len(resp.Items))
+	}
+	return fmt.Errorf("%w: unsupported import format %q", ErrInvalidJob, job.ImportFormat)
+}
+
+// progress records work done, renews the lease and notices cancellation.
+func (s *JobService) progress(job *models.Job, lease time.Duration) Progress {
+	return func(processed, failed int, errs []string) error {
+		// Progress is also reported while the caller winds down, so it must
+		// not depend on the job's context.
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		update := bson.M{
+			"$inc": bson.M{"processed": processed, "failed": failed},
+			"$set": bson.M{"lease_expires_at": time.Now().Add(lease)},
+		}
+		if len(errs) > 0 {
+			update["$push"] = bson.M{"errors": bson.M{"$each": errs, "$slice": maxJobErrors}}
+		}
+
+		var current models.Job
+		err := s.collection.FindOneAndUpdate(ctx,
+			bson.M{"_id": job.ID, "status": models.JobRunning, "lease_owner": job.LeaseOwner},
+			update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&current)
+		if err == mongo.ErrNoDocuments {
+			return ErrJobLeaseLost
+		}
+		if err != nil {
+			return err
+		}
+		if current.CancelRequested {
+			return ErrJobCancelled
+		}
+		return nil
+	}
+}
+
+func (s *JobService) resetProgress(ctx context.Context, job *models.Job, total int) error {
+	return s.updateLeased(ctx, job, bson.M{
+		"$set":   bson.M{"total": total, "processed": 0, "failed": 0},
+		"$unset": bson.M{"errors": ""},
+	})
+}
+
+func (s *JobService) setTotal(ctx context.Context, job *models.Job, total int) error {
+	return s.updateLeased(ctx, job, bson.M{"$set": bson.M{"total": total}})
+}
+
+func (s *JobService) updateLeased(ctx context.Context, job *models.Job, update bson.M) error {
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": job.ID, "status": models.JobRunning, "lease_owner": job.LeaseOwner}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+// finish records the outcome and emits jobs.completed in one transaction.
+// The input file is no longer needed once a job has finished.
+func (s *JobService) finish(ctx context.Context, job *models.Job, status models.JobStatus, reason string, resultID *primitive.ObjectID) error {
+	now := time.Now()
+	set := bson.M{"status": status, "finished_at": now}
+	if reason != "" {
+		set["reason"] = reason
+	}
+	if resultID != nil {
+		set["result_file_id"] = *resultID
+		set["result_name"] = fmt.Sprintf("reminders-%s.%s", now.UTC().Format("20060102"), job.Export.Format)
+		set["result_type"] = ExportContentType(job.Export.Format)
+	}
+
+	var finished models.Job
+	err := s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		err := s.collection.FindOneAndUpdate(ctx,
+			bson.M{"_id": job.ID, "status": models.JobRunning, "lease_owner": job.LeaseOwner},
+			bson.M{"$set": set, "$unset": bson.M{"lease_owner": "", "lease_expires_at": ""}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&finished)
+		if err == mongo.ErrNoDocuments {
+			return ErrJobLeaseLost
+		}
+		if err != nil {
+			return err
+		}
+		return s.emitCompleted(ctx, &finished)
+	})
+	if err != nil {
+		if resultID != nil {
+			s.deleteFile(resultID)
+		}
+		return err
+	}
+
+	s.deleteFile(job.InputFileID)
+	return nil
+}
+
+func (s *JobService) emitCompleted(ctx context.Context, job *models.Job) error {
+	payload, err := json.Marshal(map[string]any{
+		"job_id":      job.ID.Hex(),
+		"user_id":     job.UserID,
+		"kind":        job.Kind,
+		"status":      job.Status,
+		"total":       job.Total,
+		"processed":   job.Processed,
+		"failed":      job.Failed,
+		"finished_at": job.FinishedAt,
+	})
+	if err != nil {
+		return err
+	}
+	return s.repo.EnqueueEvent(ctx, &models.OutboxEvent{
+		Topic:     jobsCompletedTopic,
+		Key:       job.ID.Hex(),
+		DedupeKey: "job-completed:" + job.ID.Hex(),
+		Payload:   string(payload),
+	})
+}
+
+func (s *JobService) deleteFile(id *primitive.ObjectID) {
+	if id == nil {
+		return
+	}
+	if err := s.files.Delete(*id); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		log.Printf("Error deleting job file %s: %v", id.Hex(), err)
+	}
+}
+
+// PurgeExpired deletes jobs that finished longer ago than the retention
+// period, along with their files.
+func (s *JobService) PurgeExpired(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-s.retention)
+	cursor, err := s.collection.Find(ctx, bson.M{"finished_at": bson.M{"$lt": cutoff}},
+		options.Find().SetProjection(bson.M{"input_file_id": 1, "result_file_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	purged := 0
+	for cursor.Next(ctx) {
+		var job models.Job
+		if err := cursor.Decode(&job); err != nil {
+			return purged, err
+		}
+		s.deleteFile(job.InputFileID)
+		s.deleteFile(job.ResultFileID)
+		if _, err := s.collection.DeleteOne(ctx, bson.M{"_id": job.ID}); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, cursor.Err()
+}
+
+// ExportContentType is the media type of a CSV or NDJSON export.
+func ExportContentType(format string) string {
+	if format == models.ExportCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
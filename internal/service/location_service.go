@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"reminder-service/internal/audit"
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidLocation is returned for a geofence with bad coordinates,
+// radius or trigger.
+var ErrInvalidLocation = errors.New("invalid location")
+
+const (
+	// Geofence radii are in meters.
+	defaultGeofenceRadius = 100.0
+	maxGeofenceRadius     = 50000.0
+
+	// A user must be this far outside a geofence they were in before they
+	// count as having left it.
+	minGeofenceHysteresis = 20.0
+
+	// geofenceDebounce is how long a user must stay on one side of a
+	// geofence before crossing back counts as a new transition.
+	geofenceDebounce = 2 * time.Minute
+)
+
+// GeoPoint is a GeoJSON point. Coordinates are [longitude, latitude].
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+func newGeoPoint(lat, lon float64) GeoPoint {
+	return GeoPoint{Type: "Point", Coordinates: []float64{lon, lat}}
+}
+
+// ReminderLocation is a geofence around a reminder's place. TriggerOn
+// selects whether the owner entering or leaving it fires the reminder.
+type ReminderLocation struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ReminderID string             `bson:"reminder_id" json:"reminder_id"`
+	UserID     string             `bson:"user_id" json:"user_id"`
+	Name       string             `bson:"name" json:"name"`
+	Latitude   float64            `bson:"latitude" json:"latitude"`
+	Longitude  float64            `bson:"longitude" json:"longitude"`
+	Point      GeoPoint           `bson:"point" json:"-"`
+	Radius     float64            `bson:"radius" json:"radius"`
+	TriggerOn  string             `bson:"trigger_on" json:"trigger_on"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// geofenceState is which side of a geofence a user was last seen on.
+type geofenceState struct {
+	UserID     string             `bson:"user_id"`
+	LocationID primitive.ObjectID `bson:"location_id"`
+	Inside     bool               `bson:"inside"`
+	ChangedAt  time.Time          `bson:"changed_at"`
+	RecordedAt time.Time          `bson:"recorded_at"`
+}
+
+type LocationService struct {
+	locations   *audit.Collection
+	states      *mongo.Collection
+	reminders   *audit.Collection
+	reminderSvc *ReminderService
+}
+
+func NewLocationService(db *mongo.Database, reminderSvc *ReminderService, sink audit.Sink) *LocationService {
+	ctx := context.Background()
+
+	locations := db.Collection("reminder_locations")
+	_, _ = locations.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "point", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "reminder_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+
+	states := db.Collection("geofence_states")
+	_, _ = states.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "location_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	s := &LocationService{
+		locations:   audit.Wrap(locations, "location", "reminder_id", sink),
+		states:      states,
+		reminders:   audit.Wrap(db.Collection("reminders"), "reminder", "_id", sink),
+		reminderSvc: reminderSvc,
+	}
+	if err := s.backfill(ctx); err != nil {
+		log.Printf("Failed to backfill reminder locations: %v", err)
+	}
+	return s
+}
+
+// backfill adds the GeoJSON point and owner to locations stored before
+// geofences were evaluated.
+func (s *LocationService) backfill(ctx context.Context) error {
+	_, err := s.locations.UpdateMany(ctx, bson.M{"point": bson.M{"$exists": false}}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"point": bson.M{
+			"type":        "Point",
+			"coordinates": bson.A{"$longitude", "$latitude"},
+		}}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	cursor, err := s.locations.Find(ctx, bson.M{"user_id": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	var legacy []ReminderLocation
+	if err := cursor.All(ctx, &legacy); err != nil {
+		return err
+	}
+	for _, loc := range legacy {
+		reminder, err := s.reminderSvc.GetByID(ctx, loc.ReminderID)
+		if err != nil {
+			continue
+		}
+		_, err = s.locations.UpdateOne(ctx, bson.M{"_id": loc.ID}, bson.M{"$set": bson.M{"user_id": reminder.UserID}})
+		if err != nil {
+			return err
+		}
+		_ = s.setGeofenced(ctx, loc.ReminderID, true)
+	}
+	return nil
+}
+
+// ── Locations ──
+
+// SetLocation places the geofence of a reminder, replacing any earlier one.
+// From then on the reminder triggers on its location instead of its time.
+func (s *LocationService) SetLocation(ctx context.Context, loc *ReminderLocation) error {
+	if loc.Latitude < -90 || loc.Latitude > 90 || loc.Longitude < -180 || loc.Longitude > 180 {
+		return fmt.Errorf("%w: latitude must be within ±90 and longitude within ±180", ErrInvalidLocation)
+	}
+	if loc.Radius == 0 {
+		loc.Radius = defaultGeofenceRadius
+	}
+	if loc.Radius < 0 || loc.Radius > maxGeofenceRadius {
+		return fmt.Errorf("%w: radius must be between 0 and %.0f meters", ErrInvalidLocation, maxGeofenceRadius)
+	}
+	if loc.TriggerOn == "" {
+		loc.TriggerOn = models.GeofenceEnter
+	}
+	if loc.TriggerOn != models.GeofenceEnter && loc.TriggerOn != models.GeofenceExit {
+		return fmt.Errorf("%w: trigger_on must be %s or %s", ErrInvalidLocation, models.GeofenceEnter, models.GeofenceExit)
+	}
+
+	reminder, err := s.reminderSvc.GetByID(ctx, loc.ReminderID)
+	if err != nil {
+		return err
+	}
+	loc.UserID = reminder.UserID
+	loc.Point = newGeoPoint(loc.Latitude, loc.Longitude)
+	loc.CreatedAt = time.Now()
+
+	err = s.locations.FindOneAndUpdate(ctx, bson.M{"reminder_id": loc.ReminderID}, bson.M{"$set": loc},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(loc)
+	if err != nil {
+		return err
+	}
+
+	// The geofence may have moved; the next update starts from scratch.
+	if _, err := s.states.DeleteMany(ctx, bson.M{"location_id": loc.ID}); err != nil {
+		return err
+	}
+	return s.setGeofenced(ctx, loc.ReminderID, true)
+}
+
+func (s *LocationService) GetLocation(ctx context.Context, reminderID string) (*ReminderLocation, error) {
+	var loc ReminderLocation
+	err := s.locations.FindOne(ctx, bson.M{"reminder_id": reminderID}).Decode(&loc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
+
+// RemoveLocation drops a reminder's geofence. It goes back to triggering
+// at its time.
+func (s *LocationService) RemoveLocation(ctx context.Context, reminderID string) error {
+	var loc ReminderLocation
+	err := s.locations.FindOneAndDelete(ctx, bson.M{"reminder_id": reminderID}).Decode(&loc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := s.states.DeleteMany(ctx, bson.M{"location_id": loc.ID}); err != nil {
+		return err
+	}
+	return s.setGeofenced(ctx, reminderID, false)
+}
+
+// ListNearby returns userID's locations within radius meters of a point,
+// nearest first. The radius is capped at maxGeofenceRadius.
+func (s *LocationService) ListNearby(ctx context.Context, userID string, lat, lon, radius float64) ([]ReminderLocation, error) {
+	if !(lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180) {
+		return nil, fmt.Errorf("%w: latitude must be within ±90 and longitude within ±180", ErrInvalidLocation)
+	}
+	if !(radius > 0 && radius <= maxGeofenceRadius) {
+		return nil, fmt.Errorf("%w: radius must be between 0 and %.0f meters", ErrInvalidLocation, maxGeofenceRadius)
+	}
+
+	cursor, err := s.locations.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.M{
+			"near":          newGeoPoint(lat, lon),
+			"distanceField": "distance",
+			"maxDistance":   radius,
+			"query":         bson.M{"user_id": userID},
+			"spherical":     true,
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []ReminderLocation{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *LocationService) setGeofenced(ctx context.Context, reminderID string, geofenced bool) error {
+	objID, err := objectIDFromHex(reminderID)
+	if err != nil {
+		return ErrResourceNotFound
+	}
+	update := bson.M{"$unset": bson.M{"geofenced": ""}}
+	if geofenced {
+		update = bson.M{"$set": bson.M{"geofenced": true}}
+	}
+	_, err = s.reminders.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	return err
+}
+
+// ── Geofences ──
+
+// nearbyLocation is a location with its distance in meters from a point.
+type nearbyLocation struct {
+	ReminderLocation `bson:",inline"`
+	Distance         float64 `bson:"distance"`
+}
+
+// Track evaluates a user's position against their geofences and triggers
+// the reminders whose enter or exit transition it completes.
+//
+// Transitions are debounced: leaving needs a margin beyond the radius,
+// crossing back within geofenceDebounce of the last transition is ignored,
+// and positions less accurate than a geofence's radius do not move it.
+// Updates older than the last one seen for a geofence are skipped.
+func (s *LocationService) Track(ctx context.Context, u *models.LocationUpdate) ([]models.GeofenceTransition, error) {
+	if u.RecordedAt.IsZero() {
+		u.RecordedAt = time.Now()
+	}
+
+	cursor, err := s.locations.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.M{
+			"near":          newGeoPoint(u.Latitude, u.Longitude),
+			"distanceField": "distance",
+			"maxDistance":   maxGeofenceRadius + geofenceHysteresis(maxGeofenceRadius),
+			"query":         bson.M{"user_id": u.UserID},
+			"spherical":     true,
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var nearby []nearbyLocation
+	if err := cursor.All(ctx, &nearby); err != nil {
+		return nil, err
+	}
+
+	cursor, err = s.states.Find(ctx, bson.M{"user_id": u.UserID})
+	if err != nil {
+		return nil, err
+	}
+	var saved []geofenceState
+	if err := cursor.All(ctx, &saved); err != nil {
+		return nil, err
+	}
+	states := make(map[primitive.ObjectID]*geofenceState, len(saved))
+	for i := range saved {
+		states[saved[i].LocationID] = &saved[i]
+	}
+
+	// Geofences the user was in but are now out of range have been left.
+	seen := make(map[primitive.ObjectID]bool, len(nearby))
+	for _, loc := range nearby {
+		seen[loc.ID] = true
+	}
+	var left []primitive.ObjectID
+	for id, state := range states {
+		if state.Inside && !seen[id] {
+			left = append(left, id)
+		}
+	}
+	if len(left) > 0 {
+		cursor, err = s.locations.Find(ctx, bson.M{"_id": bson.M{"$in": left}})
+		if err != nil {
+			return nil, err
+		}
+		var far []ReminderLocation
+		if err := cursor.All(ctx, &far); err != nil {
+			return nil, err
+		}
+		for _, loc := range far {
+			nearby = append(nearby, nearbyLocation{ReminderLocation: loc, Distance: math.Inf(1)})
+		}
+	}
+
+	transitions := []models.GeofenceTransition{}
+	for i := range nearby {
+		loc := &nearby[i]
+		if u.Accuracy > loc.Radius {
+			continue
+		}
+		transition, err := s.evaluate(ctx, u, loc, states[loc.ID])
+		if err != nil {
+			return transitions, err
+		}
+		if transition != nil {
+			transitions = append(transitions, *transition)
+		}
+	}
+	return transitions, nil
+}
+
+func geofenceHysteresis(radius float64) float64 {
+	return math.Max(minGeofenceHysteresis, radius/10)
+}
+
+// evaluate moves the user's state for one geofence and, in the same
+// transaction, triggers its reminder on the matching transition. A
+// concurrent update for the same geofence wins over this one.
+func (s *LocationService) evaluate(ctx context.Context, u *models.LocationUpdate, loc *nearbyLocation, state *geofenceState) (*models.GeofenceTransition, error) {
+	if state != nil && !u.RecordedAt.After(state.RecordedAt) {
+		return nil, nil
+	}
+
+	wasInside := state != nil && state.Inside
+	inside := loc.Distance <= loc.Radius
+	if wasInside {
+		inside = loc.Distance <= loc.Radius+geofenceHysteresis(loc.Radius)
+	}
+	if state != nil && inside != wasInside && u.RecordedAt.Sub(state.ChangedAt) < geofenceDebounce {
+		inside = wasInside
+	}
+
+	filter := bson.M{"user_id": u.UserID, "location_id": loc.ID}
+	set := bson.M{"inside": inside, "recorded_at": u.RecordedAt}
+	if state != nil {
+		filter["recorded_at"] = state.RecordedAt
+	}
+	if inside != wasInside || state == nil {
+		set["changed_at"] = u.RecordedAt
+	}
+
+	var transition *models.GeofenceTransition
+	err := s.reminderSvc.WithTransaction(ctx, func(ctx context.Context) error {
+		transition = nil
+		result, err := s.states.UpdateOne(ctx, filter, bson.M{"$set": set},
+			options.Update().SetUpsert(state == nil))
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 && result.UpsertedCount == 0 {
+			return nil
+		}
+		if inside == wasInside {
+			return nil
+		}
+
+		transition = &models.GeofenceTransition{
+			ReminderID: loc.ReminderID,
+			LocationID: loc.ID.Hex(),
+			Event:      models.GeofenceExit,
+			At:         u.RecordedAt,
+		}
+		if inside {
+			transition.Event = models.GeofenceEnter
+		}
+		if transition.Event != loc.TriggerOn {
+			return nil
+		}
+		transition.Triggered, err = s.trigger(ctx, loc.ReminderID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transition, nil
+}
+
+// trigger fires a geofenced reminder that is still waiting. A reminder
+// deferred by quiet hours is handed to the scheduler, which sends it when
+// they end.
+func (s *LocationService) trigger(ctx context.Context, reminderID string) (bool, error) {
+	reminder, err := s.reminderSvc.GetByID(ctx, reminderID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if reminder.Status != models.StatusPending && reminder.Status != models.StatusSnoozed {
+		return false, nil
+	}
+	if len(reminder.BlockedBy) > 0 {
+		return false, nil
+	}
+
+	err = s.reminderSvc.TriggerReminder(ctx, reminder)
+	switch {
+	case errors.Is(err, ErrNotificationDeferred):
+		return false, s.setGeofenced(ctx, reminderID, false)
+	case errors.Is(err, ErrLeaseLost):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"log"
 	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,11 +16,11 @@ import (
 )
 
 type NoteService struct {
-	collection *mongo.Collection
+	collection *audit.Collection
 }
 
-func NewNoteService(db *mongo.Database) *NoteService {
-	return &NoteService{collection: db.Collection("reminder_notes")}
+func NewNoteService(db *mongo.Database, sink audit.Sink) *NoteService {
+	return &NoteService{collection: audit.Wrap(db.Collection("reminder_notes"), "note", "reminder_id", sink)}
 }
 
 func (s *NoteService) Create(ctx context.Context, reminderID, userID string, req *models.CreateNoteRequest) (*models.ReminderNote, error) {
@@ -34,6 +37,9 @@ func (s *NoteService) Create(ctx context.Context, reminderID, userID string, req
 		return nil, err
 	}
 	note.ID = result.InsertedID.(primitive.ObjectID)
+	if err := refreshSearchText(ctx, s.collection.Database(), reminderID, "reminder_notes", "search_notes"); err != nil {
+		log.Printf("Error refreshing search text for reminder %s: %v", reminderID, err)
+	}
 	return note, nil
 }
 
@@ -74,6 +80,9 @@ func (s *NoteService) Update(ctx context.Context, noteID string, req *models.Upd
 	if err := s.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&note); err != nil {
 		return nil, err
 	}
+	if err := refreshSearchText(ctx, s.collection.Database(), note.ReminderID, "reminder_notes", "search_notes"); err != nil {
+		log.Printf("Error refreshing search text for reminder %s: %v", note.ReminderID, err)
+	}
 	return &note, nil
 }
 
@@ -82,8 +91,18 @@ func (s *NoteService) Delete(ctx context.Context, noteID string) error {
 	if err != nil {
 		return err
 	}
-	_, err = s.collection.DeleteOne(ctx, bson.M{"_id": objID})
-	return err
+	var note models.ReminderNote
+	err = s.collection.FindOneAndDelete(ctx, bson.M{"_id": objID}).Decode(&note)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := refreshSearchText(ctx, s.collection.Database(), note.ReminderID, "reminder_notes", "search_notes"); err != nil {
+		log.Printf("Error refreshing search text for reminder %s: %v", note.ReminderID, err)
+	}
+	return nil
 }
 
 func (s *NoteService) CountByReminder(ctx context.Context, reminderID string) (int64, error) {
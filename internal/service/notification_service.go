@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,10 +16,23 @@ import (
 
 type NotificationService struct {
 	collection *mongo.Collection
+	reminders  *audit.Collection
 }
 
-func NewNotificationService(db *mongo.Database) *NotificationService {
-	return &NotificationService{collection: db.Collection("notification_preferences")}
+func NewNotificationService(db *mongo.Database, sink audit.Sink) *NotificationService {
+	collection := db.Collection("notification_preferences")
+	// Preferences saved before urgent_bypass existed get the default.
+	_, err := collection.UpdateMany(context.Background(),
+		bson.M{"urgent_bypass": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"urgent_bypass": models.NewNotificationPreference("", "").UrgentBypass}},
+	)
+	if err != nil {
+		log.Printf("Failed to backfill notification preferences: %v", err)
+	}
+	return &NotificationService{
+		collection: collection,
+		reminders:  audit.Wrap(db.Collection("reminders"), "reminder", "_id", sink),
+	}
 }
 
 func (s *NotificationService) GetPreferences(ctx context.Context, userID, workspaceID string) (*models.NotificationPreference, error) {
@@ -29,13 +45,7 @@ func (s *NotificationService) GetPreferences(ctx context.Context, userID, worksp
 	err := s.collection.FindOne(ctx, filter).Decode(&pref)
 	if err != nil {
 		// Return default preferences
-		return &models.NotificationPreference{
-			UserID:      userID,
-			WorkspaceID: workspaceID,
-			Channels:    []string{"in_app"},
-			Enabled:     true,
-			AdvanceLead: 0,
-		}, nil
+		return models.NewNotificationPreference(userID, workspaceID), nil
 	}
 	return &pref, nil
 }
@@ -62,6 +72,15 @@ func (s *NotificationService) UpdatePreferences(ctx context.Context, userID, wor
 	if req.Enabled != nil {
 		update["enabled"] = *req.Enabled
 	}
+	if req.UrgentBypass != nil {
+		update["urgent_bypass"] = *req.UrgentBypass
+	}
+	if req.RenotifyMins != nil {
+		update["renotify_mins"] = *req.RenotifyMins
+	}
+	if req.RenotifyMax != nil {
+		update["renotify_max"] = *req.RenotifyMax
+	}
 
 	opts := primitive.ObjectID{}
 	_ = opts
@@ -80,35 +99,169 @@ func (s *NotificationService) UpdatePreferences(ctx context.Context, userID, wor
 
 	if result.Err() != nil {
 		// Insert new
-		pref := &models.NotificationPreference{
-			UserID:      userID,
-			WorkspaceID: workspaceID,
-			Channels:    req.Channels,
-			QuietStart:  req.QuietStart,
-			QuietEnd:    req.QuietEnd,
-			Timezone:    req.Timezone,
-			Enabled:     true,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
+		pref := models.NewNotificationPreference(userID, workspaceID)
+		pref.Channels = req.Channels
+		pref.QuietStart = req.QuietStart
+		pref.QuietEnd = req.QuietEnd
+		pref.Timezone = req.Timezone
+		pref.CreatedAt = time.Now()
+		pref.UpdatedAt = time.Now()
 		if req.AdvanceLead != nil {
 			pref.AdvanceLead = *req.AdvanceLead
 		}
 		if req.Enabled != nil {
 			pref.Enabled = *req.Enabled
 		}
+		if req.UrgentBypass != nil {
+			pref.UrgentBypass = *req.UrgentBypass
+		}
+		if req.RenotifyMins != nil {
+			pref.RenotifyMins = *req.RenotifyMins
+		}
+		if req.RenotifyMax != nil {
+			pref.RenotifyMax = *req.RenotifyMax
+		}
 		insertResult, err := s.collection.InsertOne(ctx, pref)
 		if err != nil {
 			return nil, err
 		}
 		pref.ID = insertResult.InsertedID.(primitive.ObjectID)
-		return pref, nil
+		return pref, s.rescheduleIfNeeded(ctx, pref, req)
+	}
+
+	pref, err := s.GetPreferences(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return pref, s.rescheduleIfNeeded(ctx, pref, req)
+}
+
+// rescheduleIfNeeded moves the notify_at of the user's pending and snoozed
+// reminders when an update changed their advance lead, quiet hours or
+// timezone.
+// Reminders deferred by the old quiet hours go back to their lead time and
+// are checked against the new ones when they trigger.
+func (s *NotificationService) rescheduleIfNeeded(ctx context.Context, pref *models.NotificationPreference, req *models.UpdateNotificationPrefRequest) error {
+	if req.AdvanceLead == nil && req.QuietStart == "" && req.QuietEnd == "" && req.Timezone == "" {
+		return nil
 	}
 
-	return s.GetPreferences(ctx, userID, workspaceID)
+	filter := bson.M{
+		"user_id":      pref.UserID,
+		"workspace_id": pref.WorkspaceID,
+		"status":       bson.M{"$in": []models.ReminderStatus{models.StatusPending, models.StatusSnoozed}},
+	}
+	var update interface{} = bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"notify_at": ""},
+	}
+	if pref.AdvanceLead > 0 {
+		lead := (time.Duration(pref.AdvanceLead) * time.Minute).Milliseconds()
+		update = mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"notify_at":  bson.M{"$subtract": bson.A{"$remind_at", lead}},
+			"updated_at": time.Now(),
+		}}}}
+	}
+	if _, err := s.reminders.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to reschedule notifications: %w", err)
+	}
+	return nil
 }
 
 func (s *NotificationService) DeletePreferences(ctx context.Context, userID, workspaceID string) error {
 	_, err := s.collection.DeleteOne(ctx, bson.M{"user_id": userID, "workspace_id": workspaceID})
 	return err
 }
+
+// ── Delivery Rules ──
+
+// quietHoursEnd reports whether t falls inside the user's quiet hours and, if
+// so, when they end. The window is evaluated in the preference's timezone and
+// may wrap past midnight (22:00-07:00).
+func quietHoursEnd(pref *models.NotificationPreference, t time.Time) (time.Time, bool) {
+	if pref.QuietStart == "" || pref.QuietEnd == "" {
+		return time.Time{}, false
+	}
+	start, err := time.Parse("15:04", pref.QuietStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := time.Parse("15:04", pref.QuietEnd)
+	if err != nil || start.Equal(end) {
+		return time.Time{}, false
+	}
+
+	loc, err := time.LoadLocation(pref.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	y, m, d := local.Date()
+	at := func(days int, clock time.Time) time.Time {
+		return time.Date(y, m, d+days, clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+
+	if start.Before(end) {
+		if !local.Before(at(0, start)) && local.Before(at(0, end)) {
+			return at(0, end), true
+		}
+		return time.Time{}, false
+	}
+	if !local.Before(at(0, start)) {
+		return at(1, end), true
+	}
+	if local.Before(at(0, end)) {
+		return at(0, end), true
+	}
+	return time.Time{}, false
+}
+
+// advanceNotifyAt returns when to notify for remindAt given the user's
+// advance lead, or nil when there is no lead.
+func advanceNotifyAt(pref *models.NotificationPreference, remindAt time.Time) *time.Time {
+	if pref.AdvanceLead <= 0 {
+		return nil
+	}
+	at := remindAt.Add(-time.Duration(pref.AdvanceLead) * time.Minute)
+	return &at
+}
+
+// defaultRenotifyMax caps re-notifications when the user set an interval
+// but no limit.
+const defaultRenotifyMax = 3
+
+// nextRenotify returns when to notify again about a reminder nobody has
+// acknowledged, after sent re-notifications, or nil when the user's
+// policy is off or used up.
+func nextRenotify(pref *models.NotificationPreference, now time.Time, sent int) *time.Time {
+	if !pref.Enabled || pref.RenotifyMins <= 0 {
+		return nil
+	}
+	limit := pref.RenotifyMax
+	if limit <= 0 {
+		limit = defaultRenotifyMax
+	}
+	if sent >= limit {
+		return nil
+	}
+	at := now.Add(time.Duration(pref.RenotifyMins) * time.Minute)
+	return &at
+}
+
+func deliveryChannels(pref *models.NotificationPreference) []string {
+	if len(pref.Channels) == 0 {
+		return []string{"in_app"}
+	}
+	seen := make(map[string]bool, len(pref.Channels))
+	var channels []string
+	for _, ch := range pref.Channels {
+		if ch != "" && !seen[ch] {
+			seen[ch] = true
+			channels = append(channels, ch)
+		}
+	}
+	if len(channels) == 0 {
+		return []string{"in_app"}
+	}
+	return channels
+}
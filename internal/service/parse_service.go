@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"reminder-service/internal/models"
+	"reminder-service/internal/nlparse"
+)
+
+// minParseConfidence is the confidence below which a parsed reminder is
+// only created once the caller confirms it.
+const minParseConfidence = 0.5
+
+// ErrParseUnconfirmed is returned when creating a parsed reminder that needs
+// confirming first.
+var ErrParseUnconfirmed = errors.New("parsed reminder needs confirmation")
+
+// ParsedReminder is free text read as a reminder: the parse, the create
+// request it amounts to and, once created, the reminder.
+type ParsedReminder struct {
+	*nlparse.Result
+	Timezone string                        `json:"timezone"`
+	Draft    *models.CreateReminderRequest `json:"draft"`
+	Reminder *models.Reminder              `json:"reminder,omitempty"`
+}
+
+// ParseService reads reminders written in English, in each user's timezone.
+type ParseService struct {
+	timezones *TimezoneService
+	reminders *ReminderService
+}
+
+func NewParseService(timezones *TimezoneService, reminders *ReminderService) *ParseService {
+	return &ParseService{timezones: timezones, reminders: reminders}
+}
+
+// Parse reads req.Text for userID as of now in their timezone and builds
+// the reminder draft. It returns nlparse.ErrNoTime when the text names no
+// time.
+func (s *ParseService) Parse(ctx context.Context, userID string, req *models.ParseReminderRequest) (*ParsedReminder, error) {
+	loc, err := s.timezones.GetUserLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := nlparse.Parse(req.Text, time.Now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+
+	reminderType := req.Type
+	if reminderType == "" {
+		reminderType = models.ReminderTypeTask
+	}
+	return &ParsedReminder{
+		Result:   result,
+		Timezone: loc.String(),
+		Draft: &models.CreateReminderRequest{
+			UserID:      userID,
+			WorkspaceID: req.WorkspaceID,
+			ChannelID:   req.ChannelID,
+			MessageID:   req.MessageID,
+			Type:        reminderType,
+			Title:       result.Title,
+			RemindAt:    result.RemindAt,
+			Recurrence:  result.Recurrence,
+		},
+	}, nil
+}
+
+// Create creates the reminder parsed describes. Unless confirmed, a
+// reminder in the past or read with less than minParseConfidence is
+// refused with ErrParseUnconfirmed.
+func (s *ParseService) Create(ctx context.Context, parsed *ParsedReminder, confirmed bool) error {
+	if !confirmed {
+		switch {
+		case !parsed.RemindAt.After(time.Now()):
+			return fmt.Errorf("%w: %s is in the past", ErrParseUnconfirmed, parsed.RemindAt.Format(time.RFC3339))
+		case parsed.Confidence < minParseConfidence:
+			return fmt.Errorf("%w: confidence %.2f is below %.2f", ErrParseUnconfirmed, parsed.Confidence, minParseConfidence)
+		}
+	}
+
+	reminder, err := s.reminders.Create(ctx, parsed.Draft)
+	if err != nil {
+		return err
+	}
+	parsed.Reminder = reminder
+	return nil
+}
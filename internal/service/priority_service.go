@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,11 +13,11 @@ import (
 )
 
 type PriorityService struct {
-	collection *mongo.Collection
+	collection *audit.Collection
 }
 
-func NewPriorityService(db *mongo.Database) *PriorityService {
-	return &PriorityService{collection: db.Collection("reminders")}
+func NewPriorityService(db *mongo.Database, sink audit.Sink) *PriorityService {
+	return &PriorityService{collection: audit.Wrap(db.Collection("reminders"), "reminder", "_id", sink)}
 }
 
 func (s *PriorityService) SetPriority(ctx context.Context, reminderID string, priority models.ReminderPriority) error {
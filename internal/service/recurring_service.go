@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"reminder-service/internal/models"
+	"reminder-service/internal/recurrence"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,15 +15,39 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	// patternBackfillLimit caps how many missed occurrences one claim creates;
+	// the rest are picked up on the next pass.
+	patternBackfillLimit = 100
+	// patternSkipGrace is how late an occurrence may be and still be created
+	// under the skip policy.
+	patternSkipGrace = 15 * time.Minute
+)
+
+// ErrPatternLeaseLost is returned when another worker took over a pattern.
+var ErrPatternLeaseLost = errors.New("recurring pattern claimed by another worker")
+
 type RecurringService struct {
 	patterns    *mongo.Collection
 	occurrences *mongo.Collection
 }
 
 func NewRecurringService(db *mongo.Database) *RecurringService {
+	patterns := db.Collection("recurring_patterns")
+	occurrences := db.Collection("recurring_occurrences")
+	_, _ = patterns.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "is_active", Value: 1}, {Key: "next_occurrence", Value: 1}},
+	})
+	// One occurrence per scheduled time, so a retried materialization can't
+	// create the same reminder twice.
+	_, _ = occurrences.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "pattern_id", Value: 1}, {Key: "scheduled_at", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
 	return &RecurringService{
-		patterns:    db.Collection("recurring_patterns"),
-		occurrences: db.Collection("recurring_occurrences"),
+		patterns:    patterns,
+		occurrences: occurrences,
 	}
 }
 
@@ -31,6 +58,7 @@ func (s *RecurringService) CreatePattern(ctx context.Context, userID, workspaceI
 		Name:           req.Name,
 		Pattern:        req.Pattern,
 		Interval:       req.Interval,
+		RRule:          req.RRule,
 		DaysOfWeek:     req.DaysOfWeek,
 		DaysOfMonth:    req.DaysOfMonth,
 		MonthsOfYear:   req.MonthsOfYear,
@@ -43,11 +71,15 @@ func (s *RecurringService) CreatePattern(ctx context.Context, userID, workspaceI
 		ReminderTitle:  req.ReminderTitle,
 		ReminderType:   req.ReminderType,
 		ReminderDesc:   req.ReminderDesc,
+		CatchUp:        req.CatchUp,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
 
-	next := s.calculateNextOccurrence(pattern)
+	next, err := s.calculateNextOccurrence(pattern)
+	if err != nil {
+		return nil, err
+	}
 	pattern.NextOccurrence = next
 
 	result, err := s.patterns.InsertOne(ctx, pattern)
@@ -92,6 +124,17 @@ func (s *RecurringService) UpdatePattern(ctx context.Context, id string, req *mo
 		return nil, err
 	}
 
+	if req.RRule != nil && *req.RRule != "" {
+		if _, err := recurrence.Parse(*req.RRule); err != nil {
+			return nil, err
+		}
+	}
+	if req.Timezone != "" {
+		if _, err := recurrence.LoadLocation(req.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
 	update := bson.M{"updated_at": time.Now()}
 	if req.Name != "" {
 		update["name"] = req.Name
@@ -102,6 +145,9 @@ func (s *RecurringService) UpdatePattern(ctx context.Context, id string, req *mo
 	if req.Interval != nil {
 		update["interval"] = *req.Interval
 	}
+	if req.RRule != nil {
+		update["rrule"] = *req.RRule
+	}
 	if req.DaysOfWeek != nil {
 		update["days_of_week"] = req.DaysOfWeek
 	}
@@ -132,6 +178,9 @@ func (s *RecurringService) UpdatePattern(ctx context.Context, id string, req *mo
 	if req.ReminderDesc != "" {
 		update["reminder_desc"] = req.ReminderDesc
 	}
+	if req.CatchUp != "" {
+		update["catch_up"] = req.CatchUp
+	}
 
 	_, err = s.patterns.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": update})
 	if err != nil {
@@ -142,6 +191,19 @@ func (s *RecurringService) UpdatePattern(ctx context.Context, id string, req *mo
 	if err := s.patterns.FindOne(ctx, bson.M{"_id": objID}).Decode(&pattern); err != nil {
 		return nil, err
 	}
+
+	// Any schedule field may have changed, so recompute the next occurrence.
+	next, err := s.calculateNextOccurrence(&pattern)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.patterns.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{"next_occurrence": next},
+	})
+	if err != nil {
+		return nil, err
+	}
+	pattern.NextOccurrence = next
 	return &pattern, nil
 }
 
@@ -192,7 +254,15 @@ func (s *RecurringService) ListOccurrences(ctx context.Context, patternID string
 	return occs, nil
 }
 
+// RecordOccurrence logs the reminder created for an occurrence and counts
+// it against the pattern. last_triggered takes the occurrence's scheduled
+// time, which the next occurrence is computed from.
 func (s *RecurringService) RecordOccurrence(ctx context.Context, patternID, reminderID string, scheduledAt time.Time, occurrence int) error {
+	objID, err := objectIDFromHex(patternID)
+	if err != nil {
+		return ErrInvalidID
+	}
+
 	occ := &models.RecurringOccurrence{
 		PatternID:   patternID,
 		ReminderID:  reminderID,
@@ -201,19 +271,106 @@ func (s *RecurringService) RecordOccurrence(ctx context.Context, patternID, remi
 		Status:      "created",
 		CreatedAt:   time.Now(),
 	}
-	_, err := s.occurrences.InsertOne(ctx, occ)
-	if err != nil {
+	if _, err := s.occurrences.InsertOne(ctx, occ); err != nil {
 		return err
 	}
 
-	objID, _ := objectIDFromHex(patternID)
 	_, err = s.patterns.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
-		"$set": bson.M{"last_triggered": time.Now(), "updated_at": time.Now()},
+		"$max": bson.M{"last_triggered": scheduledAt},
+		"$set": bson.M{"updated_at": time.Now()},
 		"$inc": bson.M{"occurrence_count": 1},
 	})
 	return err
 }
 
+// ClaimDuePattern leases the active pattern with the oldest due occurrence.
+// It returns nil when nothing is due.
+func (s *RecurringService) ClaimDuePattern(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.RecurringPattern, error) {
+	filter := bson.M{
+		"is_active":       true,
+		"next_occurrence": bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"lease_expires_at": nil},
+			{"lease_expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"lease_owner": owner, "lease_expires_at": now.Add(lease)},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_occurrence", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var pattern models.RecurringPattern
+	err := s.patterns.FindOneAndUpdate(ctx, filter, update, opts).Decode(&pattern)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pattern, nil
+}
+
+// PlanOccurrences returns the occurrences of a claimed pattern that should be
+// materialized now, and the occurrence to wait for afterwards (nil once the
+// pattern is exhausted). Missed occurrences are backfilled or skipped
+// according to the pattern's catch-up policy.
+func (s *RecurringService) PlanOccurrences(pattern *models.RecurringPattern, now time.Time) ([]time.Time, *time.Time, error) {
+	if pattern.NextOccurrence == nil {
+		return nil, nil, nil
+	}
+	schedule, err := patternSchedule(pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	remaining := -1
+	if pattern.MaxOccurrences > 0 {
+		remaining = pattern.MaxOccurrences - pattern.OccurrenceCount
+	}
+
+	var due []time.Time
+	occ, ok := *pattern.NextOccurrence, true
+	cutoff := now.Add(-patternSkipGrace)
+	if pattern.CatchUp != models.CatchUpBackfill && occ.Before(cutoff) {
+		occ, ok = schedule.Next(cutoff)
+	}
+	for ok && remaining != 0 && !occ.After(now) && len(due) < patternBackfillLimit {
+		due = append(due, occ)
+		if remaining > 0 {
+			remaining--
+		}
+		occ, ok = schedule.Next(occ)
+	}
+
+	if !ok || remaining == 0 {
+		return due, nil, nil
+	}
+	return due, &occ, nil
+}
+
+// AdvancePattern moves a claimed pattern's next occurrence forward, and
+// releases the claim when release is set. It fails with ErrPatternLeaseLost
+// if the caller no longer holds the lease.
+func (s *RecurringService) AdvancePattern(ctx context.Context, id primitive.ObjectID, owner string, next *time.Time, release bool) error {
+	update := bson.M{
+		"$set": bson.M{"next_occurrence": next, "updated_at": time.Now()},
+	}
+	if release {
+		update["$unset"] = bson.M{"lease_owner": "", "lease_expires_at": ""}
+	}
+
+	result, err := s.patterns.UpdateOne(ctx, bson.M{"_id": id, "lease_owner": owner}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPatternLeaseLost
+	}
+	return nil
+}
+
 func (s *RecurringService) GetActivePatterns(ctx context.Context) ([]*models.RecurringPattern, error) {
 	now := time.Now()
 	filter := bson.M{
@@ -237,34 +394,61 @@ func (s *RecurringService) GetActivePatterns(ctx context.Context) ([]*models.Rec
 	return patterns, nil
 }
 
-func (s *RecurringService) calculateNextOccurrence(pattern *models.RecurringPattern) *time.Time {
-	var base time.Time
-	if pattern.LastTriggered != nil {
-		base = *pattern.LastTriggered
-	} else {
-		base = pattern.StartDate
+// calculateNextOccurrence returns nil once the pattern has run out of
+// occurrences. The first occurrence is the first match on or after the start
+// date; later ones follow the last trigger.
+func (s *RecurringService) calculateNextOccurrence(pattern *models.RecurringPattern) (*time.Time, error) {
+	if pattern.MaxOccurrences > 0 && pattern.OccurrenceCount >= pattern.MaxOccurrences {
+		return nil, nil
+	}
+
+	schedule, err := patternSchedule(pattern)
+	if err != nil {
+		return nil, err
 	}
 
 	var next time.Time
-	switch pattern.Pattern {
-	case "daily":
-		next = base.AddDate(0, 0, pattern.Interval)
-	case "weekly":
-		next = base.AddDate(0, 0, 7*pattern.Interval)
-	case "monthly":
-		next = base.AddDate(0, pattern.Interval, 0)
-	case "yearly":
-		next = base.AddDate(pattern.Interval, 0, 0)
+	ok := true
+	switch {
+	case pattern.LastTriggered != nil:
+		next, ok = schedule.Next(*pattern.LastTriggered)
+	case schedule.Matches(schedule.Start()):
+		next = schedule.Start()
 	default:
-		next = base.AddDate(0, 0, pattern.Interval)
+		next, ok = schedule.Next(schedule.Start())
 	}
+	if !ok {
+		return nil, nil
+	}
+	return &next, nil
+}
 
-	if pattern.EndDate != nil && next.After(*pattern.EndDate) {
-		return nil
+// patternSchedule builds the expander for a pattern. TimeOfDay is applied
+// to the start date in the pattern's timezone.
+func patternSchedule(pattern *models.RecurringPattern) (*recurrence.Schedule, error) {
+	rule, loc, err := seriesSpec{
+		rrule:        pattern.RRule,
+		pattern:      pattern.Pattern,
+		interval:     pattern.Interval,
+		daysOfWeek:   pattern.DaysOfWeek,
+		daysOfMonth:  pattern.DaysOfMonth,
+		monthsOfYear: pattern.MonthsOfYear,
+		endDate:      pattern.EndDate,
+		timezone:     pattern.Timezone,
+	}.rule()
+	if err != nil {
+		return nil, err
 	}
-	if pattern.MaxOccurrences > 0 && pattern.OccurrenceCount >= pattern.MaxOccurrences {
-		return nil
+
+	start := pattern.StartDate.In(loc)
+	if pattern.TimeOfDay != "" {
+		tod, err := time.Parse("15:04", pattern.TimeOfDay)
+		if err != nil {
+			return nil, fmt.Errorf("%w: time_of_day must be HH:MM", recurrence.ErrInvalidRule)
+		}
+		y, m, d := start.Date()
+		start = time.Date(y, m, d, tod.Hour(), tod.Minute(), 0, 0, loc)
 	}
 
-	return &next
+	return recurrence.NewSchedule(rule, start, nil), nil
 }
@@ -2,15 +2,28 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 
 	"reminder-service/internal/models"
+	"reminder-service/internal/query"
+	"reminder-service/internal/repository"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrInvalidFilter is returned for a filter expression that does not parse
+// or compile. It wraps a *query.Error with the position when there is one.
+var ErrInvalidFilter = errors.New("invalid filter")
+
 type SearchService struct {
 	collection *mongo.Collection
 }
@@ -19,40 +32,141 @@ func NewSearchService(db *mongo.Database) *SearchService {
 	return &SearchService{collection: db.Collection("reminders")}
 }
 
+// scoredReminder is a reminder decoded with its text score projected.
+type scoredReminder struct {
+	models.Reminder `bson:",inline"`
+	Score           float64 `bson:"score"`
+}
+
 func (s *SearchService) Search(ctx context.Context, params *models.ReminderSearchParams) (*models.PaginatedResponse, error) {
 	params.Validate()
 
-	filter := bson.M{}
+	filter, err := reminderFilter(ctx, s.collection.Database(), &params.ReminderFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.PageInfo{}
+	if params.WantsTotal() {
+		total, err := s.collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		info.Total = &total
+	}
+
+	// The text search combines q with any free text in the expression.
+	var search string
+	if text, ok := filter["$text"].(bson.M); ok {
+		search, _ = text["$search"].(string)
+	}
 
-	if params.UserID != "" {
-		filter["user_id"] = params.UserID
+	// Relevance has no stable key to page by, so its cursors hold an offset.
+	textScore := bson.M{"$meta": "textScore"}
+	sortBy := repository.PageOrder
+	relevance := params.Sort == models.SearchSortRelevance && search != ""
+	if relevance {
+		sortBy = bson.D{{Key: "score", Value: textScore}, {Key: "remind_at", Value: 1}, {Key: "_id", Value: 1}}
 	}
-	if params.WorkspaceID != "" {
-		filter["workspace_id"] = params.WorkspaceID
+
+	skip := params.Skip()
+	if params.Cursor != "" {
+		cursor, err := models.DecodeCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case cursor.IsOffset():
+			skip = cursor.Offset
+		case relevance:
+			return nil, models.ErrInvalidCursor
+		default:
+			skip = 0
+			filter = bson.M{"$and": []bson.M{filter, repository.AfterCursor(cursor)}}
+		}
 	}
-	if params.Status != "" {
-		filter["status"] = params.Status
+	limit := params.Limit()
+
+	opts := options.Find().
+		SetSort(sortBy).
+		SetSkip(skip).
+		SetLimit(limit + 1)
+	if search != "" {
+		opts.SetProjection(bson.M{"score": textScore})
 	}
-	if params.Type != "" {
-		filter["type"] = params.Type
+
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
 	}
-	if params.Query != "" {
-		filter["$or"] = []bson.M{
-			{"title": bson.M{"$regex": params.Query, "$options": "i"}},
-			{"description": bson.M{"$regex": params.Query, "$options": "i"}},
+	defer cursor.Close(ctx)
+
+	var reminders []scoredReminder
+	if err := cursor.All(ctx, &reminders); err != nil {
+		return nil, err
+	}
+
+	if int64(len(reminders)) > limit {
+		reminders = reminders[:limit]
+		last := &reminders[len(reminders)-1].Reminder
+		next := models.PageCursor{RemindAt: last.RemindAt, ID: last.ID}
+		if relevance {
+			next = models.PageCursor{Offset: skip + limit}
 		}
+		info.NextCursor = next.Encode()
+	}
+
+	terms := parseTextQuery(search)
+	hits := make([]models.SearchHit, 0, len(reminders))
+	for i := range reminders {
+		r := &reminders[i].Reminder
+		hits = append(hits, models.SearchHit{
+			Reminder:   r,
+			Score:      reminders[i].Score,
+			Highlights: terms.highlight(r),
+		})
+	}
+
+	return params.Response(hits, info), nil
+}
+
+// reminderFilter builds the Mongo filter for a ReminderFilter. Search and
+// export share it so both select the same reminders.
+func reminderFilter(ctx context.Context, db *mongo.Database, f *models.ReminderFilter) (bson.M, error) {
+	filter := bson.M{}
+
+	if f.UserID != "" {
+		filter["user_id"] = f.UserID
+	}
+	if f.WorkspaceID != "" {
+		filter["workspace_id"] = f.WorkspaceID
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.Type != "" {
+		filter["type"] = f.Type
+	}
+	if f.Priority != "" {
+		filter["priority"] = f.Priority
+	}
+	if f.CategoryID != "" {
+		filter["category_id"] = f.CategoryID
+	}
+	if strings.TrimSpace(f.Query) != "" {
+		filter["$text"] = bson.M{"$search": f.Query}
 	}
 
 	// Date range
-	if params.DateFrom != "" || params.DateTo != "" {
+	if f.DateFrom != "" || f.DateTo != "" {
 		dateFilter := bson.M{}
-		if params.DateFrom != "" {
-			if t, err := time.Parse("2006-01-02", params.DateFrom); err == nil {
+		if f.DateFrom != "" {
+			if t, err := time.Parse("2006-01-02", f.DateFrom); err == nil {
 				dateFilter["$gte"] = t
 			}
 		}
-		if params.DateTo != "" {
-			if t, err := time.Parse("2006-01-02", params.DateTo); err == nil {
+		if f.DateTo != "" {
+			if t, err := time.Parse("2006-01-02", f.DateTo); err == nil {
 				dateFilter["$lte"] = t
 			}
 		}
@@ -61,41 +175,485 @@ func (s *SearchService) Search(ctx context.Context, params *models.ReminderSearc
 		}
 	}
 
-	// Count
-	total, err := s.collection.CountDocuments(ctx, filter)
+	var sets []reminderIDSet
+	for _, tagID := range f.Tags {
+		found, err := db.Collection("reminder_tag_mappings").Distinct(ctx, "reminder_id", bson.M{"tag_id": tagID})
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, reminderIDSet{ids: found})
+	}
+	for _, label := range f.Labels {
+		found, err := db.Collection("reminder_labels").Distinct(ctx, "reminder_id", bson.M{"label": label})
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, reminderIDSet{ids: found})
+	}
+
+	if f.Expression != "" {
+		expr, err := compileExpression(f)
+		if err != nil {
+			return nil, err
+		}
+		conditions := expr.Conditions
+		for _, lookup := range expr.Lookups {
+			if lookup.Kind == query.LookupCategory {
+				cond, err := categoryCondition(ctx, db, f, lookup)
+				if err != nil {
+					return nil, err
+				}
+				conditions = append(conditions, cond)
+				continue
+			}
+			set, err := lookupReminderIDs(ctx, db, f, lookup)
+			if err != nil {
+				return nil, err
+			}
+			sets = append(sets, set)
+		}
+		if len(conditions) > 0 {
+			filter["$and"] = conditions
+		}
+		if expr.Text != "" {
+			search := strings.TrimSpace(f.Query + " " + expr.Text)
+			filter["$text"] = bson.M{"$search": search}
+		}
+	}
+
+	if idFilter := reminderIDFilter(sets); idFilter != nil {
+		filter["_id"] = idFilter
+	}
+	return filter, nil
+}
+
+// compileExpression compiles f.Expression, reading dates in f.Timezone.
+func compileExpression(f *models.ReminderFilter) (*query.Query, error) {
+	loc := time.UTC
+	if f.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(f.Timezone); err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidFilter, f.Timezone)
+		}
+	}
+	expr, err := query.Compile(f.Expression, query.Env{Now: time.Now(), Location: loc, Viewer: f.Viewer})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFilter, err)
 	}
+	return expr, nil
+}
 
-	skip := int64((params.Page - 1) * params.PerPage)
-	limit := int64(params.PerPage)
+// reminderIDSet is a set of reminder IDs, as hex strings, that a reminder
+// must be in, or not be in when negated.
+type reminderIDSet struct {
+	ids     []any
+	negated bool
+}
 
-	opts := options.Find().
-		SetSort(bson.D{{Key: "remind_at", Value: 1}}).
-		SetSkip(skip).
-		SetLimit(limit)
+// reminderIDFilter intersects the sets into an _id condition, or returns
+// nil when there are none.
+func reminderIDFilter(sets []reminderIDSet) bson.M {
+	if len(sets) == 0 {
+		return nil
+	}
 
-	cursor, err := s.collection.Find(ctx, filter, opts)
+	required := 0
+	counts := map[string]int{}
+	excluded := map[string]bool{}
+	for _, set := range sets {
+		if !set.negated {
+			required++
+		}
+		seen := map[string]bool{}
+		for _, v := range set.ids {
+			id, ok := v.(string)
+			if !ok || seen[id] {
+				continue
+			}
+			seen[id] = true
+			if set.negated {
+				excluded[id] = true
+			} else {
+				counts[id]++
+			}
+		}
+	}
+
+	toObjectIDs := func(ids []string) []primitive.ObjectID {
+		out := []primitive.ObjectID{}
+		for _, id := range ids {
+			if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+				out = append(out, oid)
+			}
+		}
+		return out
+	}
+
+	cond := bson.M{}
+	if required > 0 {
+		var in []string
+		for id, n := range counts {
+			if n == required && !excluded[id] {
+				in = append(in, id)
+			}
+		}
+		cond["$in"] = toObjectIDs(in)
+	}
+	if len(excluded) > 0 && required == 0 {
+		var nin []string
+		for id := range excluded {
+			nin = append(nin, id)
+		}
+		cond["$nin"] = toObjectIDs(nin)
+	}
+	if len(cond) == 0 {
+		return nil
+	}
+	return cond
+}
+
+// lookupReminderIDs resolves a tag, label or assignee term to the
+// reminders it matches.
+func lookupReminderIDs(ctx context.Context, db *mongo.Database, f *models.ReminderFilter, lookup query.Lookup) (reminderIDSet, error) {
+	set := reminderIDSet{negated: lookup.Negated}
+	var err error
+	switch lookup.Kind {
+	case query.LookupTag:
+		var tagIDs []string
+		tagIDs, err = resolveNamed(ctx, db.Collection("reminder_tags"), f, lookup.Values)
+		if err == nil {
+			set.ids, err = db.Collection("reminder_tag_mappings").Distinct(ctx, "reminder_id", bson.M{"tag_id": bson.M{"$in": tagIDs}})
+		}
+	case query.LookupLabel:
+		set.ids, err = db.Collection("reminder_labels").Distinct(ctx, "reminder_id", bson.M{"label": bson.M{"$in": lookup.Values}})
+	case query.LookupAssignee:
+		set.ids, err = db.Collection("reminder_delegations").Distinct(ctx, "reminder_id", bson.M{
+			"delegated_to": bson.M{"$in": lookup.Values},
+			"status":       bson.M{"$ne": models.DelegationRejected},
+		})
+	}
+	return set, err
+}
+
+func categoryCondition(ctx context.Context, db *mongo.Database, f *models.ReminderFilter, lookup query.Lookup) (bson.M, error) {
+	ids, err := resolveNamed(ctx, db.Collection("reminder_categories"), f, lookup.Values)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
+	op := "$in"
+	if lookup.Negated {
+		op = "$nin"
+	}
+	return bson.M{"category_id": bson.M{op: ids}}, nil
+}
 
-	var reminders []*models.Reminder
-	if err := cursor.All(ctx, &reminders); err != nil {
+// resolveNamed maps tag or category names, case-insensitively and within
+// the filter's user or workspace, to IDs. Values that are already IDs are
+// kept as they are.
+func resolveNamed(ctx context.Context, coll *mongo.Collection, f *models.ReminderFilter, values []string) ([]string, error) {
+	ids := []string{}
+	var names []any
+	for _, v := range values {
+		if primitive.IsValidObjectID(v) {
+			ids = append(ids, v)
+			continue
+		}
+		names = append(names, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(v) + "$", Options: "i"})
+	}
+	if len(names) == 0 {
+		return ids, nil
+	}
+
+	filter := bson.M{"name": bson.M{"$in": names}}
+	if f.UserID != "" {
+		filter["user_id"] = f.UserID
+	}
+	if f.WorkspaceID != "" {
+		filter["workspace_id"] = f.WorkspaceID
+	}
+	found, err := coll.Distinct(ctx, "_id", filter)
+	if err != nil {
 		return nil, err
 	}
+	for _, v := range found {
+		if oid, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, oid.Hex())
+		}
+	}
+	return ids, nil
+}
+
+// ── Search Text ──
+
+// refreshSearchText copies the content of a reminder's notes or comments,
+// held in source, onto the reminder's field for the text index.
+func refreshSearchText(ctx context.Context, db *mongo.Database, reminderID, source, field string) error {
+	objID, err := primitive.ObjectIDFromHex(reminderID)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := db.Collection(source).Find(ctx, bson.M{"reminder_id": reminderID},
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetProjection(bson.M{"content": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		Content string `bson:"content"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{field: ""}}
+	if len(docs) > 0 {
+		contents := make([]string, 0, len(docs))
+		for _, d := range docs {
+			contents = append(contents, d.Content)
+		}
+		update = bson.M{"$set": bson.M{field: contents}}
+	}
+	_, err = db.Collection("reminders").UpdateOne(ctx, bson.M{"_id": objID}, update)
+	return err
+}
+
+// Reindex rebuilds the note and comment text of every reminder that has
+// notes or comments, for data written before they were indexed. It returns
+// how many note and comment lists were rewritten.
+func (s *SearchService) Reindex(ctx context.Context) (int, error) {
+	db := s.collection.Database()
+	refreshed := 0
+	for source, field := range map[string]string{
+		"reminder_notes":    "search_notes",
+		"reminder_comments": "search_comments",
+	} {
+		ids, err := db.Collection(source).Distinct(ctx, "reminder_id", bson.M{})
+		if err != nil {
+			return refreshed, err
+		}
+		for _, v := range ids {
+			id, ok := v.(string)
+			if !ok || !primitive.IsValidObjectID(id) {
+				continue
+			}
+			if err := refreshSearchText(ctx, db, id, source, field); err != nil {
+				return refreshed, err
+			}
+			refreshed++
+		}
+	}
+	return refreshed, nil
+}
+
+// ── Highlights ──
+
+const (
+	snippetLength  = 160
+	snippetContext = 40
+)
+
+// textQuery is a text search split the way $text reads it. Negated words
+// and phrases are dropped: they never appear in a match.
+type textQuery struct {
+	terms   []string
+	phrases [][]rune
+}
+
+func parseTextQuery(q string) textQuery {
+	var tq textQuery
+	for len(q) > 0 {
+		q = strings.TrimLeftFunc(q, unicode.IsSpace)
+		negated := strings.HasPrefix(q, "-")
+		if negated {
+			q = q[1:]
+		}
+
+		if strings.HasPrefix(q, `"`) {
+			phrase, rest := q[1:], ""
+			if end := strings.IndexByte(phrase, '"'); end >= 0 {
+				phrase, rest = phrase[:end], phrase[end+1:]
+			}
+			q = rest
+			if p := strings.TrimSpace(phrase); p != "" && !negated {
+				tq.phrases = append(tq.phrases, lowerRunes(p))
+			}
+			continue
+		}
+
+		end := strings.IndexFunc(q, unicode.IsSpace)
+		if end < 0 {
+			end = len(q)
+		}
+		word := q[:end]
+		q = q[end:]
+		if negated {
+			continue
+		}
+		for _, w := range strings.FieldsFunc(strings.ToLower(word), notWordRune) {
+			tq.terms = append(tq.terms, stem(w))
+		}
+	}
+	return tq
+}
+
+// highlight returns a snippet for each field of r that matches the query.
+// Only the first matching note and comment are shown.
+func (q textQuery) highlight(r *models.Reminder) []models.SearchHighlight {
+	if len(q.terms) == 0 && len(q.phrases) == 0 {
+		return nil
+	}
+
+	var out []models.SearchHighlight
+	add := func(field string, texts ...string) {
+		for _, text := range texts {
+			if h, ok := q.snippet(field, text); ok {
+				out = append(out, h)
+				return
+			}
+		}
+	}
+	add("title", r.Title)
+	add("description", r.Description)
+	add("notes", r.SearchNotes...)
+	add("comments", r.SearchComments...)
+	return out
+}
+
+func (q textQuery) snippet(field, text string) (models.SearchHighlight, bool) {
+	runes := []rune(text)
+	matches := q.matches(runes)
+	if len(matches) == 0 {
+		return models.SearchHighlight{}, false
+	}
+
+	start, end := 0, len(runes)
+	if len(runes) > snippetLength {
+		first := matches[0]
+		start = max(first[0]-snippetContext, 0)
+		end = min(start+snippetLength, len(runes))
+		start = max(end-snippetLength, 0)
+		// Move the edges to word boundaries without cutting into the first
+		// match.
+		for start > 0 && start < first[0] && !unicode.IsSpace(runes[start-1]) {
+			start++
+		}
+		for end < len(runes) && end > first[1] && !unicode.IsSpace(runes[end]) {
+			end--
+		}
+		for start < first[0] && unicode.IsSpace(runes[start]) {
+			start++
+		}
+		for end > first[1] && unicode.IsSpace(runes[end-1]) {
+			end--
+		}
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(runes) {
+		suffix = "…"
+	}
 
-	totalPages := 0
-	if total > 0 {
-		totalPages = int((total + limit - 1) / limit)
+	h := models.SearchHighlight{
+		Field:   field,
+		Snippet: prefix + string(runes[start:end]) + suffix,
+	}
+	offset := len([]rune(prefix)) - start
+	for _, m := range matches {
+		if m[0] < start || m[1] > end {
+			continue
+		}
+		h.Matches = append(h.Matches, [2]int{m[0] + offset, m[1] + offset})
 	}
+	return h, true
+}
+
+// matches returns the merged [start, end) rune ranges of text that match a
+// term or phrase. Words match a term when their stems are equal, mirroring
+// the stemming of the text index closely enough to point at the hit.
+func (q textQuery) matches(text []rune) [][2]int {
+	lower := lowerRunes(string(text))
+	var ranges [][2]int
 
-	return &models.PaginatedResponse{
-		Data:       reminders,
-		Total:      total,
-		Page:       params.Page,
-		PerPage:    params.PerPage,
-		TotalPages: totalPages,
-	}, nil
+	for _, phrase := range q.phrases {
+		for i := 0; i+len(phrase) <= len(lower); i++ {
+			if string(lower[i:i+len(phrase)]) == string(phrase) {
+				ranges = append(ranges, [2]int{i, i + len(phrase)})
+			}
+		}
+	}
+
+	if len(q.terms) > 0 {
+		for i := 0; i < len(lower); {
+			if notWordRune(lower[i]) {
+				i++
+				continue
+			}
+			j := i
+			for j < len(lower) && !notWordRune(lower[j]) {
+				j++
+			}
+			word := stem(string(lower[i:j]))
+			for _, t := range q.terms {
+				if word == t {
+					ranges = append(ranges, [2]int{i, j})
+					break
+				}
+			}
+			i = j
+		}
+	}
+
+	if len(ranges) == 0 {
+		return nil
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	merged := [][2]int{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r[0] <= last[1] {
+			last[1] = max(last[1], r[1])
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// stem strips common English inflections so "meetings" finds "meeting" and
+// "meet".
+func stem(w string) string {
+	for {
+		trimmed := w
+		for _, suffix := range []string{"ing", "ed", "s"} {
+			if len(w) > len(suffix)+2 && strings.HasSuffix(w, suffix) {
+				trimmed = strings.TrimSuffix(w, suffix)
+				break
+			}
+		}
+		if trimmed == w {
+			return w
+		}
+		w = trimmed
+	}
+}
+
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// lowerRunes lower-cases rune by rune so offsets into the result are offsets
+// into s.
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
 }
@@ -2,31 +2,76 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"reminder-service/internal/models"
+	"reminder-service/internal/recurrence"
 	"reminder-service/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// EventPublisher interface for publishing events
-type EventPublisher interface {
-	Publish(topic string, message interface{}) error
-}
+// ErrLeaseLost is returned when a reminder was triggered by someone else while
+// the caller was working on it.
+var ErrLeaseLost = errors.New("reminder already triggered by another worker")
+
+// ErrNotificationDeferred is returned by TriggerReminder when the user is in
+// quiet hours. The reminder is back to pending with a later notify_at.
+var ErrNotificationDeferred = errors.New("notification deferred until quiet hours end")
+
+// ErrNotTriggered is returned when acknowledging a reminder that has not
+// been triggered.
+var ErrNotTriggered = errors.New("reminder has not been triggered")
 
 type ReminderService struct {
-	repo     repository.Repository
-	producer EventPublisher
+	repo          repository.Repository
+	notifications *NotificationService
+	escalations   *EscalationService
+	dependencies  *DependencyService
+	snoozes       *SnoozeService
+	deliveries    *DeliveryService
 }
 
-func NewReminderService(repo repository.Repository, producer EventPublisher) *ReminderService {
-	return &ReminderService{
-		repo:     repo,
-		producer: producer,
+func NewReminderService(repo repository.Repository, notifications *NotificationService, escalations *EscalationService, dependencies *DependencyService, snoozes *SnoozeService, deliveries *DeliveryService) *ReminderService {
+	return &ReminderService{repo: repo, notifications: notifications, escalations: escalations, dependencies: dependencies, snoozes: snoozes, deliveries: deliveries}
+}
+
+// WithTransaction runs fn in a transaction. Service calls made with the
+// callback's context join it, across services sharing the database.
+func (s *ReminderService) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.repo.WithTransaction(ctx, fn)
+}
+
+// emit records an event in the outbox as part of the caller's transaction.
+// The outbox relay publishes it to Kafka once the transaction commits.
+func (s *ReminderService) emit(ctx context.Context, topic, key, dedupeKey string, payload map[string]any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
 	}
+	return s.repo.EnqueueEvent(ctx, &models.OutboxEvent{
+		Topic:     topic,
+		Key:       key,
+		DedupeKey: dedupeKey,
+		Payload:   string(data),
+	})
 }
 
 func (s *ReminderService) Create(ctx context.Context, req *models.CreateReminderRequest) (*models.Reminder, error) {
+	rec, err := seriesRecurrence(req.Recurrence, req.RemindAt)
+	if err != nil {
+		return nil, err
+	}
+	pref, err := s.notifications.GetPreferences(ctx, req.UserID, req.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
 	reminder := &models.Reminder{
 		UserID:      req.UserID,
 		WorkspaceID: req.WorkspaceID,
@@ -36,23 +81,54 @@ func (s *ReminderService) Create(ctx context.Context, req *models.CreateReminder
 		Title:       req.Title,
 		Description: req.Description,
 		RemindAt:    req.RemindAt,
-		Recurrence:  req.Recurrence,
+		CategoryID:  req.CategoryID,
+		Recurrence:  rec,
 		Metadata:    req.Metadata,
+		NotifyAt:    advanceNotifyAt(pref, req.RemindAt),
 	}
+	if err := s.insert(ctx, reminder); err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
 
-	if err := s.repo.Create(ctx, reminder); err != nil {
-		return nil, fmt.Errorf("failed to create reminder: %w", err)
+// Import creates a reminder read from another calendar. It keeps the
+// priority, external UID and alarm the calendar gave it; without an alarm
+// NotifyAt follows the user's advance notice, as for Create.
+func (s *ReminderService) Import(ctx context.Context, reminder *models.Reminder) error {
+	if reminder.Title == "" || reminder.Type == "" || reminder.WorkspaceID == "" || reminder.RemindAt.IsZero() {
+		return errMissingImportFields
 	}
+	rec, err := seriesRecurrence(reminder.Recurrence, reminder.RemindAt)
+	if err != nil {
+		return err
+	}
+	reminder.Recurrence = rec
+	if reminder.NotifyAt == nil {
+		pref, err := s.notifications.GetPreferences(ctx, reminder.UserID, reminder.WorkspaceID)
+		if err != nil {
+			return err
+		}
+		reminder.NotifyAt = advanceNotifyAt(pref, reminder.RemindAt)
+	}
+	return s.insert(ctx, reminder)
+}
 
-	// Publish event
-	s.producer.Publish("reminders.created", map[string]any{
-		"reminder_id":  reminder.ID.Hex(),
-		"user_id":      reminder.UserID,
-		"workspace_id": reminder.WorkspaceID,
-		"remind_at":    reminder.RemindAt,
-	})
+// insert stores a new reminder and announces it, in one transaction.
+func (s *ReminderService) insert(ctx context.Context, reminder *models.Reminder) error {
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, reminder); err != nil {
+			return fmt.Errorf("failed to create reminder: %w", err)
+		}
 
-	return reminder, nil
+		return s.emit(ctx, "reminders.created", reminder.ID.Hex(), "", map[string]any{
+			"reminder_id":  reminder.ID.Hex(),
+			"user_id":      reminder.UserID,
+			"workspace_id": reminder.WorkspaceID,
+			"remind_at":    reminder.RemindAt,
+		})
+	})
 }
 
 func (s *ReminderService) GetByID(ctx context.Context, id string) (*models.Reminder, error) {
@@ -64,54 +140,146 @@ func (s *ReminderService) GetByUserID(ctx context.Context, userID string, status
 }
 
 func (s *ReminderService) Update(ctx context.Context, id string, req *models.UpdateReminderRequest) (*models.Reminder, error) {
-	if err := s.repo.Update(ctx, id, req); err != nil {
-		return nil, fmt.Errorf("failed to update reminder: %w", err)
+	copied := *req
+	req = &copied
+
+	if req.Recurrence != nil {
+		start := time.Time{}
+		if req.RemindAt != nil {
+			start = *req.RemindAt
+		}
+		rec, err := seriesRecurrence(req.Recurrence, start)
+		if err != nil {
+			return nil, err
+		}
+		req.Recurrence = rec
+	}
+	if req.RemindAt != nil && req.NotifyAt == nil {
+		existing, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		pref, err := s.notifications.GetPreferences(ctx, existing.UserID, existing.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		req.NotifyAt = advanceNotifyAt(pref, *req.RemindAt)
+	}
+	completing := req.Status == models.StatusCompleted
+	if completing {
+		if err := s.dependencies.CheckCompletion(ctx, id); err != nil {
+			return nil, err
+		}
 	}
 
-	reminder, err := s.repo.GetByID(ctx, id)
+	// The status goes through the state machine; the other fields are
+	// written as they are.
+	status := req.Status
+	req.Status = ""
+
+	var reminder *models.Reminder
+	err := s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if status != "" {
+			existing, err := s.repo.GetByID(ctx, id)
+			if err != nil {
+				return err
+			}
+			if err := checkRequestedStatus(existing.Status, status); err != nil {
+				return err
+			}
+			if err := s.setStatus(ctx, existing, status); err != nil {
+				return err
+			}
+		}
+		if err := s.repo.Update(ctx, id, req); err != nil {
+			return fmt.Errorf("failed to update reminder: %w", err)
+		}
+		if completing {
+			if err := s.releaseDependents(ctx, id, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		reminder, err = s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.emit(ctx, "reminders.updated", id, "", map[string]any{
+			"reminder_id": id,
+			"user_id":     reminder.UserID,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Publish event
-	s.producer.Publish("reminders.updated", map[string]any{
-		"reminder_id": id,
-		"user_id":     reminder.UserID,
-	})
-
 	return reminder, nil
 }
 
-func (s *ReminderService) Snooze(ctx context.Context, id string, duration time.Duration) (*models.Reminder, error) {
+// Snooze moves a reminder to duration from now and records the snooze in
+// its history, in one transaction. snoozedBy defaults to the reminder's
+// owner. The reminder stays snoozed until it is due again.
+func (s *ReminderService) Snooze(ctx context.Context, id string, duration time.Duration, snoozedBy string, source models.SnoozeSource) (*models.Reminder, error) {
 	reminder, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkTransition(reminder.Status, models.StatusSnoozed); err != nil {
+		return nil, err
+	}
+	if snoozedBy == "" {
+		snoozedBy = reminder.UserID
+	}
+	pref, err := s.notifications.GetPreferences(ctx, reminder.UserID, reminder.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
 
-	newRemindAt := time.Now().Add(duration)
+	now := time.Now()
+	newRemindAt := now.Add(duration)
 	update := &models.UpdateReminderRequest{
 		RemindAt: &newRemindAt,
-		Status:   models.StatusSnoozed,
+		NotifyAt: advanceNotifyAt(pref, newRemindAt),
 	}
 
-	if err := s.repo.Update(ctx, id, update); err != nil {
-		return nil, fmt.Errorf("failed to snooze reminder: %w", err)
-	}
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.setStatus(ctx, reminder, models.StatusSnoozed); err != nil {
+			return err
+		}
+		if err := s.repo.Update(ctx, id, update); err != nil {
+			return fmt.Errorf("failed to snooze reminder: %w", err)
+		}
+		if err := s.escalations.CancelPending(ctx, id); err != nil {
+			return err
+		}
+		err := s.snoozes.Record(ctx, &ReminderSnoozeHistory{
+			ReminderID:   id,
+			UserID:       snoozedBy,
+			Source:       source,
+			SnoozedAt:    now,
+			Duration:     duration.String(),
+			PreviousTime: reminder.RemindAt,
+			NewTime:      newRemindAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record snooze: %w", err)
+		}
 
-	// Reset to pending after snooze update
-	if err := s.repo.UpdateStatus(ctx, id, models.StatusPending); err != nil {
+		return s.emit(ctx, "reminders.snoozed", id, "", map[string]any{
+			"reminder_id": id,
+			"user_id":     reminder.UserID,
+			"snoozed_by":  snoozedBy,
+			"new_time":    newRemindAt,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	reminder.RemindAt = newRemindAt
-	reminder.Status = models.StatusPending
-
-	// Publish event
-	s.producer.Publish("reminders.snoozed", map[string]any{
-		"reminder_id": id,
-		"user_id":     reminder.UserID,
-		"new_time":    newRemindAt,
-	})
+	reminder.Status = models.StatusSnoozed
 
 	return reminder, nil
 }
@@ -122,17 +290,19 @@ func (s *ReminderService) Cancel(ctx context.Context, id string) error {
 		return err
 	}
 
-	if err := s.repo.UpdateStatus(ctx, id, models.StatusCancelled); err != nil {
-		return fmt.Errorf("failed to cancel reminder: %w", err)
-	}
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.setStatus(ctx, reminder, models.StatusCancelled); err != nil {
+			return err
+		}
+		if err := s.escalations.CancelPending(ctx, id); err != nil {
+			return err
+		}
 
-	// Publish event
-	s.producer.Publish("reminders.cancelled", map[string]any{
-		"reminder_id": id,
-		"user_id":     reminder.UserID,
+		return s.emit(ctx, "reminders.cancelled", id, "", map[string]any{
+			"reminder_id": id,
+			"user_id":     reminder.UserID,
+		})
 	})
-
-	return nil
 }
 
 func (s *ReminderService) Delete(ctx context.Context, id string) error {
@@ -141,174 +311,670 @@ func (s *ReminderService) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	if err := s.repo.Delete(ctx, id); err != nil {
-		return fmt.Errorf("failed to delete reminder: %w", err)
-	}
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete reminder: %w", err)
+		}
+		if err := s.escalations.CancelPending(ctx, id); err != nil {
+			return err
+		}
+		if err := s.dependencies.Forget(ctx, id); err != nil {
+			return err
+		}
 
-	// Publish event
-	s.producer.Publish("reminders.deleted", map[string]any{
-		"reminder_id": id,
-		"user_id":     reminder.UserID,
+		return s.emit(ctx, "reminders.deleted", id, "", map[string]any{
+			"reminder_id": id,
+			"user_id":     reminder.UserID,
+		})
 	})
-
-	return nil
 }
 
+// TriggerReminder marks the reminder triggered, queues its notifications and
+// schedules the next recurrence in a single transaction, so a notification
+// goes out exactly once per occurrence and channel.
+//
+// The user's preferences apply: during quiet hours the reminder is deferred
+// (ErrNotificationDeferred) unless it is urgent and the user lets urgent
+// reminders through, and disabled preferences trigger without sending.
+// With re-notification on, the first repeat is scheduled too.
 func (s *ReminderService) TriggerReminder(ctx context.Context, reminder *models.Reminder) error {
-	if err := s.repo.UpdateStatus(ctx, reminder.ID.Hex(), models.StatusTriggered); err != nil {
-		return fmt.Errorf("failed to trigger reminder: %w", err)
-	}
-
-	// Publish notification event
-	s.producer.Publish("notifications.send", map[string]any{
-		"type":        "reminder",
-		"user_id":     reminder.UserID,
-		"title":       reminder.Title,
-		"description": reminder.Description,
-		"reminder_id": reminder.ID.Hex(),
-		"channel_id":  reminder.ChannelID,
-		"message_id":  reminder.MessageID,
-		"metadata":    reminder.Metadata,
-	})
+	pref, err := s.notifications.GetPreferences(ctx, reminder.UserID, reminder.WorkspaceID)
+	if err != nil {
+		return err
+	}
 
-	// Handle recurrence
-	if reminder.Recurrence != nil {
-		return s.scheduleNextRecurrence(ctx, reminder)
+	bypass := reminder.Priority == models.PriorityUrgent && pref.UrgentBypass
+	if pref.Enabled && !bypass {
+		if until, quiet := quietHoursEnd(pref, time.Now()); quiet {
+			// A claimed reminder goes back to the status it was claimed from.
+			from, to := reminder.Status, reminder.Status
+			if from == models.StatusProcessing {
+				to = reminder.ClaimedFrom
+				if to == "" {
+					to = models.StatusPending
+				}
+				if err := checkTransition(from, to); err != nil {
+					return err
+				}
+			}
+			won, err := s.repo.DeferNotification(ctx, reminder.ID.Hex(), reminder.LeaseOwner, from, to, until)
+			if err != nil {
+				return fmt.Errorf("failed to defer reminder: %w", err)
+			}
+			if !won {
+				return ErrLeaseLost
+			}
+			return ErrNotificationDeferred
+		}
 	}
 
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := checkTransition(reminder.Status, models.StatusTriggered); err != nil {
+			return err
+		}
+		now := time.Now()
+		won, err := s.repo.MarkTriggered(ctx, reminder.ID.Hex(), reminder.LeaseOwner, reminder.Status, now)
+		if err != nil {
+			return fmt.Errorf("failed to trigger reminder: %w", err)
+		}
+		if !won {
+			return ErrLeaseLost
+		}
+		reminder.TriggeredAt = &now
+
+		if !pref.Enabled {
+			log.Printf("Notifications disabled for user %s, not sending reminder %s", reminder.UserID, reminder.ID.Hex())
+		} else {
+			if err := s.sendNotifications(ctx, reminder, pref, 0, now); err != nil {
+				return err
+			}
+			if next := nextRenotify(pref, now, 0); next != nil {
+				if err := s.repo.SetRenotify(ctx, reminder.ID.Hex(), next, 0); err != nil {
+					return fmt.Errorf("failed to schedule re-notification: %w", err)
+				}
+			}
+		}
+
+		if err := s.escalations.Schedule(ctx, reminder, now); err != nil {
+			return fmt.Errorf("failed to schedule escalations: %w", err)
+		}
+
+		// Handle recurrence
+		if reminder.Recurrence != nil {
+			return s.scheduleNextRecurrence(ctx, reminder, pref)
+		}
+
+		return nil
+	})
+}
+
+// sendNotifications queues a notification of reminder on each of the
+// user's channels and records it as a delivery attempt. Attempt 0 is the
+// trigger; re-notifications count up from 1. The outbox dedupe key names the
+// trigger, so a reminder set back to pending and triggered again at the
+// same remind_at is notified again.
+func (s *ReminderService) sendNotifications(ctx context.Context, reminder *models.Reminder, pref *models.NotificationPreference, attempt int, now time.Time) error {
+	for _, channel := range deliveryChannels(pref) {
+		delivery, err := s.deliveries.Record(ctx, reminder, channel, attempt, now)
+		if err != nil {
+			return fmt.Errorf("failed to record delivery: %w", err)
+		}
+
+		dedupeKey := fmt.Sprintf("notifications.send:%s:%d:%s", reminder.ID.Hex(), reminder.TriggeredAt.UnixMilli(), channel)
+		if attempt > 0 {
+			dedupeKey += fmt.Sprintf(":%d", attempt)
+		}
+		err = s.emit(ctx, "notifications.send", reminder.ID.Hex(), dedupeKey, map[string]any{
+			"type":         "reminder",
+			"channel":      channel,
+			"user_id":      reminder.UserID,
+			"workspace_id": reminder.WorkspaceID,
+			"title":        reminder.Title,
+			"description":  reminder.Description,
+			"priority":     reminder.Priority,
+			"reminder_id":  reminder.ID.Hex(),
+			"remind_at":    reminder.RemindAt,
+			"channel_id":   reminder.ChannelID,
+			"message_id":   reminder.MessageID,
+			"metadata":     reminder.Metadata,
+			"delivery_id":  delivery.ID.Hex(),
+			"attempt":      attempt,
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (s *ReminderService) scheduleNextRecurrence(ctx context.Context, reminder *models.Reminder) error {
-	nextTime := calculateNextOccurrence(reminder.RemindAt, reminder.Recurrence)
+func (s *ReminderService) scheduleNextRecurrence(ctx context.Context, reminder *models.Reminder, pref *models.NotificationPreference) error {
+	rec := *reminder.Recurrence
+	if rec.Start == nil {
+		start := reminder.RemindAt
+		rec.Start = &start
+	}
+	if rec.Occurrence == 0 {
+		rec.Occurrence = 1
+	}
 
-	if reminder.Recurrence.EndDate != nil && nextTime.After(*reminder.Recurrence.EndDate) {
+	schedule, err := reminderSchedule(&rec, *rec.Start)
+	if err != nil {
+		// The rule was validated on write; don't fail the trigger over it.
+		log.Printf("Stopping recurrence of reminder %s: %v", reminder.ID.Hex(), err)
+		return nil
+	}
+
+	nextTime, ok := schedule.Next(reminder.RemindAt)
+	if !ok {
 		return nil // End of recurrence
 	}
+	rec.Occurrence++
 
 	newReminder := &models.Reminder{
 		UserID:      reminder.UserID,
 		WorkspaceID: reminder.WorkspaceID,
 		ChannelID:   reminder.ChannelID,
 		MessageID:   reminder.MessageID,
+		ExternalUID: reminder.ExternalUID,
 		Type:        reminder.Type,
 		Title:       reminder.Title,
 		Description: reminder.Description,
+		Priority:    reminder.Priority,
+		CategoryID:  reminder.CategoryID,
 		RemindAt:    nextTime,
-		Recurrence:  reminder.Recurrence,
+		Recurrence:  &rec,
 		Metadata:    reminder.Metadata,
+		NotifyAt:    advanceNotifyAt(pref, nextTime),
 	}
 
 	return s.repo.Create(ctx, newReminder)
 }
 
-func calculateNextOccurrence(current time.Time, recurrence *models.Recurrence) time.Time {
-	switch recurrence.Pattern {
-	case "daily":
-		return current.AddDate(0, 0, recurrence.Interval)
-	case "weekly":
-		return current.AddDate(0, 0, 7*recurrence.Interval)
-	case "monthly":
-		return current.AddDate(0, recurrence.Interval, 0)
-	case "yearly":
-		return current.AddDate(recurrence.Interval, 0, 0)
+// ── Escalation ──
+
+// ExecuteEscalation runs a claimed escalation event and records the outcome
+// in the same transaction. Events for reminders that were completed,
+// cancelled or deleted are skipped, and so are those for acknowledged
+// reminders unless the action runs while the reminder is open.
+func (s *ReminderService) ExecuteEscalation(ctx context.Context, event *models.EscalationEvent) error {
+	reminder, err := s.repo.GetByID(ctx, event.ReminderID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return s.escalations.FinishEvent(ctx, event, models.EscalationSkipped, "reminder deleted")
+	}
+	if err != nil {
+		return err
+	}
+	if reason := escalationStopReason(reminder, event.Action); reason != "" {
+		return s.escalations.FinishEvent(ctx, event, models.EscalationSkipped, reason)
+	}
+
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		action := event.Action
+		message := action.Message
+		if message == "" {
+			message = fmt.Sprintf("Reminder still open: %s", reminder.Title)
+		}
+		dedupeKey := "escalation:" + event.ID.Hex()
+		payload := map[string]any{
+			"type":          "escalation",
+			"reminder_id":   reminder.ID.Hex(),
+			"workspace_id":  reminder.WorkspaceID,
+			"title":         reminder.Title,
+			"message":       message,
+			"priority":      reminder.Priority,
+			"escalation_id": event.ID.Hex(),
+		}
+
+		var err error
+		switch action.Type {
+		case models.EscalationNotify:
+			if action.Target == "" {
+				return s.escalations.FinishEvent(ctx, event, models.EscalationFailed, "notify needs a target user")
+			}
+			payload["user_id"] = action.Target
+			payload["on_behalf_of"] = reminder.UserID
+			err = s.emit(ctx, "notifications.send", reminder.ID.Hex(), dedupeKey, payload)
+		case models.EscalationRenotify:
+			payload["user_id"] = reminder.UserID
+			err = s.emit(ctx, "notifications.send", reminder.ID.Hex(), dedupeKey, payload)
+		case models.EscalationPostChannel:
+			channelID := action.Target
+			if channelID == "" {
+				channelID = reminder.ChannelID
+			}
+			if channelID == "" {
+				return s.escalations.FinishEvent(ctx, event, models.EscalationFailed, "post_channel needs a channel")
+			}
+			payload["user_id"] = reminder.UserID
+			payload["channel_id"] = channelID
+			err = s.emit(ctx, "notifications.channel_post", channelID, dedupeKey, payload)
+		case models.EscalationBumpPriority:
+			priority := bumpPriority(reminder.Priority, models.ReminderPriority(action.Target))
+			if priority == reminder.Priority {
+				return s.escalations.FinishEvent(ctx, event, models.EscalationSkipped, "priority already at "+string(priority))
+			}
+			if err := s.repo.Update(ctx, reminder.ID.Hex(), &models.UpdateReminderRequest{Priority: priority}); err != nil {
+				return err
+			}
+			err = s.emit(ctx, "reminders.updated", reminder.ID.Hex(), dedupeKey, map[string]any{
+				"reminder_id": reminder.ID.Hex(),
+				"user_id":     reminder.UserID,
+				"priority":    priority,
+			})
+		default:
+			return s.escalations.FinishEvent(ctx, event, models.EscalationFailed, "unknown action "+action.Type)
+		}
+		if err != nil {
+			return err
+		}
+
+		return s.escalations.FinishEvent(ctx, event, models.EscalationExecuted, "")
+	})
+}
+
+func escalationStopReason(reminder *models.Reminder, action models.EscalationAction) string {
+	switch {
+	case reminder.Status == models.StatusCompleted:
+		return "reminder completed"
+	case reminder.Status == models.StatusCancelled:
+		return "reminder cancelled"
+	case reminder.Status == models.StatusArchived:
+		return "reminder archived"
+	case reminder.AcknowledgedAt != nil && action.Condition != models.EscalationWhenOpen:
+		return "reminder acknowledged"
+	}
+	return ""
+}
+
+var priorityOrder = []models.ReminderPriority{
+	models.PriorityLow, models.PriorityMedium, models.PriorityHigh, models.PriorityUrgent,
+}
+
+// bumpPriority raises current one level, or to target when target is a
+// higher known priority. Priorities never go down; unset counts as medium.
+func bumpPriority(current, target models.ReminderPriority) models.ReminderPriority {
+	if current == "" {
+		current = models.PriorityMedium
+	}
+	rank := func(p models.ReminderPriority) int {
+		for i, candidate := range priorityOrder {
+			if candidate == p {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if t := rank(target); t >= 0 {
+		if t > rank(current) {
+			return target
+		}
+		return current
+	}
+	next := rank(current) + 1
+	if next >= len(priorityOrder) {
+		return current
+	}
+	return priorityOrder[next]
+}
+
+// seriesRecurrence validates a recurrence from a request and pins the start
+// of the series so COUNT and INTERVAL stay anchored to the first reminder.
+func seriesRecurrence(rec *models.Recurrence, start time.Time) (*models.Recurrence, error) {
+	if rec == nil {
+		return nil, nil
+	}
+
+	copied := *rec
+	if copied.Start == nil && !start.IsZero() {
+		copied.Start = &start
+	}
+	if copied.Start != nil {
+		start = *copied.Start
+	}
+	if _, err := reminderSchedule(&copied, start); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+// reminderSchedule builds the expander for a reminder series. Occurrences
+// are computed in the recurrence's timezone so wall-clock times survive DST.
+func reminderSchedule(rec *models.Recurrence, start time.Time) (*recurrence.Schedule, error) {
+	rule, loc, err := seriesRule(rec)
+	if err != nil {
+		return nil, err
+	}
+	return recurrence.NewSchedule(rule, start.In(loc), rec.ExDates), nil
+}
+
+// seriesRule resolves a recurrence to a single rule, folding the legacy
+// Count and EndDate fields into it, along with the series' timezone.
+func seriesRule(rec *models.Recurrence) (*recurrence.Rule, *time.Location, error) {
+	return seriesSpec{
+		rrule:      rec.RRule,
+		pattern:    rec.Pattern,
+		interval:   rec.Interval,
+		daysOfWeek: rec.DaysOfWeek,
+		count:      rec.Count,
+		endDate:    rec.EndDate,
+		timezone:   rec.Timezone,
+	}.rule()
+}
+
+// seriesSpec is what reminder series and recurring patterns have in common:
+// an RRULE or a simple pattern, legacy bounds and a timezone.
+type seriesSpec struct {
+	rrule        string
+	pattern      string
+	interval     int
+	daysOfWeek   []int
+	daysOfMonth  []int
+	monthsOfYear []int
+	count        int
+	endDate      *time.Time
+	timezone     string
+}
+
+// rule resolves spec to a single rule with count and endDate folded in,
+// along with its timezone.
+func (spec seriesSpec) rule() (*recurrence.Rule, *time.Location, error) {
+	loc, err := recurrence.LoadLocation(spec.timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rule *recurrence.Rule
+	switch {
+	case spec.rrule != "":
+		rule, err = recurrence.Parse(spec.rrule)
+	case spec.pattern != "":
+		rule, err = recurrence.FromPattern(spec.pattern, spec.interval, spec.daysOfWeek, spec.daysOfMonth, spec.monthsOfYear)
 	default:
-		return current.AddDate(0, 0, 1)
+		err = fmt.Errorf("%w: pattern or rrule is required", recurrence.ErrInvalidRule)
 	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rule.Count == 0 && rule.Until.IsZero() && spec.count > 0 {
+		rule.Count = spec.count
+	}
+	if spec.endDate != nil && (rule.Until.IsZero() || spec.endDate.Before(rule.Until)) {
+		rule.Until, rule.UntilLocal = *spec.endDate, false
+	}
+	return rule, loc, nil
 }
 
 func (s *ReminderService) GetPendingReminders(ctx context.Context, before time.Time) ([]*models.Reminder, error) {
 	return s.repo.GetPendingReminders(ctx, before)
 }
 
+// ClaimDueReminder leases the next due reminder to owner, or returns nil when
+// nothing is due.
+func (s *ReminderService) ClaimDueReminder(ctx context.Context, owner string, lease time.Duration) (*models.Reminder, error) {
+	return s.repo.ClaimDueReminder(ctx, owner, time.Now(), lease)
+}
+
 // ── Paginated Queries ──
 
 func (s *ReminderService) GetByUserIDPaginated(ctx context.Context, userID string, status *models.ReminderStatus, page *models.PaginationParams) (*models.PaginatedResponse, error) {
 	page.Validate()
-	reminders, total, err := s.repo.GetByUserIDPaginated(ctx, userID, status, page.Skip(), page.Limit())
+	reminders, info, err := s.repo.GetByUserIDPaginated(ctx, userID, status, page)
 	if err != nil {
 		return nil, err
 	}
+	return page.Response(reminders, info), nil
+}
 
-	totalPages := 0
-	if total > 0 {
-		totalPages = int((total + page.Limit() - 1) / page.Limit())
+// GetByChannelID lists the reminders of a channel in workspaceID. Channels
+// belong to a workspace, so callers check workspace membership first.
+func (s *ReminderService) GetByChannelID(ctx context.Context, workspaceID, channelID string, page *models.PaginationParams) (*models.PaginatedResponse, error) {
+	page.Validate()
+	reminders, info, err := s.repo.GetByChannelID(ctx, workspaceID, channelID, page)
+	if err != nil {
+		return nil, err
 	}
-
-	return &models.PaginatedResponse{
-		Data:       reminders,
-		Total:      total,
-		Page:       page.Page,
-		PerPage:    page.PerPage,
-		TotalPages: totalPages,
-	}, nil
+	return page.Response(reminders, info), nil
 }
 
-func (s *ReminderService) GetByChannelID(ctx context.Context, channelID string, page *models.PaginationParams) (*models.PaginatedResponse, error) {
+func (s *ReminderService) GetByWorkspaceID(ctx context.Context, workspaceID string, status *models.ReminderStatus, page *models.PaginationParams) (*models.PaginatedResponse, error) {
 	page.Validate()
-	reminders, total, err := s.repo.GetByChannelID(ctx, channelID, page.Skip(), page.Limit())
+	reminders, info, err := s.repo.GetByWorkspaceID(ctx, workspaceID, status, page)
 	if err != nil {
 		return nil, err
 	}
+	return page.Response(reminders, info), nil
+}
 
-	totalPages := 0
-	if total > 0 {
-		totalPages = int((total + page.Limit() - 1) / page.Limit())
-	}
+// ── Stats ──
 
-	return &models.PaginatedResponse{
-		Data:       reminders,
-		Total:      total,
-		Page:       page.Page,
-		PerPage:    page.PerPage,
-		TotalPages: totalPages,
-	}, nil
+func (s *ReminderService) GetStats(ctx context.Context, userID string) (*models.ReminderStats, error) {
+	return s.repo.GetStats(ctx, userID)
 }
 
-func (s *ReminderService) GetByWorkspaceID(ctx context.Context, workspaceID string, status *models.ReminderStatus, page *models.PaginationParams) (*models.PaginatedResponse, error) {
-	page.Validate()
-	reminders, total, err := s.repo.GetByWorkspaceID(ctx, workspaceID, status, page.Skip(), page.Limit())
+// ── Acknowledgement ──
+
+// Acknowledge records that the user saw a triggered reminder at at and
+// moves it to acknowledged if it is still triggered. It stops
+// re-notification and the escalations waiting for an acknowledgement.
+// Acknowledging again, or with a time from before the trigger, changes
+// nothing.
+func (s *ReminderService) Acknowledge(ctx context.Context, id string, at time.Time) (*models.Reminder, error) {
+	reminder, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if reminder.TriggeredAt == nil {
+		return nil, ErrNotTriggered
+	}
+
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		won, err := s.repo.Acknowledge(ctx, id, at)
+		if err != nil {
+			return fmt.Errorf("failed to acknowledge reminder: %w", err)
+		}
+		if !won {
+			return nil
+		}
+		if reminder.Status == models.StatusTriggered {
+			if err := s.setStatus(ctx, reminder, models.StatusAcknowledged); err != nil {
+				return err
+			}
+		}
+		if err := s.escalations.CancelUnacknowledged(ctx, id); err != nil {
+			return err
+		}
 
-	totalPages := 0
-	if total > 0 {
-		totalPages = int((total + page.Limit() - 1) / page.Limit())
+		dedupeKey := fmt.Sprintf("reminders.acknowledged:%s:%d", id, reminder.TriggeredAt.Unix())
+		return s.emit(ctx, "reminders.acknowledged", id, dedupeKey, map[string]any{
+			"reminder_id":     id,
+			"user_id":         reminder.UserID,
+			"workspace_id":    reminder.WorkspaceID,
+			"triggered_at":    reminder.TriggeredAt,
+			"acknowledged_at": at,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &models.PaginatedResponse{
-		Data:       reminders,
-		Total:      total,
-		Page:       page.Page,
-		PerPage:    page.PerPage,
-		TotalPages: totalPages,
-	}, nil
+	return s.repo.GetByID(ctx, id)
 }
 
-// ── Stats ──
+// RecordReceipt applies a delivered or read receipt from the notification
+// service. Reading a notification acknowledges its reminder.
+func (s *ReminderService) RecordReceipt(ctx context.Context, status string, receipt *models.DeliveryReceipt) error {
+	if receipt.At.IsZero() {
+		receipt.At = time.Now()
+	}
+	if err := s.deliveries.Mark(ctx, status, receipt); err != nil {
+		return fmt.Errorf("failed to record receipt: %w", err)
+	}
+	if status != models.DeliveryRead {
+		return nil
+	}
 
-func (s *ReminderService) GetStats(ctx context.Context, userID string) (*models.ReminderStats, error) {
-	return s.repo.GetStats(ctx, userID)
+	_, err := s.Acknowledge(ctx, receipt.ReminderID, receipt.At)
+	if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, ErrNotTriggered) {
+		return nil
+	}
+	return err
+}
+
+// RenotifyDue sends the re-notification that has been due longest and
+// schedules the next while the user's policy allows. During quiet hours it
+// is put off until they end. It returns the reminder it handled, or nil
+// when nothing is due.
+func (s *ReminderService) RenotifyDue(ctx context.Context, now time.Time) (*models.Reminder, error) {
+	var claimed *models.Reminder
+	err := s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		reminder, err := s.repo.ClaimRenotify(ctx, now)
+		claimed = reminder
+		if err != nil || reminder == nil {
+			return err
+		}
+		id := reminder.ID.Hex()
+		pref, err := s.notifications.GetPreferences(ctx, reminder.UserID, reminder.WorkspaceID)
+		if err != nil {
+			return err
+		}
+
+		bypass := reminder.Priority == models.PriorityUrgent && pref.UrgentBypass
+		if until, quiet := quietHoursEnd(pref, now); quiet && !bypass {
+			return s.repo.SetRenotify(ctx, id, &until, reminder.RenotifyCount)
+		}
+		if !pref.Enabled {
+			return s.repo.SetRenotify(ctx, id, nil, reminder.RenotifyCount)
+		}
+
+		sent := reminder.RenotifyCount + 1
+		if err := s.sendNotifications(ctx, reminder, pref, sent, now); err != nil {
+			return err
+		}
+		if err := s.repo.SetRenotify(ctx, id, nextRenotify(pref, now, sent), sent); err != nil {
+			return fmt.Errorf("failed to schedule re-notification: %w", err)
+		}
+		reminder.RenotifyCount = sent
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
 }
 
 // ── Complete ──
 
+// Complete marks a reminder completed and releases the reminders that
+// depend on it. It fails with ErrReminderBlocked while a completion
+// prerequisite is open, and with ErrInvalidTransition once the reminder
+// was cancelled.
 func (s *ReminderService) Complete(ctx context.Context, id string) error {
 	reminder, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := checkTransition(reminder.Status, models.StatusCompleted); err != nil {
+		return err
+	}
+	if err := s.dependencies.CheckCompletion(ctx, id); err != nil {
+		return err
+	}
 
-	if err := s.repo.UpdateStatus(ctx, id, models.StatusCompleted); err != nil {
-		return fmt.Errorf("failed to complete reminder: %w", err)
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.setStatus(ctx, reminder, models.StatusCompleted); err != nil {
+			return err
+		}
+		if err := s.escalations.CancelPending(ctx, id); err != nil {
+			return err
+		}
+		if err := s.releaseDependents(ctx, id, time.Now()); err != nil {
+			return err
+		}
+
+		return s.emit(ctx, "reminders.completed", id, "", map[string]any{
+			"reminder_id": id,
+			"user_id":     reminder.UserID,
+		})
+	})
+}
+
+// Archive retires a completed or cancelled reminder. A cancelled
+// reminder will never be completed, so archiving it releases the
+// reminders that depend on it.
+func (s *ReminderService) Archive(ctx context.Context, id string) (*models.Reminder, error) {
+	reminder, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	s.producer.Publish("reminders.completed", map[string]any{
-		"reminder_id": id,
-		"user_id":     reminder.UserID,
+	err = s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.setStatus(ctx, reminder, models.StatusArchived); err != nil {
+			return err
+		}
+		if reminder.Status == models.StatusCancelled {
+			if err := s.releaseDependents(ctx, id, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		return s.emit(ctx, "reminders.archived", id, "", map[string]any{
+			"reminder_id": id,
+			"user_id":     reminder.UserID,
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
 
+	reminder.Status = models.StatusArchived
+	return reminder, nil
+}
+
+// releaseDependents unblocks the reminders waiting on a prerequisite
+// completed at now. Pending dependents with a lag move to now plus the lag
+// when that is later than their time.
+func (s *ReminderService) releaseDependents(ctx context.Context, prerequisiteID string, now time.Time) error {
+	deps, err := s.dependencies.Release(ctx, prerequisiteID)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range deps {
+		lag, err := time.ParseDuration(d.Lag)
+		if d.Lag == "" || err != nil {
+			continue
+		}
+		dependent, err := s.repo.GetByID(ctx, d.ReminderID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		remindAt := now.Add(lag)
+		waiting := dependent.Status == models.StatusPending || dependent.Status == models.StatusSnoozed
+		if !waiting || !remindAt.After(dependent.RemindAt) {
+			continue
+		}
+
+		pref, err := s.notifications.GetPreferences(ctx, dependent.UserID, dependent.WorkspaceID)
+		if err != nil {
+			return err
+		}
+		update := &models.UpdateReminderRequest{RemindAt: &remindAt, NotifyAt: advanceNotifyAt(pref, remindAt)}
+		if err := s.repo.Update(ctx, d.ReminderID, update); err != nil {
+			return fmt.Errorf("failed to reschedule dependent reminder: %w", err)
+		}
+		err = s.emit(ctx, "reminders.updated", d.ReminderID, "", map[string]any{
+			"reminder_id": d.ReminderID,
+			"user_id":     dependent.UserID,
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -329,28 +995,73 @@ func (s *ReminderService) BulkCreate(ctx context.Context, reqs []models.CreateRe
 	return resp
 }
 
+// BulkCancel cancels the given reminders in one transaction. Reminders that
+// are missing or may not be cancelled are reported and skipped.
 func (s *ReminderService) BulkCancel(ctx context.Context, ids []string) *models.BulkActionResponse {
 	resp := &models.BulkActionResponse{}
-	count, err := s.repo.BulkUpdateStatus(ctx, ids, models.StatusCancelled)
+	var cancelled []string
+	var skipped []string
+	err := s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		cancelled, skipped = nil, nil
+		for _, id := range ids {
+			reminder, err := s.repo.GetByID(ctx, id)
+			if err == nil {
+				err = s.setStatus(ctx, reminder, models.StatusCancelled)
+			}
+			switch {
+			case err == nil:
+				cancelled = append(cancelled, id)
+			case errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, primitive.ErrInvalidHex), errors.Is(err, models.ErrInvalidTransition):
+				skipped = append(skipped, fmt.Sprintf("%s: %s", id, err.Error()))
+			default:
+				return err
+			}
+		}
+		if len(cancelled) == 0 {
+			return nil
+		}
+		if err := s.escalations.CancelPending(ctx, cancelled...); err != nil {
+			return err
+		}
+
+		return s.emit(ctx, "reminders.bulk_cancelled", "", "", map[string]any{
+			"ids":       cancelled,
+			"cancelled": len(cancelled),
+		})
+	})
 	if err != nil {
 		resp.Failed = len(ids)
 		resp.Errors = append(resp.Errors, err.Error())
 	} else {
-		resp.Successful = int(count)
-		resp.Failed = len(ids) - int(count)
+		resp.Successful = len(cancelled)
+		resp.Failed = len(skipped)
+		resp.Errors = skipped
 	}
 
-	s.producer.Publish("reminders.bulk_cancelled", map[string]any{
-		"ids":       ids,
-		"cancelled": count,
-	})
-
 	return resp
 }
 
 func (s *ReminderService) BulkDelete(ctx context.Context, ids []string) *models.BulkActionResponse {
 	resp := &models.BulkActionResponse{}
-	count, err := s.repo.BulkDelete(ctx, ids)
+	var count int64
+	err := s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		count, err = s.repo.BulkDelete(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if err := s.escalations.CancelPending(ctx, ids...); err != nil {
+			return err
+		}
+		if err := s.dependencies.Forget(ctx, ids...); err != nil {
+			return err
+		}
+
+		return s.emit(ctx, "reminders.bulk_deleted", "", "", map[string]any{
+			"ids":     ids,
+			"deleted": count,
+		})
+	})
 	if err != nil {
 		resp.Failed = len(ids)
 		resp.Errors = append(resp.Errors, err.Error())
@@ -359,10 +1070,5 @@ func (s *ReminderService) BulkDelete(ctx context.Context, ids []string) *models.
 		resp.Failed = len(ids) - int(count)
 	}
 
-	s.producer.Publish("reminders.bulk_deleted", map[string]any{
-		"ids":     ids,
-		"deleted": count,
-	})
-
 	return resp
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,11 +14,15 @@ import (
 )
 
 type SharingService struct {
-	collection *mongo.Collection
+	collection *audit.Collection
 }
 
-func NewSharingService(db *mongo.Database) *SharingService {
-	return &SharingService{collection: db.Collection("reminder_shares")}
+func NewSharingService(db *mongo.Database, sink audit.Sink) *SharingService {
+	collection := db.Collection("reminder_shares")
+	_, _ = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "reminder_id", Value: 1}, {Key: "shared_with", Value: 1}},
+	})
+	return &SharingService{collection: audit.Wrap(collection, "share", "reminder_id", sink)}
 }
 
 func (s *SharingService) Share(ctx context.Context, reminderID, sharedBy string, req *models.ShareReminderRequest) (*models.ReminderShare, error) {
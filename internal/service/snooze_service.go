@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Suggestions learn from at most this much of a user's recent history.
+	snoozeLookback   = 8 * 7 * 24 * time.Hour
+	maxSnoozeHistory = 500
+
+	// Without history, "later today" is three hours out and mornings start
+	// at nine. Learned same-day snoozes are kept within an hour and six.
+	defaultLaterToday  = 3 * time.Hour
+	minLaterToday      = time.Hour
+	maxLaterToday      = 6 * time.Hour
+	defaultMorningHour = 9
+	workdayEndHour     = 18
+
+	// An hour of the week is meeting-heavy once the user has snoozed
+	// something during it on this many different days.
+	busyHourDays = 3
+)
+
+// ReminderSnoozeHistory is one snooze of a reminder. Every snooze is
+// recorded, whether it came over HTTP, in bulk or as a Kafka command.
+type ReminderSnoozeHistory struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ReminderID   string              `bson:"reminder_id" json:"reminder_id"`
+	UserID       string              `bson:"user_id" json:"user_id"`
+	Source       models.SnoozeSource `bson:"source" json:"source"`
+	SnoozedAt    time.Time           `bson:"snoozed_at" json:"snoozed_at"`
+	Duration     string              `bson:"duration" json:"duration"`
+	PreviousTime time.Time           `bson:"previous_time" json:"previous_time"`
+	NewTime      time.Time           `bson:"new_time" json:"new_time"`
+}
+
+type SnoozeService struct {
+	history       *mongo.Collection
+	timezones     *TimezoneService
+	notifications *NotificationService
+}
+
+func NewSnoozeService(db *mongo.Database, timezones *TimezoneService, notifications *NotificationService) *SnoozeService {
+	history := db.Collection("reminder_snooze_history")
+	_, _ = history.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "reminder_id", Value: 1}, {Key: "snoozed_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "snoozed_at", Value: -1}}},
+	})
+	return &SnoozeService{history: history, timezones: timezones, notifications: notifications}
+}
+
+// ── History ──
+
+// Record stores a snooze. ReminderService calls it inside the snooze
+// transaction so the history never disagrees with the reminder.
+func (s *SnoozeService) Record(ctx context.Context, h *ReminderSnoozeHistory) error {
+	if h.SnoozedAt.IsZero() {
+		h.SnoozedAt = time.Now()
+	}
+	result, err := s.history.InsertOne(ctx, h)
+	if err != nil {
+		return err
+	}
+	h.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (s *SnoozeService) List(ctx context.Context, reminderID string) ([]ReminderSnoozeHistory, error) {
+	cursor, err := s.history.Find(ctx, bson.M{"reminder_id": reminderID},
+		options.Find().SetSort(bson.D{{Key: "snoozed_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []ReminderSnoozeHistory
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ── Suggestions ──
+
+// Suggest proposes snooze times for userID at now, in the user's timezone
+// and learned from their recent snoozes. Times inside the user's quiet
+// hours in workspaceID move to when quiet hours end. Suggestions are in
+// chronological order.
+func (s *SnoozeService) Suggest(ctx context.Context, userID, workspaceID string, now time.Time) ([]models.SnoozeSuggestion, error) {
+	loc, err := s.timezones.GetUserLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	pref, err := s.notifications.GetPreferences(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.history.Find(ctx,
+		bson.M{"user_id": userID, "snoozed_at": bson.M{"$gte": now.Add(-snoozeLookback)}},
+		options.Find().SetSort(bson.D{{Key: "snoozed_at", Value: -1}}).SetLimit(maxSnoozeHistory))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var history []ReminderSnoozeHistory
+	if err := cursor.All(ctx, &history); err != nil {
+		return nil, err
+	}
+
+	return learnSnoozeHabits(history, loc).suggest(now.In(loc), pref), nil
+}
+
+// snoozeHabits is what a user's snooze history says about their day.
+type snoozeHabits struct {
+	laterToday     time.Duration
+	learnedLater   bool
+	morningHour    int
+	learnedMorning bool
+
+	// busy counts, per weekday and hour, the days the user snoozed
+	// something during that hour.
+	busy [7][24]int
+}
+
+func learnSnoozeHabits(history []ReminderSnoozeHistory, loc *time.Location) *snoozeHabits {
+	h := &snoozeHabits{laterToday: defaultLaterToday, morningHour: defaultMorningHour}
+
+	var sameDay []time.Duration
+	mornings := map[int]int{}
+	busyDays := map[string]bool{}
+	for _, e := range history {
+		at := e.SnoozedAt.In(loc)
+		until := e.NewTime.In(loc)
+
+		// A bulk snooze counts once for its hour.
+		slot := at.Format("2006-01-02T15")
+		if !busyDays[slot] {
+			busyDays[slot] = true
+			h.busy[at.Weekday()][at.Hour()]++
+		}
+
+		switch {
+		case sameDate(at, until):
+			sameDay = append(sameDay, until.Sub(at))
+		case until.Hour() >= 5 && until.Hour() < 12:
+			mornings[until.Hour()]++
+		}
+	}
+
+	if len(sameDay) > 0 {
+		sort.Slice(sameDay, func(i, j int) bool { return sameDay[i] < sameDay[j] })
+		h.laterToday = min(max(sameDay[len(sameDay)/2], minLaterToday), maxLaterToday)
+		h.learnedLater = true
+	}
+
+	best := 0
+	for hour, n := range mornings {
+		if n > best || (n == best && hour < h.morningHour) {
+			best = n
+			h.morningHour = hour
+			h.learnedMorning = true
+		}
+	}
+	return h
+}
+
+func (h *snoozeHabits) suggest(now time.Time, pref *models.NotificationPreference) []models.SnoozeSuggestion {
+	var out []models.SnoozeSuggestion
+	add := func(key, label, reason string, until time.Time) {
+		if end, ok := quietHoursEnd(pref, until); ok {
+			until = end.In(now.Location())
+			reason = strings.TrimPrefix(reason+"; moved past quiet hours", "; ")
+		}
+		if !until.After(now) {
+			return
+		}
+		for _, s := range out {
+			if s.Until.Equal(until) {
+				return
+			}
+		}
+		out = append(out, models.SnoozeSuggestion{
+			Key:      key,
+			Label:    label,
+			Duration: formatSnooze(until.Sub(now)),
+			Until:    until,
+			Reason:   reason,
+		})
+	}
+	day := func(offset, hour int) time.Time {
+		y, m, d := now.Date()
+		return time.Date(y, m, d+offset, hour, 0, 0, 0, now.Location())
+	}
+
+	if later := ceilQuarterHour(now.Add(h.laterToday)); later.Before(day(0, workdayEndHour)) {
+		reason := ""
+		if h.learnedLater {
+			reason = "your usual same-day snooze"
+		}
+		add("later_today", "Later today", reason, later)
+	}
+
+	if end, ok := h.busyBlockEnd(now); ok {
+		add("after_busy_block", "After the meeting-heavy block", "you often snooze reminders at this time", end)
+	}
+
+	morningReason := ""
+	if h.learnedMorning {
+		morningReason = "when you usually pick reminders back up"
+	}
+	add("tomorrow_morning", "Tomorrow morning", morningReason, day(1, h.morningHour))
+
+	next := day(1, h.morningHour)
+	for next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
+		next = next.AddDate(0, 0, 1)
+	}
+	add("next_work_day", "Next work day", morningReason, next)
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Until.Before(out[j].Until) })
+	return out
+}
+
+// busyBlockEnd returns when the run of meeting-heavy hours now falls in
+// ends, if it ends today.
+func (h *snoozeHabits) busyBlockEnd(now time.Time) (time.Time, bool) {
+	weekday, hour := now.Weekday(), now.Hour()
+	if h.busy[weekday][hour] < busyHourDays {
+		return time.Time{}, false
+	}
+	for hour < 24 && h.busy[weekday][hour] >= busyHourDays {
+		hour++
+	}
+	if hour == 24 {
+		return time.Time{}, false
+	}
+	y, m, d := now.Date()
+	return time.Date(y, m, d, hour, 0, 0, 0, now.Location()), true
+}
+
+func sameDate(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func ceilQuarterHour(t time.Time) time.Time {
+	if r := t.Truncate(15 * time.Minute); r.Before(t) {
+		return r.Add(15 * time.Minute)
+	}
+	return t
+}
+
+// formatSnooze renders d to the minute in the form /snooze accepts,
+// without the zero units time.Duration prints ("1h30m", "2h").
+func formatSnooze(d time.Duration) string {
+	s := strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"reminder-service/internal/models"
+)
+
+// reminderTransitions lists the statuses a reminder may move to from each
+// status. The scheduler claims pending and snoozed reminders for
+// processing, then triggers them or, during quiet hours, returns them to
+// the status they were claimed from.
+var reminderTransitions = map[models.ReminderStatus][]models.ReminderStatus{
+	models.StatusPending: {
+		models.StatusSnoozed, models.StatusProcessing, models.StatusTriggered,
+		models.StatusCompleted, models.StatusCancelled,
+	},
+	models.StatusSnoozed: {
+		models.StatusSnoozed, models.StatusPending, models.StatusProcessing, models.StatusTriggered,
+		models.StatusCompleted, models.StatusCancelled,
+	},
+	models.StatusProcessing: {
+		models.StatusProcessing, models.StatusPending, models.StatusSnoozed, models.StatusTriggered,
+		models.StatusCompleted, models.StatusCancelled,
+	},
+	models.StatusTriggered: {
+		models.StatusAcknowledged, models.StatusSnoozed, models.StatusPending,
+		models.StatusCompleted, models.StatusCancelled,
+	},
+	models.StatusAcknowledged: {
+		models.StatusSnoozed, models.StatusPending, models.StatusCompleted, models.StatusCancelled,
+	},
+	models.StatusCompleted: {models.StatusArchived},
+	models.StatusCancelled: {models.StatusArchived},
+	models.StatusArchived:  {},
+}
+
+// checkTransition returns a TransitionError explaining why a reminder may
+// not move from one status to another, or nil if it may.
+func checkTransition(from, to models.ReminderStatus) error {
+	if _, known := reminderTransitions[to]; !known {
+		return &models.TransitionError{From: from, To: to, Reason: "unknown status"}
+	}
+	for _, allowed := range reminderTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+
+	reason := "not allowed"
+	switch {
+	case from == to:
+		reason = "already " + string(to)
+	case from == models.StatusArchived:
+		reason = "archived reminders cannot change"
+	case from == models.StatusProcessing:
+		reason = "the reminder is being triggered"
+	case from == models.StatusCompleted || from == models.StatusCancelled:
+		reason = fmt.Sprintf("%s reminders can only be archived", from)
+	case to == models.StatusArchived:
+		reason = "only completed or cancelled reminders can be archived"
+	case to == models.StatusAcknowledged:
+		reason = "only triggered reminders can be acknowledged"
+	}
+	return &models.TransitionError{From: from, To: to, Reason: reason}
+}
+
+// checkRequestedStatus limits the statuses clients set through an update
+// to those without an operation of their own.
+func checkRequestedStatus(from, to models.ReminderStatus) error {
+	switch to {
+	case models.StatusPending, models.StatusCompleted, models.StatusCancelled, models.StatusArchived:
+		return checkTransition(from, to)
+	case models.StatusSnoozed:
+		return &models.TransitionError{From: from, To: to, Reason: "snooze the reminder instead"}
+	case models.StatusAcknowledged:
+		return &models.TransitionError{From: from, To: to, Reason: "acknowledge the reminder instead"}
+	case models.StatusProcessing, models.StatusTriggered:
+		return &models.TransitionError{From: from, To: to, Reason: "only the scheduler triggers reminders"}
+	}
+	return &models.TransitionError{From: from, To: to, Reason: "unknown status"}
+}
+
+// settled reports whether a reminder in status no longer holds up the
+// reminders that depend on it.
+func settled(status models.ReminderStatus) bool {
+	return status == models.StatusCompleted || status == models.StatusArchived
+}
+
+// setStatus moves reminder to status if the state machine allows it and
+// nobody changed the status in the meantime. It must be called with the
+// caller's transaction context, and leaves reminder as it is so the
+// transaction can be retried.
+func (s *ReminderService) setStatus(ctx context.Context, reminder *models.Reminder, to models.ReminderStatus) error {
+	from := reminder.Status
+	if err := checkTransition(from, to); err != nil {
+		return err
+	}
+
+	won, err := s.repo.UpdateStatus(ctx, reminder.ID.Hex(), from, to)
+	if err != nil {
+		return fmt.Errorf("failed to update reminder status: %w", err)
+	}
+	if !won {
+		return &models.TransitionError{From: from, To: to, Reason: "the status changed in the meantime"}
+	}
+	return nil
+}
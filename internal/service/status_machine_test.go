@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"reminder-service/internal/models"
+)
+
+var allStatuses = []models.ReminderStatus{
+	models.StatusPending, models.StatusSnoozed, models.StatusProcessing, models.StatusTriggered,
+	models.StatusAcknowledged, models.StatusCompleted, models.StatusCancelled, models.StatusArchived,
+}
+
+func TestReminderTransitionsCoverEveryStatus(t *testing.T) {
+	for _, from := range allStatuses {
+		targets, ok := reminderTransitions[from]
+		if !ok {
+			t.Errorf("no transitions listed from %s", from)
+		}
+		for _, to := range targets {
+			if _, known := reminderTransitions[to]; !known {
+				t.Errorf("%s moves to unlisted status %s", from, to)
+			}
+		}
+	}
+	if len(reminderTransitions) != len(allStatuses) {
+		t.Errorf("reminderTransitions has %d statuses, want %d", len(reminderTransitions), len(allStatuses))
+	}
+}
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to models.ReminderStatus
+		reason   string // empty when allowed
+	}{
+		{models.StatusPending, models.StatusSnoozed, ""},
+		{models.StatusPending, models.StatusCompleted, ""},
+		{models.StatusSnoozed, models.StatusSnoozed, ""},
+		{models.StatusProcessing, models.StatusPending, ""},
+		{models.StatusProcessing, models.StatusSnoozed, ""},
+		{models.StatusTriggered, models.StatusAcknowledged, ""},
+		{models.StatusAcknowledged, models.StatusPending, ""},
+		{models.StatusCompleted, models.StatusArchived, ""},
+		{models.StatusCancelled, models.StatusArchived, ""},
+		{models.StatusPending, models.StatusPending, "already pending"},
+		{models.StatusArchived, models.StatusPending, "archived reminders cannot change"},
+		{models.StatusProcessing, models.StatusAcknowledged, "the reminder is being triggered"},
+		{models.StatusCompleted, models.StatusPending, "completed reminders can only be archived"},
+		{models.StatusCancelled, models.StatusCompleted, "cancelled reminders can only be archived"},
+		{models.StatusPending, models.StatusArchived, "only completed or cancelled reminders can be archived"},
+		{models.StatusPending, models.StatusAcknowledged, "only triggered reminders can be acknowledged"},
+		{models.StatusPending, "deleted", "unknown status"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			err := checkTransition(tt.from, tt.to)
+			checkTransitionError(t, err, tt.from, tt.to, tt.reason)
+		})
+	}
+}
+
+func TestSchedulerTransitions(t *testing.T) {
+	// Claiming, reclaiming an expired lease, triggering and deferring.
+	moves := [][2]models.ReminderStatus{
+		{models.StatusPending, models.StatusProcessing},
+		{models.StatusSnoozed, models.StatusProcessing},
+		{models.StatusProcessing, models.StatusProcessing},
+		{models.StatusProcessing, models.StatusTriggered},
+		{models.StatusPending, models.StatusTriggered},
+		{models.StatusSnoozed, models.StatusTriggered},
+		{models.StatusProcessing, models.StatusPending},
+		{models.StatusProcessing, models.StatusSnoozed},
+	}
+	for _, m := range moves {
+		if err := checkTransition(m[0], m[1]); err != nil {
+			t.Errorf("scheduler move %s -> %s: %v", m[0], m[1], err)
+		}
+	}
+}
+
+func TestCheckRequestedStatus(t *testing.T) {
+	tests := []struct {
+		from, to models.ReminderStatus
+		reason   string
+	}{
+		{models.StatusPending, models.StatusCompleted, ""},
+		{models.StatusTriggered, models.StatusPending, ""},
+		{models.StatusCancelled, models.StatusArchived, ""},
+		{models.StatusCompleted, models.StatusCancelled, "completed reminders can only be archived"},
+		{models.StatusPending, models.StatusSnoozed, "snooze the reminder instead"},
+		{models.StatusTriggered, models.StatusAcknowledged, "acknowledge the reminder instead"},
+		{models.StatusPending, models.StatusProcessing, "only the scheduler triggers reminders"},
+		{models.StatusPending, models.StatusTriggered, "only the scheduler triggers reminders"},
+		{models.StatusPending, "done", "unknown status"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			err := checkRequestedStatus(tt.from, tt.to)
+			checkTransitionError(t, err, tt.from, tt.to, tt.reason)
+		})
+	}
+}
+
+func checkTransitionError(t *testing.T, err error, from, to models.ReminderStatus, reason string) {
+	t.Helper()
+	if reason == "" {
+		if err != nil {
+			t.Errorf("%s -> %s: unexpected error %v", from, to, err)
+		}
+		return
+	}
+	var terr *models.TransitionError
+	if !errors.As(err, &terr) {
+		t.Fatalf("%s -> %s: error = %v, want a TransitionError", from, to, err)
+	}
+	if !errors.Is(err, models.ErrInvalidTransition) {
+		t.Errorf("%s -> %s: error does not wrap ErrInvalidTransition", from, to)
+	}
+	if terr.From != from || terr.To != to || terr.Reason != reason {
+		t.Errorf("%s -> %s: error = %+v, want reason %q", from, to, terr, reason)
+	}
+}
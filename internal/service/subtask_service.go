@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,11 +14,11 @@ import (
 )
 
 type SubtaskService struct {
-	collection *mongo.Collection
+	collection *audit.Collection
 }
 
-func NewSubtaskService(db *mongo.Database) *SubtaskService {
-	return &SubtaskService{collection: db.Collection("reminder_subtasks")}
+func NewSubtaskService(db *mongo.Database, sink audit.Sink) *SubtaskService {
+	return &SubtaskService{collection: audit.Wrap(db.Collection("reminder_subtasks"), "subtask", "reminder_id", sink)}
 }
 
 func (s *SubtaskService) Create(ctx context.Context, reminderID, userID string, req *models.CreateSubtaskRequest) (*models.ReminderSubtask, error) {
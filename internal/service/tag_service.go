@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"reminder-service/internal/audit"
 	"reminder-service/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,14 +15,14 @@ import (
 )
 
 type TagService struct {
-	collection *mongo.Collection
-	tagMap     *mongo.Collection // reminder_tag_mappings
+	collection *audit.Collection
+	tagMap     *audit.Collection // reminder_tag_mappings
 }
 
-func NewTagService(db *mongo.Database) *TagService {
+func NewTagService(db *mongo.Database, sink audit.Sink) *TagService {
 	return &TagService{
-		collection: db.Collection("reminder_tags"),
-		tagMap:     db.Collection("reminder_tag_mappings"),
+		collection: audit.Wrap(db.Collection("reminder_tags"), "tag", "", sink),
+		tagMap:     audit.Wrap(db.Collection("reminder_tag_mappings"), "reminder_tag", "reminder_id", sink),
 	}
 }
 
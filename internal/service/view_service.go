@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrViewForbidden is returned when a workspace view is opened by someone
+// whose workspace role does not allow workspace-wide search.
+var ErrViewForbidden = errors.New("workspace view requires workspace search access")
+
+// ErrInvalidView is returned for a view with an unknown scope, or a share
+// with an unknown permission.
+var ErrInvalidView = errors.New("invalid view")
+
+func checkViewScope(scope string) error {
+	if scope != models.ViewScopePersonal && scope != models.ViewScopeWorkspace {
+		return fmt.Errorf("%w: scope must be %s or %s", ErrInvalidView, models.ViewScopePersonal, models.ViewScopeWorkspace)
+	}
+	return nil
+}
+
+// ViewService stores saved searches and runs them. A view runs as the user
+// opening it, so a shared personal view shows each teammate their own
+// reminders.
+type ViewService struct {
+	collection *mongo.Collection
+	shares     *mongo.Collection
+	reminders  *mongo.Collection
+	access     *AccessService
+	search     *SearchService
+	workspaces *WorkspaceService
+}
+
+func NewViewService(db *mongo.Database, access *AccessService, search *SearchService, workspaces *WorkspaceService) *ViewService {
+	collection := db.Collection("reminder_views")
+	_, _ = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "workspace_id", Value: 1}, {Key: "name", Value: 1}},
+	})
+
+	shares := db.Collection("reminder_view_shares")
+	_, _ = shares.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "view_id", Value: 1}, {Key: "shared_with", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "shared_with", Value: 1}}},
+	})
+
+	return &ViewService{
+		collection: collection,
+		shares:     shares,
+		reminders:  db.Collection("reminders"),
+		access:     access,
+		search:     search,
+		workspaces: workspaces,
+	}
+}
+
+// ── Views ──
+
+func (s *ViewService) Create(ctx context.Context, userID string, req *models.CreateViewRequest) (*models.SavedView, error) {
+	scope := req.Scope
+	if scope == "" {
+		scope = models.ViewScopePersonal
+	}
+	if err := checkViewScope(scope); err != nil {
+		return nil, err
+	}
+	filter := storedFilter(req.Filter)
+	if _, err := compileExpression(&filter); err != nil {
+		return nil, err
+	}
+
+	view := &models.SavedView{
+		UserID:      userID,
+		WorkspaceID: req.WorkspaceID,
+		Name:        req.Name,
+		Scope:       scope,
+		Filter:      filter,
+		Sort:        req.Sort,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	result, err := s.collection.InsertOne(ctx, view)
+	if err != nil {
+		return nil, err
+	}
+	view.ID = result.InsertedID.(primitive.ObjectID)
+	return view, nil
+}
+
+func (s *ViewService) Get(ctx context.Context, viewID string) (*models.SavedView, error) {
+	objID, err := objectIDFromHex(viewID)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+
+	var view models.SavedView
+	err = s.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&view)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// List returns the views userID owns or has been shared, optionally in one
+// workspace, each with a live count of matching reminders. Workspace views
+// the user may not run are listed without a count.
+func (s *ViewService) List(ctx context.Context, userID, workspaceID string) ([]models.SavedView, error) {
+	cursor, err := s.shares.Find(ctx, bson.M{"shared_with": userID})
+	if err != nil {
+		return nil, err
+	}
+	var shares []models.ViewShare
+	if err := cursor.All(ctx, &shares); err != nil {
+		return nil, err
+	}
+	permissions := map[primitive.ObjectID]string{}
+	sharedIDs := []primitive.ObjectID{}
+	for _, share := range shares {
+		if objID, err := objectIDFromHex(share.ViewID); err == nil {
+			permissions[objID] = share.Permission
+			sharedIDs = append(sharedIDs, objID)
+		}
+	}
+
+	filter := bson.M{"$or": []bson.M{
+		{"user_id": userID},
+		{"_id": bson.M{"$in": sharedIDs}},
+	}}
+	if workspaceID != "" {
+		filter["workspace_id"] = workspaceID
+	}
+	cursor, err = s.collection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var views []models.SavedView
+	if err := cursor.All(ctx, &views); err != nil {
+		return nil, err
+	}
+
+	for i := range views {
+		view := &views[i]
+		if view.UserID != userID {
+			view.Permission = permissions[view.ID]
+		}
+		count, err := s.Count(ctx, view, userID)
+		if errors.Is(err, ErrViewForbidden) || errors.Is(err, ErrInvalidFilter) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		view.Count = &count
+	}
+	return views, nil
+}
+
+func (s *ViewService) Update(ctx context.Context, viewID string, req *models.UpdateViewRequest) (*models.SavedView, error) {
+	objID, err := objectIDFromHex(viewID)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+
+	update := bson.M{"updated_at": time.Now()}
+	if req.Name != "" {
+		update["name"] = req.Name
+	}
+	if req.Scope != "" {
+		if err := checkViewScope(req.Scope); err != nil {
+			return nil, err
+		}
+		update["scope"] = req.Scope
+	}
+	if req.Filter != nil {
+		filter := storedFilter(*req.Filter)
+		if _, err := compileExpression(&filter); err != nil {
+			return nil, err
+		}
+		update["filter"] = filter
+	}
+	if req.Sort != "" {
+		update["sort"] = req.Sort
+	}
+
+	var view models.SavedView
+	err = s.collection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, bson.M{"$set": update},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&view)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// Delete removes a view and its shares. Calendar feeds built on it stop
+// serving.
+func (s *ViewService) Delete(ctx context.Context, viewID string) error {
+	objID, err := objectIDFromHex(viewID)
+	if err != nil {
+		return ErrResourceNotFound
+	}
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrResourceNotFound
+	}
+	_, _ = s.shares.DeleteMany(ctx, bson.M{"view_id": viewID})
+	return nil
+}
+
+// storedFilter drops the fields a view takes from its scope and the
+// caller at run time.
+func storedFilter(f models.ReminderFilter) models.ReminderFilter {
+	f.UserID = ""
+	f.WorkspaceID = ""
+	f.Viewer = ""
+	return f
+}
+
+// ── Running Views ──
+
+// RunFilter returns the filter view applies when runner opens it.
+func (s *ViewService) RunFilter(ctx context.Context, view *models.SavedView, runner string) (*models.ReminderFilter, error) {
+	f := view.Filter
+	f.WorkspaceID = view.WorkspaceID
+	f.UserID = runner
+	f.Viewer = runner
+
+	if view.Scope == models.ViewScopeWorkspace {
+		role, err := s.workspaces.Role(ctx, view.WorkspaceID, runner)
+		if err != nil {
+			return nil, err
+		}
+		if !Allows(role, ActionSearchWorkspace) {
+			return nil, ErrViewForbidden
+		}
+		f.UserID = ""
+	}
+	return &f, nil
+}
+
+// Run returns a page of the reminders in view.
+func (s *ViewService) Run(ctx context.Context, view *models.SavedView, runner string, page models.PaginationParams) (*models.PaginatedResponse, error) {
+	f, err := s.RunFilter(ctx, view, runner)
+	if err != nil {
+		return nil, err
+	}
+	return s.search.Search(ctx, &models.ReminderSearchParams{
+		ReminderFilter:   *f,
+		PaginationParams: page,
+		Sort:             view.Sort,
+	})
+}
+
+// Count returns how many reminders view currently holds for runner.
+func (s *ViewService) Count(ctx context.Context, view *models.SavedView, runner string) (int64, error) {
+	f, err := s.RunFilter(ctx, view, runner)
+	if err != nil {
+		return 0, err
+	}
+	filter, err := reminderFilter(ctx, s.reminders.Database(), f)
+	if err != nil {
+		return 0, err
+	}
+	return s.reminders.CountDocuments(ctx, filter)
+}
+
+// Filter opens viewID as runner and returns its Mongo filter, for the
+// calendar. Views runner may not read are reported as ErrResourceNotFound.
+func (s *ViewService) Filter(ctx context.Context, viewID, runner string) (bson.M, error) {
+	access, err := s.access.ViewAccess(ctx, viewID, runner)
+	if err != nil {
+		return nil, err
+	}
+	if access < AccessView {
+		return nil, ErrResourceNotFound
+	}
+	view, err := s.Get(ctx, viewID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.RunFilter(ctx, view, runner)
+	if err != nil {
+		return nil, err
+	}
+	return reminderFilter(ctx, s.reminders.Database(), f)
+}
+
+// ── Sharing ──
+
+// Share gives sharedWith access to a view, replacing any earlier share.
+func (s *ViewService) Share(ctx context.Context, viewID, sharedBy string, req *models.ShareReminderRequest) (*models.ViewShare, error) {
+	if req.Permission != models.SharePermissionView && req.Permission != models.SharePermissionEdit {
+		return nil, fmt.Errorf("%w: permission must be %s or %s", ErrInvalidView, models.SharePermissionView, models.SharePermissionEdit)
+	}
+	share := &models.ViewShare{
+		ViewID:     viewID,
+		SharedBy:   sharedBy,
+		SharedWith: req.SharedWith,
+		Permission: req.Permission,
+		CreatedAt:  time.Now(),
+	}
+	err := s.shares.FindOneAndUpdate(ctx,
+		bson.M{"view_id": viewID, "shared_with": req.SharedWith},
+		bson.M{
+			"$set":         bson.M{"permission": req.Permission, "shared_by": sharedBy},
+			"$setOnInsert": bson.M{"created_at": share.CreatedAt},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(share)
+	if err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+func (s *ViewService) ListShares(ctx context.Context, viewID string) ([]models.ViewShare, error) {
+	cursor, err := s.shares.Find(ctx, bson.M{"view_id": viewID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var shares []models.ViewShare
+	if err := cursor.All(ctx, &shares); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+func (s *ViewService) Unshare(ctx context.Context, viewID, sharedWith string) error {
+	result, err := s.shares.DeleteOne(ctx, bson.M{"view_id": viewID, "shared_with": sharedWith})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrResourceNotFound
+	}
+	return nil
+}
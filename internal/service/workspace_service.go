@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"reminder-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WorkspaceAction is an operation on workspace-wide data.
+type WorkspaceAction string
+
+const (
+	ActionViewAnalytics    WorkspaceAction = "analytics.view"
+	ActionListReminders    WorkspaceAction = "reminders.list"
+	ActionImportReminders  WorkspaceAction = "reminders.import"
+	ActionSearchWorkspace  WorkspaceAction = "search.workspace"
+	ActionExportWorkspace  WorkspaceAction = "export.workspace"
+	ActionViewAccessDenial WorkspaceAction = "audit.view"
+	ActionManageEscalation WorkspaceAction = "escalation.manage"
+)
+
+// workspacePolicies lists the roles allowed to perform each action. Guests
+// only ever see reminders of their own.
+var workspacePolicies = map[WorkspaceAction][]models.WorkspaceRole{
+	ActionViewAnalytics:    {models.WorkspaceOwner, models.WorkspaceAdmin},
+	ActionListReminders:    {models.WorkspaceOwner, models.WorkspaceAdmin, models.WorkspaceMember},
+	ActionImportReminders:  {models.WorkspaceOwner, models.WorkspaceAdmin, models.WorkspaceMember},
+	ActionSearchWorkspace:  {models.WorkspaceOwner, models.WorkspaceAdmin, models.WorkspaceMember},
+	ActionExportWorkspace:  {models.WorkspaceOwner, models.WorkspaceAdmin},
+	ActionViewAccessDenial: {models.WorkspaceOwner, models.WorkspaceAdmin},
+	ActionManageEscalation: {models.WorkspaceOwner, models.WorkspaceAdmin},
+}
+
+// Allows reports whether role may perform action. Unknown actions are denied.
+func Allows(role models.WorkspaceRole, action WorkspaceAction) bool {
+	for _, r := range workspacePolicies[action] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type WorkspaceService struct {
+	members *mongo.Collection
+	denials *mongo.Collection
+}
+
+func NewWorkspaceService(db *mongo.Database) *WorkspaceService {
+	members := db.Collection("workspace_members")
+	denials := db.Collection("workspace_access_denials")
+	_, _ = members.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "workspace_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	_, _ = denials.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "denied_at", Value: -1}}},
+	})
+
+	return &WorkspaceService{members: members, denials: denials}
+}
+
+// ── Membership ──
+
+// ApplyMemberEvent folds a workspace.members event into the local copy.
+// Events older than what is stored are ignored, so redelivered or reordered
+// messages are harmless. Events without an occurred_at cannot be ordered and
+// are rejected.
+func (s *WorkspaceService) ApplyMemberEvent(ctx context.Context, ev *models.WorkspaceMemberEvent) error {
+	if err := ev.Validate(); err != nil {
+		return err
+	}
+	at := ev.OccurredAt
+
+	set := bson.M{"updated_at": at}
+	if ev.Type == models.MemberRemoved {
+		set["removed"] = true
+	} else {
+		set["role"] = ev.Role
+		set["removed"] = false
+	}
+
+	filter := bson.M{
+		"workspace_id": ev.WorkspaceID,
+		"user_id":      ev.UserID,
+		"updated_at":   bson.M{"$lt": at},
+	}
+	_, err := s.members.UpdateOne(ctx, filter, bson.M{"$set": set}, options.Update().SetUpsert(true))
+	// A newer record already exists: the upsert collides with it.
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// Role returns the caller's role in a workspace, or "" if they are not a
+// member.
+func (s *WorkspaceService) Role(ctx context.Context, workspaceID, userID string) (models.WorkspaceRole, error) {
+	var m models.WorkspaceMembership
+	err := s.members.FindOne(ctx, bson.M{
+		"workspace_id": workspaceID,
+		"user_id":      userID,
+		"removed":      false,
+	}).Decode(&m)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return m.Role, nil
+}
+
+// ── Audit ──
+
+func (s *WorkspaceService) RecordDenial(ctx context.Context, denial *models.AccessDenial) error {
+	denial.DeniedAt = time.Now()
+	_, err := s.denials.InsertOne(ctx, denial)
+	return err
+}
+
+func (s *WorkspaceService) ListDenials(ctx context.Context, workspaceID string, limit int64) ([]models.AccessDenial, error) {
+	cursor, err := s.denials.Find(ctx, bson.M{"workspace_id": workspaceID},
+		options.Find().SetSort(bson.D{{Key: "denied_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var denials []models.AccessDenial
+	if err := cursor.All(ctx, &denials); err != nil {
+		return nil, err
+	}
+	return denials, nil
+}
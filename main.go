@@ -3,8 +3,10 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"reminder-service/internal/api"
+	"reminder-service/internal/auth"
 	"reminder-service/internal/config"
 	"reminder-service/internal/kafka"
 	"reminder-service/internal/repository"
@@ -35,35 +37,70 @@ func main() {
 	defer producer.Close()
 
 	// ── Initialize Core Service ──
-	reminderService := service.NewReminderService(repo, producer)
+	// Writes through the services below are recorded in the activity log.
+	activityService := service.NewActivityService(db, cfg.ActivityRetention)
+	repo.Audit(activityService)
+
+	notificationService := service.NewNotificationService(db, activityService)
+	escalationService := service.NewEscalationService(db, activityService)
+	dependencyService := service.NewDependencyService(db, activityService)
+	timezoneService := service.NewTimezoneService(db)
+	snoozeService := service.NewSnoozeService(db, timezoneService, notificationService)
+	deliveryService := service.NewDeliveryService(db)
+	reminderService := service.NewReminderService(repo, notificationService, escalationService, dependencyService, snoozeService, deliveryService)
 
 	// ── Initialize Extended Services ──
-	tagService := service.NewTagService(db)
+	tagService := service.NewTagService(db, activityService)
 	templateService := service.NewTemplateService(db)
-	notificationService := service.NewNotificationService(db)
-	sharingService := service.NewSharingService(db)
-	noteService := service.NewNoteService(db)
-	activityService := service.NewActivityService(db)
+	sharingService := service.NewSharingService(db, activityService)
+	noteService := service.NewNoteService(db, activityService)
 	analyticsService := service.NewAnalyticsService(repo, db)
 	searchService := service.NewSearchService(db)
-	exportService := service.NewExportService(repo, db)
-	priorityService := service.NewPriorityService(db)
-	subtaskService := service.NewSubtaskService(db)
-	calendarService := service.NewCalendarService(db)
-	escalationService := service.NewEscalationService(db)
+	exportService := service.NewExportService(repo, db, reminderService, activityService)
+	priorityService := service.NewPriorityService(db, activityService)
+	subtaskService := service.NewSubtaskService(db, activityService)
 	categoryService := service.NewCategoryService(db)
-	delegationService := service.NewDelegationService(db)
+	delegationService := service.NewDelegationService(db, activityService)
 	recurringService := service.NewRecurringService(db)
-	timezoneService := service.NewTimezoneService(db)
 	habitService := service.NewHabitService(db)
-	extended2Service := service.NewExtended2Service(db)
+	extended2Service := service.NewExtended2Service(db, activityService)
+	commandLogService := service.NewCommandLogService(db)
+	deadLetterService := service.NewDeadLetterService(db, producer)
+	accessService := service.NewAccessService(repo, db, sharingService, delegationService)
+	workspaceService := service.NewWorkspaceService(db)
+	viewService := service.NewViewService(db, accessService, searchService, workspaceService)
+	calendarService := service.NewCalendarService(db, viewService)
+	locationService := service.NewLocationService(db, reminderService, activityService)
+	parseService := service.NewParseService(timezoneService, reminderService)
+	jobService, err := service.NewJobService(repo, db, exportService, cfg.JobRetention)
+	if err != nil {
+		log.Fatalf("Failed to initialize job service: %v", err)
+	}
 
 	// ── Initialize Scheduler ──
-	reminderScheduler := scheduler.NewScheduler(reminderService, producer)
+	reminderScheduler := scheduler.NewScheduler(reminderService, cfg.InstanceID, cfg.SchedulerLease, cfg.SchedulerBatchSize)
 	go reminderScheduler.Start()
 
+	patternMaterializer := scheduler.NewPatternMaterializer(recurringService, reminderService, cfg.InstanceID, cfg.SchedulerLease, cfg.SchedulerBatchSize)
+	go patternMaterializer.Start()
+
+	escalationWorker := scheduler.NewEscalationWorker(escalationService, reminderService, cfg.InstanceID, cfg.SchedulerLease, cfg.SchedulerBatchSize)
+	go escalationWorker.Start()
+
+	renotifyWorker := scheduler.NewRenotifyWorker(reminderService, cfg.SchedulerBatchSize)
+	go renotifyWorker.Start()
+
+	jobWorker := scheduler.NewJobWorker(jobService, cfg.InstanceID, cfg.JobLease)
+	go jobWorker.Start()
+
+	outboxRelay := scheduler.NewOutboxRelay(repo, producer, cfg.InstanceID)
+	go outboxRelay.Start()
+
 	// ── Initialize Kafka Consumer ──
-	consumer, err := kafka.NewConsumer(cfg.KafkaBrokers, "reminder-service", reminderService)
+	consumer, err := kafka.NewConsumer(cfg.KafkaBrokers, "reminder-service", reminderService, commandLogService, deadLetterService, producer, kafka.RetryPolicy{
+		MaxAttempts: cfg.CommandMaxAttempts,
+		Backoff:     cfg.CommandRetryBackoff,
+	})
 	if err != nil {
 		log.Printf("Warning: Failed to connect Kafka consumer: %v", err)
 	} else {
@@ -71,23 +108,69 @@ func main() {
 		defer consumer.Close()
 	}
 
+	memberConsumer, err := kafka.NewMemberConsumer(cfg.KafkaBrokers, "reminder-service-members", workspaceService)
+	if err != nil {
+		log.Printf("Warning: Failed to connect workspace member consumer: %v", err)
+	} else {
+		go memberConsumer.Start()
+		defer memberConsumer.Close()
+	}
+
+	locationConsumer, err := kafka.NewLocationConsumer(cfg.KafkaBrokers, "reminder-service-locations", locationService)
+	if err != nil {
+		log.Printf("Warning: Failed to connect location consumer: %v", err)
+	} else {
+		go locationConsumer.Start()
+		defer locationConsumer.Close()
+	}
+
+	receiptConsumer, err := kafka.NewReceiptConsumer(cfg.KafkaBrokers, "reminder-service-receipts", reminderService)
+	if err != nil {
+		log.Printf("Warning: Failed to connect delivery receipt consumer: %v", err)
+	} else {
+		go receiptConsumer.Start()
+		defer receiptConsumer.Close()
+	}
+
+	// ── Initialize Auth ──
+	verifier, err := auth.NewVerifier(auth.Config{
+		Secret:   cfg.JWTSecret,
+		JWKSFile: cfg.JWKSFile,
+		Issuer:   cfg.JWTIssuer,
+		Audience: cfg.JWTAudience,
+		Leeway:   30 * time.Second,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize token verifier: %v", err)
+	}
+	authz := api.NewAuthorizer(accessService, workspaceService)
+
 	// ── Initialize Handlers ──
-	tagHandler := api.NewTagHandler(tagService)
+	tagHandler := api.NewTagHandler(tagService, authz)
 	templateHandler := api.NewTemplateHandler(templateService, reminderService)
 	notifHandler := api.NewNotificationHandler(notificationService)
 	sharingHandler := api.NewSharingHandler(sharingService)
 	noteHandler := api.NewNoteHandler(noteService)
-	analyticsHandler := api.NewAnalyticsHandler(analyticsService, searchService, activityService, exportService, reminderService)
+	analyticsHandler := api.NewAnalyticsHandler(analyticsService, searchService, activityService, exportService, reminderService, authz)
 	priorityHandler := api.NewPriorityHandler(priorityService)
 	subtaskHandler := api.NewSubtaskHandler(subtaskService)
 	calendarHandler := api.NewCalendarHandler(calendarService)
-	escalationHandler := api.NewEscalationHandler(escalationService)
+	escalationHandler := api.NewEscalationHandler(escalationService, authz)
 	categoryHandler := api.NewCategoryHandler(categoryService)
 	delegationHandler := api.NewDelegationHandler(delegationService)
 	recurringHandler := api.NewRecurringHandler(recurringService)
 	timezoneHandler := api.NewTimezoneHandler(timezoneService)
 	habitHandler := api.NewHabitHandler(habitService)
-	ext2Handler := api.NewExtended2Handler(extended2Service)
+	ext2Handler := api.NewExtended2Handler(extended2Service, authz)
+	adminHandler := api.NewAdminHandler(deadLetterService, searchService)
+	workspaceHandler := api.NewWorkspaceHandler(workspaceService)
+	jobHandler := api.NewJobHandler(jobService, authz)
+	viewHandler := api.NewViewHandler(viewService)
+	dependencyHandler := api.NewDependencyHandler(dependencyService, authz)
+	locationHandler := api.NewLocationHandler(locationService, authz)
+	snoozeHandler := api.NewSnoozeHandler(snoozeService, reminderService)
+	parseHandler := api.NewParseHandler(parseService)
+	deliveryHandler := api.NewDeliveryHandler(deliveryService)
 
 	// ── Setup HTTP Server ──
 	if os.Getenv("GIN_MODE") == "" {
@@ -114,6 +197,17 @@ func main() {
 		timezoneHandler,
 		habitHandler,
 		ext2Handler,
+		adminHandler,
+		workspaceHandler,
+		jobHandler,
+		viewHandler,
+		dependencyHandler,
+		locationHandler,
+		snoozeHandler,
+		parseHandler,
+		deliveryHandler,
+		verifier,
+		authz,
 	)
 
 	port := cfg.Port